@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"esim-platform/internal/qpaysim"
+)
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// qpaysim runs a local QPay v2 simulator. Point the API server at it with
+// QPAY_ENDPOINT=http://localhost:8090/v2 and the same QPAY_USERNAME / QPAY_PASSWORD.
+func main() {
+	addr := flag.String("addr", getEnv("QPAYSIM_ADDR", ":8090"), "listen address")
+	username := flag.String("username", getEnv("QPAY_USERNAME", "merchant"), "merchant username accepted by /auth/token")
+	password := flag.String("password", getEnv("QPAY_PASSWORD", "secret"), "merchant password, also used to sign callbacks")
+	callbackURL := flag.String("callback-url", getEnv("QPAY_CALLBACK_URL", "http://localhost:8080/api/v1/webhooks/qpay"), "callback URL for invoices created without one")
+	flag.Parse()
+
+	sim := qpaysim.NewServer(qpaysim.Options{
+		Username:    *username,
+		Password:    *password,
+		CallbackURL: *callbackURL,
+	})
+
+	log.Printf("QPay simulator listening on %s (admin UI at /, API at /v2)", *addr)
+	if err := http.ListenAndServe(*addr, sim); err != nil {
+		log.Fatalf("qpaysim: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"esim-platform/internal/config"
 	"esim-platform/internal/services"
@@ -15,7 +16,7 @@ func main() {
 
 	cfg := config.Load()
 	rw := services.NewRoamWiFiService(cfg.RoamWiFi)
-	raw, err := rw.GetPackagesRaw(*sku)
+	raw, err := rw.GetPackagesRaw(context.Background(), *sku)
 	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
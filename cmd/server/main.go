@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -51,6 +53,9 @@ func main() {
 
 	// Initialize configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		logrus.Fatal("Invalid configuration: ", err)
+	}
 
 	// Initialize database
 	db, err := database.InitDB(cfg.Database)
@@ -59,24 +64,38 @@ func main() {
 	}
 
 	// Initialize Redis
-	_, err = database.InitRedis(cfg.Redis)
+	redisClient, err := database.InitRedis(cfg.Redis)
 	if err != nil {
 		logrus.Fatal("Failed to connect to Redis:", err)
 	}
 
 	// Initialize services
-	roamWiFiService := services.NewRoamWiFiService(cfg.RoamWiFi)
+	esimProvider, err := services.NewESIMProvider(cfg)
+	if err != nil {
+		logrus.Fatal("Failed to initialize eSIM provider:", err)
+	}
 	qpayService := services.NewQPayService(cfg.QPay)
 	pricingService := services.NewPricingService(db)
-	orderService := services.NewOrderService(db, roamWiFiService, qpayService)
-	productService := services.NewProductService(db, roamWiFiService)
+	jobService := services.NewJobService(db, cfg.Jobs)
+	orderService := services.NewOrderService(db, esimProvider, qpayService, jobService)
+	var catalogCache *services.CatalogCache
+	if cfg.Catalog.Enabled {
+		catalogCache = services.NewCatalogCache(services.NewRedisCatalogStore(redisClient), cfg.Catalog)
+	}
+	productService := services.NewProductService(db, esimProvider, catalogCache)
 	userService := services.NewUserService(db)
+	catalogSyncer := services.NewCatalogSyncer(db, productService, cfg.Sync)
+	rateSources, err := services.NewExchangeRateSources(db, cfg.Rates)
+	if err != nil {
+		logrus.Fatal("Failed to configure exchange rate sources:", err)
+	}
+	rateUpdater := services.NewExchangeRateUpdater(db, productService, rateSources, cfg.Rates)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userService)
 	productHandler := handlers.NewProductHandler(productService)
 	orderHandler := handlers.NewOrderHandler(orderService)
-	adminHandler := handlers.NewAdminHandler(productService, orderService, userService, pricingService)
+	adminHandler := handlers.NewAdminHandler(productService, orderService, userService, pricingService, jobService, catalogSyncer, rateUpdater)
 	webhookHandler := handlers.NewWebhookHandler(orderService, qpayService)
 
 	// Setup Gin router
@@ -94,6 +113,7 @@ func main() {
 	// Middleware
 	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
+	router.Use(middleware.RequestTimeout(time.Duration(cfg.Server.RequestTimeout) * time.Second))
 
 	// Public routes
 	public := router.Group("/api/v1")
@@ -112,6 +132,7 @@ func main() {
 			products.GET("/", productHandler.GetProducts)
 			products.GET("/continents", productHandler.GetProductsByContinent)
 			products.GET("/skus", productHandler.GetSKUList)
+			products.GET("/skus/continents", productHandler.GetSKUsByContinent)
 			products.GET("/sku/:skuId", productHandler.GetSKU)
 			products.GET("/sku/:skuId/packages", productHandler.GetPackagesBySKU)
 			products.GET("/:id", productHandler.GetProduct)
@@ -170,6 +191,8 @@ func main() {
 		adminSkus := admin.Group("/skus")
 		{
 			adminSkus.POST(":skuId/packages/sync", adminHandler.SyncPackagePrices)
+			adminSkus.PUT(":skuId/margin-policy", adminHandler.UpdateSKUMarginPolicy)
+			adminSkus.DELETE(":skuId/margin-policy", adminHandler.DeleteSKUMarginPolicy)
 		}
 
 		// Order management
@@ -178,6 +201,30 @@ func main() {
 			adminOrders.GET("/", adminHandler.GetAllOrders)
 			adminOrders.GET("/:id", adminHandler.GetOrder)
 			adminOrders.PUT("/:id/status", adminHandler.UpdateOrderStatus)
+			adminOrders.GET("/:id/history", adminHandler.GetOrderStatusHistory)
+			adminOrders.POST("/:id/refund", adminHandler.RefundOrder)
+		}
+
+		// Background jobs
+		adminJobs := admin.Group("/jobs")
+		{
+			adminJobs.GET("/", adminHandler.GetJobs)
+			adminJobs.GET("/:id", adminHandler.GetJob)
+			adminJobs.POST("/:id/retry", adminHandler.RetryJob)
+		}
+
+		// Upstream provider health
+		admin.GET("/provider/status", adminHandler.GetProviderStatus)
+
+		// Catalog cache and sync runs
+		adminCatalog := admin.Group("/catalog")
+		{
+			adminCatalog.GET("/cache", adminHandler.GetCatalogCacheStats)
+			adminCatalog.DELETE("/cache", adminHandler.InvalidateCatalogCache)
+			adminCatalog.POST("/sync", adminHandler.TriggerCatalogSync)
+			adminCatalog.GET("/sync-runs", adminHandler.GetSyncRuns)
+			adminCatalog.GET("/sync-runs/:id", adminHandler.GetSyncRun)
+			adminCatalog.GET("/changes", adminHandler.GetCatalogChanges)
 		}
 
 		// User management
@@ -200,7 +247,22 @@ func main() {
 		{
 			adminPricing.GET("/info", adminHandler.GetPricingInfo)
 			adminPricing.PUT("/exchange-rate", adminHandler.UpdateExchangeRate)
+			adminPricing.POST("/exchange-rate/refresh", adminHandler.RefreshExchangeRates)
+			adminPricing.GET("/exchange-rate/history", adminHandler.GetExchangeRateHistory)
+			adminPricing.PUT("/rounding-rule", adminHandler.UpdateRoundingRule)
 			adminPricing.POST("/update-all", adminHandler.UpdateAllProductPricing)
+			adminPricing.GET("/margin-policy", adminHandler.GetMarginPolicies)
+			adminPricing.PUT("/margin-policy", adminHandler.UpdateGlobalMarginPolicy)
+			adminPricing.GET("/alerts", adminHandler.GetPricingAlerts)
+			adminPricing.POST("/alerts/:id/resolve", adminHandler.ResolvePricingAlert)
+			adminPricing.GET("/rules", adminHandler.GetPricingRules)
+			adminPricing.POST("/rules", adminHandler.CreatePricingRule)
+			adminPricing.POST("/rules/preview", adminHandler.PreviewPricingRules)
+			adminPricing.PUT("/rules/:id", adminHandler.UpdatePricingRule)
+			adminPricing.DELETE("/rules/:id", adminHandler.DeletePricingRule)
+			adminPricing.GET("/schedules", adminHandler.GetScheduledPriceChanges)
+			adminPricing.POST("/schedules", adminHandler.CreateScheduledPriceChange)
+			adminPricing.POST("/schedules/:id/cancel", adminHandler.CancelScheduledPriceChange)
 		}
 
 		// Product Pricing
@@ -228,11 +290,40 @@ func main() {
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Request and worker contexts derive from baseCtx, which is only cancelled when the
+	// shutdown deadline passes with work still in flight
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
 	// Start server
 	srv := &http.Server{
-		Addr:    ":" + cfg.Server.Port,
-		Handler: router,
+		Addr:        ":" + cfg.Server.Port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	// Background workers: eSIM provisioning/email jobs, payment reconciliation for orders
+	// whose QPay callback never arrived, expiry of abandoned orders, the scheduled
+	// catalog sync, exchange rate refreshes and scheduled price changes. Cancelling
+	// workerCtx stops them from picking up new work; a unit already started runs on
+	// a detached context and is drained below.
+	workerCtx, stopWorkers := context.WithCancel(baseCtx)
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
 	}
+	startWorker(services.NewJobWorker(jobService, orderService).Run)
+	if cfg.Reconciler.Enabled {
+		startWorker(services.NewPaymentReconciler(db, orderService, qpayService, cfg.Reconciler).Run)
+	}
+	startWorker(services.NewOrderExpirySweeper(db, orderService, cfg.Expiry).Run)
+	startWorker(catalogSyncer.Run)
+	startWorker(rateUpdater.Run)
+	startWorker(services.NewPriceScheduler(productService, cfg.Schedule).Run)
 
 	// Graceful shutdown
 	go func() {
@@ -248,12 +339,26 @@ func main() {
 	<-quit
 	logrus.Info("Shutting down server...")
 
-	// Give outstanding requests a deadline for completion
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Stop taking new work, then let outstanding requests and jobs finish within the deadline
+	stopWorkers()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logrus.Fatal("Server forced to shutdown:", err)
+		logrus.Warnf("Server forced to shutdown: %v", err)
+		cancelBase()
+		srv.Close()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		logrus.Warnf("Background work still running at shutdown deadline; abandoned jobs are re-queued after JOBS_LOCK_TIMEOUT")
 	}
 
 	logrus.Info("Server exited")
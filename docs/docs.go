@@ -76,104 +76,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/orders": {
+        "/admin/catalog/cache": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieve all orders with pagination and optional status filter (admin only)",
+                "description": "Hit, stale hit, miss, refresh and error counts of the catalog cache on this instance, by kind (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders",
                     "Admin"
                 ],
-                "summary": "Get all orders (Admin)",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Order status filter",
-                        "name": "status",
-                        "in": "query"
-                    }
-                ],
+                "summary": "Get catalog cache metrics (Admin)",
                 "responses": {
                     "200": {
-                        "description": "All orders with pagination",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Cache counters by kind; enabled=false when caching is off",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/admin/orders/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieve a specific order by its ID (admin only)",
+                "description": "Drop cached packages of one SKU, or the whole catalog cache when sku_id is omitted (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders",
                     "Admin"
                 ],
-                "summary": "Get order by ID (Admin)",
+                "summary": "Invalidate catalog cache (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "SKU ID",
+                        "name": "sku_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order information",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid order ID",
+                        "description": "Cache invalidated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "501": {
-                        "description": "Not implemented",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -182,63 +141,85 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/orders/{id}/status": {
-            "put": {
+        "/admin/catalog/changes": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update the status of a specific order (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Browse package differences found by syncs: new and removed packages, provider price changes and data/day changes, newest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders",
-                    "Admin"
+                    "Admin",
+                    "Packages"
                 ],
-                "summary": "Update order status (Admin)",
+                "summary": "List catalog changes (Admin)",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only changes found by this sync run (UUID)",
+                        "name": "sync_run_id",
+                        "in": "query"
                     },
                     {
-                        "description": "Status update",
-                        "name": "status",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Filter by SKU ID",
+                        "name": "sku_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by type (added, removed, price_changed, data_changed, days_changed)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only price changes beyond the alert threshold",
+                        "name": "flagged",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only changes of at least this many percent, up or down",
+                        "name": "min_change_percent",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Status updated",
+                        "description": "Catalog changes with pagination",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid order ID or request",
+                        "description": "Invalid filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "501": {
-                        "description": "Not implemented",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -247,17 +228,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/packages/{priceId}/markup": {
-            "put": {
+        "/admin/catalog/sync": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Set or clear markup percent (clears override)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Start a sync of every provider SKU and its package prices in the background (admin only)",
                 "produces": [
                     "application/json"
                 ],
@@ -265,42 +243,23 @@ const docTemplate = `{
                     "Admin",
                     "Packages"
                 ],
-                "summary": "Update package markup (Admin)",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Provider Price ID",
-                        "name": "priceId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Markup payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdatePackageMarkupRequest"
-                        }
-                    }
-                ],
+                "summary": "Sync the whole catalog now (Admin)",
                 "responses": {
-                    "200": {
-                        "description": "Updated",
+                    "202": {
+                        "description": "Sync run started",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.SyncRun"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "409": {
+                        "description": "A sync is already running",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Internal error",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -309,17 +268,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/packages/{priceId}/override": {
-            "put": {
+        "/admin/catalog/sync-runs": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Set or clear override price (clears markup usage)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List scheduled and manual catalog sync runs, newest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
@@ -327,42 +283,39 @@ const docTemplate = `{
                     "Admin",
                     "Packages"
                 ],
-                "summary": "Update package override price (Admin)",
+                "summary": "List catalog sync runs (Admin)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Provider Price ID",
-                        "name": "priceId",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "Override payload (null to clear)",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdatePackageOverrideRequest"
-                        }
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (running, succeeded, partial, failed)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "Sync runs with pagination",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Internal error",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -371,53 +324,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/pricing/exchange-rate": {
-            "put": {
+        "/admin/catalog/sync-runs/{id}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Manually set the USD to MNT exchange rate (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Inspect a sync run including its counters and per-SKU errors (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Pricing"
+                    "Packages"
                 ],
-                "summary": "Update exchange rate (Admin)",
+                "summary": "Get catalog sync run (Admin)",
                 "parameters": [
                     {
-                        "description": "Exchange rate",
-                        "name": "rate",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateExchangeRateRequest"
-                        }
+                        "type": "string",
+                        "description": "Sync run ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Exchange rate updated",
+                        "description": "Sync run",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.SyncRun"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid sync run ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Sync run not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -426,27 +373,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/pricing/info": {
+        "/admin/jobs": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get current pricing information including exchange rates and profit margins (admin only)",
+                "description": "List provisioning and email jobs, newest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Pricing"
+                    "Jobs"
+                ],
+                "summary": "List background jobs (Admin)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (queued, running, succeeded, dead)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by type (provision_esim, send_esim_email)",
+                        "name": "type",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get pricing information (Admin)",
                 "responses": {
                     "200": {
-                        "description": "Current pricing information",
+                        "description": "Jobs list with pagination",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PricingInfo"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -459,32 +435,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/pricing/update-all": {
-            "post": {
+        "/admin/jobs/{id}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Recalculate pricing for all products and packages (admin only)",
+                "description": "Inspect a job including its attempts and last error (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Pricing"
+                    "Jobs"
+                ],
+                "summary": "Get background job (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Update all product pricing (Admin)",
                 "responses": {
                     "200": {
-                        "description": "All pricing updated",
+                        "description": "Job",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid job ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Job not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -493,53 +484,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/products": {
+        "/admin/jobs/{id}/retry": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Create a new eSIM product (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Re-queue a dead-lettered job with a fresh set of attempts (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Products"
+                    "Jobs"
                 ],
-                "summary": "Create new product (Admin)",
+                "summary": "Retry a dead job (Admin)",
                 "parameters": [
                     {
-                        "description": "Product details",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.CreateProductRequest"
-                        }
+                        "type": "string",
+                        "description": "Job ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Product created successfully",
+                    "200": {
+                        "description": "Re-queued job",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid job ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "404": {
+                        "description": "Job not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "409": {
+                        "description": "Job is not dead",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -548,25 +540,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/products/sync": {
-            "post": {
+        "/admin/orders": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Synchronize products from RoamWiFi API (admin only)",
+                "description": "Retrieve all orders with pagination and optional status filter (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin",
-                    "Products"
+                    "Orders",
+                    "Admin"
+                ],
+                "summary": "Get all orders (Admin)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order status filter",
+                        "name": "status",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Sync products from RoamWiFi API (Admin)",
                 "responses": {
                     "200": {
-                        "description": "Products synced successfully",
+                        "description": "All orders with pagination",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -582,86 +596,76 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/products/{id}": {
-            "put": {
+        "/admin/orders/{id}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update an existing eSIM product (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve a specific order by its ID (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin",
-                    "Products"
+                    "Orders",
+                    "Admin"
                 ],
-                "summary": "Update product (Admin)",
+                "summary": "Get order by ID (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Order ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Product update details",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.UpdateProductRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product updated successfully",
+                        "description": "Order information",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID or input",
+                        "description": "Invalid order ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "501": {
+                        "description": "Not implemented",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/orders/{id}/history": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete an eSIM product (admin only)",
+                "description": "List lifecycle transitions recorded for an order, oldest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Products"
+                    "Orders"
                 ],
-                "summary": "Delete product (Admin)",
+                "summary": "Get order status history (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Order ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -669,14 +673,16 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product deleted successfully",
+                        "description": "Status history",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderStatusHistory"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid order ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -692,14 +698,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/products/{id}/price": {
-            "put": {
+        "/admin/orders/{id}/refund": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Set a manual price override for a specific product (admin only)",
+                "description": "Refund a paid order through QPay, fully or partially. A full refund moves the order to refunded (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -708,78 +714,73 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "Admin",
-                    "Pricing"
+                    "Orders"
                 ],
-                "summary": "Set product price (Admin)",
+                "summary": "Refund an order (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Order ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Product price",
-                        "name": "price",
+                        "description": "Refund amount (MNT, omit for full) and reason",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SetProductPriceRequest"
+                            "$ref": "#/definitions/handlers.RefundOrderRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product price updated",
+                        "description": "Refund issued",
+                        "schema": {
+                            "$ref": "#/definitions/services.RefundResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID, request or amount",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid product ID or input",
+                    "404": {
+                        "description": "Order not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/admin/settings": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "Retrieve admin settings (admin only)",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Admin",
-                    "Settings"
-                ],
-                "summary": "Get admin settings (Admin)",
-                "responses": {
-                    "200": {
-                        "description": "Admin settings",
+                    },
+                    "409": {
+                        "description": "Order cannot be refunded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/orders/{id}/status": {
             "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update admin settings (admin only)",
+                "description": "Move an order to a new lifecycle status; illegal transitions are rejected (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -788,30 +789,57 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "Admin",
-                    "Settings"
+                    "Orders"
                 ],
-                "summary": "Update admin settings (Admin)",
+                "summary": "Update order status (Admin)",
                 "parameters": [
                     {
-                        "description": "Settings to update",
-                        "name": "settings",
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target status and reason",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateSettingsRequest"
+                            "$ref": "#/definitions/handlers.UpdateOrderStatusRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Settings updated successfully",
+                        "description": "Updated order",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Transition not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -820,14 +848,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/skus/{skuId}/packages/sync": {
-            "post": {
+        "/admin/packages/{priceId}/markup": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Fetch provider packages and upsert pricing rows",
+                "description": "Set or clear markup percent (clears override)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
@@ -835,19 +866,42 @@ const docTemplate = `{
                     "Admin",
                     "Packages"
                 ],
-                "summary": "Sync package prices for a SKU (Admin)",
+                "summary": "Update package markup (Admin)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "SKU ID",
-                        "name": "skuId",
+                        "type": "integer",
+                        "description": "Provider Price ID",
+                        "name": "priceId",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Packages synced",
+                    },
+                    {
+                        "description": "Markup payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdatePackageMarkupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Price below the minimum margin",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -863,48 +917,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/users": {
-            "get": {
+        "/admin/packages/{priceId}/override": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieve all users with pagination (admin only)",
+                "description": "Set or clear override price (clears markup usage)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Users"
+                    "Packages"
                 ],
-                "summary": "Get all users (Admin)",
+                "summary": "Update package override price (Admin)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Provider Price ID",
+                        "name": "priceId",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Override payload (null to clear)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdatePackageOverrideRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Users list with pagination",
+                        "description": "Updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Price below the minimum margin",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -913,115 +986,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/users/{id}": {
+        "/admin/pricing/alerts": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieve a specific user by ID (admin only)",
+                "description": "Packages whose override, markup or provider price is below their minimum margin, most recent first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Users"
+                    "Packages"
                 ],
-                "summary": "Get user by ID (Admin)",
+                "summary": "List pricing alerts (Admin)",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by status (open, resolved)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by SKU ID",
+                        "name": "sku_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User information",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid user ID",
+                        "description": "Pricing alerts with pagination",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "500": {
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/pricing/alerts/{id}/resolve": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update user information (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Close an alert by hand; the next sync opens it again if the package is still below its margin (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin",
-                    "Users"
+                    "Packages"
                 ],
-                "summary": "Update user (Admin)",
+                "summary": "Resolve a pricing alert (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
+                        "description": "Alert ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "User update details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateUserRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User updated successfully",
+                        "description": "Resolved alert",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.PricingAlert"
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID or input",
+                        "description": "Invalid alert ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Alert not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1030,9 +1097,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
-            "post": {
-                "description": "Authenticate user and return JWT token",
+        "/admin/pricing/exchange-rate": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Manually set an exchange rate, USD to MNT unless from/to are given, and reprice packages at it (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1040,25 +1112,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "User login",
+                "summary": "Update exchange rate (Admin)",
                 "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "credentials",
+                        "description": "Exchange rate",
+                        "name": "rate",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
+                            "$ref": "#/definitions/handlers.UpdateExchangeRateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login successful",
+                        "description": "Exchange rate updated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1068,8 +1142,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid credentials",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1078,46 +1152,65 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Create a new user account",
-                "consumes": [
-                    "application/json"
+        "/admin/pricing/exchange-rate/history": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "List the stored MNT rates of a currency, newest first, with the number of active packages priced at each; for USD also the rate each package's MNT price was derived from (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Register a new user",
+                "summary": "Exchange rate history (Admin)",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.RegisterRequest"
-                        }
+                        "type": "string",
+                        "default": "USD",
+                        "description": "Currency quoted against MNT (USD, CNY, KRW)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rates that took effect at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rates that took effect before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of rates",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only packages of this SKU",
+                        "name": "sku_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                    "200": {
+                        "description": "Rate history and package rates",
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginResponse"
+                            "$ref": "#/definitions/services.ExchangeRateHistory"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "409": {
-                        "description": "User already exists",
+                        "description": "Invalid filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1133,47 +1226,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders": {
+        "/admin/pricing/exchange-rate/refresh": {
             "post": {
-                "description": "Create a new order for eSIM purchase",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Fetch rates from the configured sources in priority order and store the first acceptable set (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Create new eSIM order",
-                "parameters": [
-                    {
-                        "description": "Order details (include package_price_id or provider_price_id)",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
+                "summary": "Refresh exchange rates now (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "Rates stored",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateOrderRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Order created successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid input",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/services.RateRefreshResult"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "502": {
+                        "description": "No source returned acceptable rates, or repricing at them failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1182,72 +1259,110 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{orderNumber}": {
+        "/admin/pricing/info": {
             "get": {
-                "description": "Retrieve order information by order number",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get current pricing information including exchange rates, rate source alerts and profit margins (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get order by order number",
-                "parameters": [
+                "summary": "Get pricing information (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "Current pricing information",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PricingInfo"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pricing/margin-policy": {
+            "get": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Order Number",
-                        "name": "orderNumber",
-                        "in": "path",
-                        "required": true
+                        "Bearer": []
                     }
                 ],
+                "description": "Global minimum margin over the provider price and per-SKU overrides (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Packages"
+                ],
+                "summary": "Get margin policies (Admin)",
                 "responses": {
                     "200": {
-                        "description": "Order information",
+                        "description": "Global and per-SKU policies",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Order not found",
+                    "500": {
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/orders/{orderNumber}/payment": {
-            "post": {
-                "description": "Start payment process for an existing order",
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Set the minimum margin for SKUs without their own policy and re-price stored packages (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin",
+                    "Packages"
                 ],
-                "summary": "Initiate payment for order",
+                "summary": "Update global margin policy (Admin)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Order Number",
-                        "name": "orderNumber",
-                        "in": "path",
-                        "required": true
+                        "description": "Margin policy",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateMarginPolicyRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Payment initiation response",
+                        "description": "Saved policy",
+                        "schema": {
+                            "$ref": "#/definitions/models.MarginPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid policy",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1256,58 +1371,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
-            "get": {
-                "description": "Retrieve list of all available eSIM products with MNT pricing",
+        "/admin/pricing/rounding-rule": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Set how MNT prices are rounded (whole, up_\u003cn\u003e or end_\u003cn\u003e) and reprice stored packages (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get all products",
+                "summary": "Update MNT rounding rule (Admin)",
                 "parameters": [
                     {
-                        "enum": [
-                            "Asia",
-                            "Europe",
-                            "Africa",
-                            "Americas",
-                            "Oceania"
-                        ],
-                        "type": "string",
-                        "description": "Filter by continent",
-                        "name": "continent",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "true",
-                            "false"
-                        ],
-                        "type": "string",
-                        "description": "Filter by active status",
-                        "name": "active",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Number of items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Rounding rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateRoundingRuleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of products",
+                        "description": "Rounding rule updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1323,68 +1426,86 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/continents": {
+        "/admin/pricing/rules": {
             "get": {
-                "description": "Retrieve active products grouped by continent",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Pricing rules in priority order, active or not (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get products grouped by continent",
+                "summary": "List pricing rules (Admin)",
                 "responses": {
                     "200": {
-                        "description": "Products grouped by continent",
+                        "description": "Pricing rules",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ProductsByContinentResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PricingRule"
+                            }
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/products/sku/{skuId}": {
-            "get": {
-                "description": "Public: Retrieve metadata for a specific SKU",
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Add a rule marking up the provider price of matching packages by a percent or fixed USD amount, and re-price stored packages (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get single SKU info",
+                "summary": "Create pricing rule (Admin)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "SKU ID",
-                        "name": "skuId",
-                        "in": "path",
-                        "required": true
+                        "description": "Pricing rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PricingRuleRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "SKU details",
+                    "201": {
+                        "description": "Created rule",
                         "schema": {
-                            "$ref": "#/definitions/services.SKUInfo"
+                            "$ref": "#/definitions/models.PricingRule"
                         }
                     },
-                    "404": {
-                        "description": "SKU not found",
+                    "400": {
+                        "description": "Invalid rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to retrieve SKU",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1393,92 +1514,159 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/sku/{skuId}/packages": {
-            "get": {
-                "description": "Retrieve available data packages (plans) for a specific product SKU (day passes, data/validity variants)",
+        "/admin/pricing/rules/preview": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Show which rule wins for each package and the price it would get, next to its current price, without saving anything. An optional rule in the body is previewed as a new rule, or as a change to rule_id (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get packages for a SKU",
+                "summary": "Preview pricing rules (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "SKU ID",
-                        "name": "skuId",
-                        "in": "path",
-                        "required": true
+                        "description": "Only packages of this SKU",
+                        "name": "sku_id",
+                        "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "If true returns detailed provider package structure",
-                        "name": "detailed",
-                        "in": "query"
+                        "description": "Draft rule",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PreviewPricingRulesRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Detailed packages with pricing when detailed=true",
+                        "description": "Per-package preview and the number of changed prices",
                         "schema": {
-                            "$ref": "#/definitions/services.EnrichedRoamWiFiPackagesResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Invalid rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/products/skus": {
-            "get": {
-                "description": "Public: Retrieve the raw list of available eSIM SKUs from provider (for client SKU selection)",
-                "produces": [
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pricing/rules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replace a rule's conditions, priority and add-on, and re-price stored packages (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
+                ],
+                "summary": "Update pricing rule (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pricing rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PricingRuleRequest"
+                        }
+                    }
                 ],
-                "summary": "Get available SKUs",
                 "responses": {
                     "200": {
-                        "description": "List of SKUs",
+                        "description": "Saved rule",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/services.SKUInfo"
-                            }
+                            "$ref": "#/definitions/models.PricingRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to retrieve SKUs",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/products/{id}": {
-            "get": {
-                "description": "Retrieve a single product by its UUID",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove a rule and re-price the packages it priced (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get product by ID",
+                "summary": "Delete pricing rule (Admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Rule ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1486,20 +1674,28 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product details",
+                        "description": "Deleted",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ProductResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid rule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1508,21 +1704,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/orders": {
+        "/admin/pricing/schedules": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieve orders for the authenticated user with pagination",
+                "description": "Scheduled, running and finished price changes and sales, latest start first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Get user orders",
+                "summary": "List scheduled price changes (Admin)",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1537,32 +1734,83 @@ const docTemplate = `{
                         "description": "Items per page",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (scheduled, active, ended, cancelled)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User orders with pagination",
+                        "description": "Scheduled price changes with pagination",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Schedule an override or markup for a package, SKU or pricing rule between starts_at and ends_at. It is applied and reverted automatically, and storefront listings show the regular price as the \"was\" price while it runs (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Pricing"
+                ],
+                "summary": "Schedule a price change (Admin)",
+                "parameters": [
+                    {
+                        "description": "Scheduled price change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ScheduledPriceChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Scheduled change",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledPriceChange"
+                        }
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid change",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Authentication required",
+                    "422": {
+                        "description": "Override below the package's minimum margin",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1571,55 +1819,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/webhooks/qpay": {
+        "/admin/pricing/schedules/{id}/cancel": {
             "post": {
-                "description": "Process QPay webhook notifications for payment status updates",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Stop a change before or while it runs; a running sale is reverted right away (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Webhooks"
+                    "Admin",
+                    "Pricing"
                 ],
-                "summary": "Handle QPay webhook",
+                "summary": "Cancel a scheduled price change (Admin)",
                 "parameters": [
                     {
-                        "description": "QPay webhook data",
-                        "name": "webhook",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                        "type": "string",
+                        "description": "Scheduled change ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Webhook processed successfully",
+                        "description": "Cancelled change",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledPriceChange"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid webhook data",
+                    "404": {
+                        "description": "Change not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid webhook signature",
+                    "409": {
+                        "description": "Change already ended or cancelled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to process webhook",
+                        "description": "Internal error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1628,9 +1882,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/webhooks/roamwifi": {
+        "/admin/pricing/update-all": {
             "post": {
-                "description": "Process RoamWiFi webhook notifications (not implemented)",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Recalculate pricing for all products and packages (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Pricing"
+                ],
+                "summary": "Update all product pricing (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "All pricing updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/products": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a new eSIM product (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1638,31 +1931,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Webhooks"
+                    "Admin",
+                    "Products"
                 ],
-                "summary": "Handle RoamWiFi webhook",
+                "summary": "Create new product (Admin)",
                 "parameters": [
                     {
-                        "description": "RoamWiFi webhook data",
-                        "name": "webhook",
+                        "description": "Product details",
+                        "name": "product",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/services.CreateProductRequest"
                         }
                     }
                 ],
                 "responses": {
+                    "201": {
+                        "description": "Product created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
-                        "description": "Invalid webhook data",
+                        "description": "Invalid input",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "501": {
-                        "description": "Not implemented",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1670,107 +1970,2432 @@ const docTemplate = `{
                     }
                 }
             }
-        }
+        },
+        "/admin/products/sync": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Synchronize products from RoamWiFi API (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Products"
+                ],
+                "summary": "Sync products from RoamWiFi API (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "Products synced successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/products/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update an existing eSIM product (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Products"
+                ],
+                "summary": "Update product (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product update details",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete an eSIM product (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Products"
+                ],
+                "summary": "Delete product (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/products/{id}/price": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Set a manual price override for a specific product (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Pricing"
+                ],
+                "summary": "Set product price (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product price",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetProductPriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product price updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/provider/status": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Report the active eSIM provider and the health of its login session (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get eSIM provider status (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "Provider is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/services.ProviderStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Provider session is unhealthy",
+                        "schema": {
+                            "$ref": "#/definitions/services.ProviderStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/settings": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieve admin settings (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Settings"
+                ],
+                "summary": "Get admin settings (Admin)",
+                "responses": {
+                    "200": {
+                        "description": "Admin settings",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update admin settings (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Settings"
+                ],
+                "summary": "Update admin settings (Admin)",
+                "parameters": [
+                    {
+                        "description": "Settings to update",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Settings updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/skus/{skuId}/margin-policy": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Set the minimum margin of one SKU, overriding the global policy, and re-price its packages (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Packages"
+                ],
+                "summary": "Update SKU margin policy (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU ID",
+                        "name": "skuId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Margin policy",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateMarginPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved policy",
+                        "schema": {
+                            "$ref": "#/definitions/models.MarginPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove a SKU's own policy so the global policy applies, and re-price its packages (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Packages"
+                ],
+                "summary": "Delete SKU margin policy (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU ID",
+                        "name": "skuId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No policy for this SKU",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/skus/{skuId}/packages/sync": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetch provider packages and upsert pricing rows",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Packages"
+                ],
+                "summary": "Sync package prices for a SKU (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU ID",
+                        "name": "skuId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Packages synced, with added/changed/deactivated counts and the catalog changes found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieve all users with pagination (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Users"
+                ],
+                "summary": "Get all users (Admin)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users list with pagination",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieve a specific user by ID (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Users"
+                ],
+                "summary": "Get user by ID (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User information",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update user information (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin",
+                    "Users"
+                ],
+                "summary": "Update user (Admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID or input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user and return JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login successful",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Create a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "post": {
+                "description": "Create a new order for eSIM purchase",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Create new eSIM order",
+                "parameters": [
+                    {
+                        "description": "Order details (include package_price_id or provider_price_id)",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Order created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Package not available for sale",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "eSIM provider or QPay unavailable, or no exchange rate",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{orderNumber}": {
+            "get": {
+                "description": "Retrieve order information by order number",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get order by order number",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order Number",
+                        "name": "orderNumber",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order information",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{orderNumber}/payment": {
+            "post": {
+                "description": "Start payment process for an existing order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Initiate payment for order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order Number",
+                        "name": "orderNumber",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment initiation response",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Order expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "QPay unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Retrieve list of all available eSIM products with MNT pricing, shown in the requested currency",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get all products",
+                "parameters": [
+                    {
+                        "enum": [
+                            "Asia",
+                            "Europe",
+                            "Africa",
+                            "Americas",
+                            "Oceania"
+                        ],
+                        "type": "string",
+                        "description": "Filter by continent",
+                        "name": "continent",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "true",
+                            "false"
+                        ],
+                        "type": "string",
+                        "description": "Filter by active status",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "MNT",
+                            "USD",
+                            "CNY",
+                            "KRW"
+                        ],
+                        "type": "string",
+                        "description": "Display currency (default from Accept-Language, else MNT)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Picks the display currency when currency is omitted",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of products",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported currency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/continents": {
+            "get": {
+                "description": "Retrieve active products grouped by continent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get products grouped by continent",
+                "parameters": [
+                    {
+                        "enum": [
+                            "MNT",
+                            "USD",
+                            "CNY",
+                            "KRW"
+                        ],
+                        "type": "string",
+                        "description": "Display currency (default from Accept-Language, else MNT)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Picks the display currency when currency is omitted",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Products grouped by continent",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductsByContinentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported currency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/sku/{skuId}": {
+            "get": {
+                "description": "Public: Retrieve metadata for a specific SKU",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get single SKU info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU ID",
+                        "name": "skuId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SKU details",
+                        "schema": {
+                            "$ref": "#/definitions/services.SKUInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "SKU not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to retrieve SKU",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/sku/{skuId}/packages": {
+            "get": {
+                "description": "Retrieve available data packages (plans) for a specific product SKU (day passes, data/validity variants)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get packages for a SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU ID",
+                        "name": "skuId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If true returns detailed provider package structure",
+                        "name": "detailed",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "MNT",
+                            "USD",
+                            "CNY",
+                            "KRW"
+                        ],
+                        "type": "string",
+                        "description": "Display currency of detailed package prices (default from Accept-Language, else MNT)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Picks the display currency when currency is omitted",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detailed packages with pricing when detailed=true",
+                        "schema": {
+                            "$ref": "#/definitions/services.EnrichedRoamWiFiPackagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported currency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable and no cached packages",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/skus": {
+            "get": {
+                "description": "Public: Retrieve the raw list of available eSIM SKUs from provider (for client SKU selection)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get available SKUs",
+                "responses": {
+                    "200": {
+                        "description": "List of SKUs",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.SKUInfo"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to retrieve SKUs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/skus/continents": {
+            "get": {
+                "description": "Public: Retrieve the provider SKU list grouped by continent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get SKUs grouped by continent",
+                "responses": {
+                    "200": {
+                        "description": "List of SKUs",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.SKUInfo"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to retrieve SKUs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "description": "Retrieve a single product by its UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "MNT",
+                            "USD",
+                            "CNY",
+                            "KRW"
+                        ],
+                        "type": "string",
+                        "description": "Display currency (default from Accept-Language, else MNT)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Picks the display currency when currency is omitted",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product details",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or unsupported currency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieve orders for the authenticated user with pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get user orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User orders with pagination",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/qpay": {
+            "post": {
+                "description": "Process QPay webhook notifications for payment status updates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Handle QPay webhook",
+                "parameters": [
+                    {
+                        "description": "QPay webhook data",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook processed successfully (or already processed)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid webhook signature",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Payment not confirmed by QPay",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process webhook",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/roamwifi": {
+            "post": {
+                "description": "Process RoamWiFi webhook notifications (not implemented)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Handle RoamWiFi webhook",
+                "parameters": [
+                    {
+                        "description": "RoamWiFi webhook data",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Invalid webhook data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "501": {
+                        "description": "Not implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
     },
     "definitions": {
         "handlers.CreateOrderRequest": {
             "type": "object",
             "required": [
-                "customer_email",
-                "product_id"
+                "customer_email",
+                "product_id"
+            ],
+            "properties": {
+                "customer_email": {
+                    "type": "string"
+                },
+                "customer_phone": {
+                    "type": "string"
+                },
+                "package_price_id": {
+                    "description": "One of PackagePriceID (internal) or ProviderPriceID (upstream price_id) must be supplied to select package pricing",
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "provider_price_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "handlers.PreviewPricingRulesRequest": {
+            "type": "object",
+            "properties": {
+                "rule": {
+                    "$ref": "#/definitions/handlers.PricingRuleRequest"
+                },
+                "rule_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PricingInfo": {
+            "type": "object",
+            "properties": {
+                "current_exchange_rate": {
+                    "description": "CurrentExchangeRate is zero when no USD to MNT rate is available; RateStatus says why",
+                    "type": "number"
+                },
+                "default_profit_margin": {
+                    "type": "number"
+                },
+                "display_rates": {
+                    "description": "DisplayRates are the MNT rates storefront prices are shown at, per display currency",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ExchangeRate"
+                    }
+                },
+                "last_updated": {
+                    "type": "string"
+                },
+                "rate_status": {
+                    "description": "RateStatus reports the rate source in use and alerts when it is stale or a fallback",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.ExchangeRateStatus"
+                        }
+                    ]
+                },
+                "rounding_rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PricingRuleRequest": {
+            "type": "object",
+            "required": [
+                "add_on_type",
+                "name"
+            ],
+            "properties": {
+                "active": {
+                    "description": "default true",
+                    "type": "boolean"
+                },
+                "add_on": {
+                    "type": "number"
+                },
+                "add_on_type": {
+                    "description": "percent or fixed (USD)",
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "max_data_mb": {
+                    "type": "number"
+                },
+                "max_days": {
+                    "type": "integer"
+                },
+                "max_provider_price_usd": {
+                    "type": "number"
+                },
+                "min_data_mb": {
+                    "type": "number"
+                },
+                "min_days": {
+                    "type": "integer"
+                },
+                "min_provider_price_usd": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "lower wins",
+                    "type": "integer"
+                },
+                "sku_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ProductAnalyticsResponse": {
+            "type": "object",
+            "properties": {
+                "active_products": {
+                    "type": "integer"
+                },
+                "inactive_products": {
+                    "type": "integer"
+                },
+                "top_selling_products": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "total_products": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "base_price": {
+                    "type": "number"
+                },
+                "charge_currency": {
+                    "description": "orders are always paid in MNT",
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "custom_price_usd": {
+                    "type": "number"
+                },
+                "data_limit": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display_price": {
+                    "description": "in Currency",
+                    "type": "number"
+                },
+                "display_rate": {
+                    "description": "DisplayRate is the MNT per Currency rate DisplayPrice was converted at; omitted for MNT",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.ExchangeRate"
+                        }
+                    ]
+                },
+                "exchange_rate": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_synced_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price_mnt": {
+                    "type": "number"
+                },
+                "profit_margin": {
+                    "type": "number"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "validity_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ProductsByContinentResponse": {
+            "type": "object",
+            "properties": {
+                "Africa": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                },
+                "Asia": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                },
+                "Europe": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                },
+                "Global": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                },
+                "North America": {
+                    "description": "matches inferred continent value",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                },
+                "Oceania": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                }
+            }
+        },
+        "handlers.RefundOrderRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "whole MNT; omit for a full refund",
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SalesAnalyticsResponse": {
+            "type": "object",
+            "properties": {
+                "average_order_value": {
+                    "description": "MNT",
+                    "type": "number"
+                },
+                "completed_orders": {
+                    "type": "integer"
+                },
+                "failed_orders": {
+                    "type": "integer"
+                },
+                "pending_orders": {
+                    "type": "integer"
+                },
+                "total_orders": {
+                    "type": "integer"
+                },
+                "total_sales": {
+                    "description": "MNT",
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.ScheduledPriceChangeRequest": {
+            "type": "object",
+            "required": [
+                "ends_at",
+                "name",
+                "starts_at"
+            ],
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "markup_percent": {
+                    "description": "over the provider price",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "override_price_usd": {
+                    "type": "number"
+                },
+                "package_price_id": {
+                    "type": "string"
+                },
+                "pricing_rule_id": {
+                    "type": "string"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SetProductPriceRequest": {
+            "type": "object",
+            "required": [
+                "price"
+            ],
+            "properties": {
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.UpdateExchangeRateRequest": {
+            "type": "object",
+            "required": [
+                "rate"
+            ],
+            "properties": {
+                "from": {
+                    "description": "default USD",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "to": {
+                    "description": "default MNT",
+                    "type": "string",
+                    "example": "MNT"
+                }
+            }
+        },
+        "handlers.UpdateMarginPolicyRequest": {
+            "type": "object",
+            "required": [
+                "min_margin_percent"
+            ],
+            "properties": {
+                "action": {
+                    "description": "reprice (default) or deactivate",
+                    "type": "string"
+                },
+                "min_margin_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.UpdateOrderStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UpdatePackageMarkupRequest": {
+            "type": "object",
+            "properties": {
+                "markup_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.UpdatePackageOverrideRequest": {
+            "type": "object",
+            "properties": {
+                "override_price_usd": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.UpdateRoundingRuleRequest": {
+            "type": "object",
+            "required": [
+                "rule"
             ],
             "properties": {
-                "custom_price_usd": {
+                "rule": {
+                    "type": "string",
+                    "example": "end_900"
+                }
+            }
+        },
+        "handlers.UpdateSettingsRequest": {
+            "type": "object",
+            "required": [
+                "settings"
+            ],
+            "properties": {
+                "settings": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "first_name": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "locked_at": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "run_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MarginPolicy": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "min_margin_percent": {
+                    "type": "number"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "customer_email": {
+                    "type": "string"
+                },
+                "customer_phone": {
+                    "type": "string"
+                },
+                "esim_data": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "end of the payment window and price lock",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "package_price": {
+                    "$ref": "#/definitions/models.PackagePrice"
+                },
+                "package_price_id": {
+                    "description": "Package pricing (new)",
+                    "type": "string"
+                },
+                "payment_transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PaymentTransaction"
+                    }
+                },
+                "product": {
+                    "$ref": "#/definitions/models.Product"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "provider_price_id": {
+                    "type": "integer"
+                },
+                "provision_attempt": {
+                    "description": "RoamWiFi order sent, outcome not yet known",
+                    "type": "string"
+                },
+                "qpay_invoice_id": {
+                    "type": "string"
+                },
+                "roamwifi_order_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "awaiting_payment",
+                "paid",
+                "provisioning",
+                "completed",
+                "provisioning_failed",
+                "needs_attention",
+                "refunded",
+                "cancelled",
+                "expired"
+            ],
+            "x-enum-comments": {
+                "OrderStatusNeedsAttention": "provider outcome unknown; never refunded automatically"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "",
+                "",
+                "provider outcome unknown; never refunded automatically",
+                "",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "OrderStatusPending",
+                "OrderStatusAwaitingPayment",
+                "OrderStatusPaid",
+                "OrderStatusProvisioning",
+                "OrderStatusCompleted",
+                "OrderStatusProvisioningFailed",
+                "OrderStatusNeedsAttention",
+                "OrderStatusRefunded",
+                "OrderStatusCancelled",
+                "OrderStatusExpired"
+            ]
+        },
+        "models.OrderStatusHistory": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "system|qpay_webhook|admin:\u003cuser_id\u003e",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "to_status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
+        "models.PackagePrice": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "api_code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "days": {
+                    "type": "integer"
+                },
+                "effective_price_mnt": {
+                    "type": "number"
+                },
+                "effective_price_usd": {
+                    "type": "number"
+                },
+                "exchange_rate": {
+                    "type": "number"
+                },
+                "exchange_rate_id": {
+                    "type": "string"
+                },
+                "flows": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "inactive_reason": {
+                    "description": "removed|margin",
+                    "type": "string"
+                },
+                "last_synced_at": {
+                    "type": "string"
+                },
+                "markup_percent": {
+                    "type": "number"
+                },
+                "override_price_usd": {
+                    "type": "number"
+                },
+                "price_source": {
+                    "description": "base|rule|markup|override|margin_floor|sale",
+                    "type": "string"
+                },
+                "pricing_rule_id": {
+                    "description": "rule that priced it when price_source is rule",
+                    "type": "string"
+                },
+                "provider_price_id": {
+                    "type": "integer"
+                },
+                "raw_provider_price": {
+                    "type": "number"
+                },
+                "regular_price_mnt": {
+                    "type": "number"
+                },
+                "regular_price_usd": {
+                    "description": "While a scheduled price change is running, the regular prices it replaced",
+                    "type": "number"
+                },
+                "rounding_rule": {
+                    "description": "MNT rounding applied to EffectivePriceMNT",
+                    "type": "string"
+                },
+                "scheduled_change_id": {
+                    "type": "string"
+                },
+                "show_name": {
+                    "type": "string"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PaymentTransaction": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "MNT, negative for refunds",
                     "type": "number"
                 },
-                "customer_email": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "order_id": {
                     "type": "string"
                 },
-                "customer_phone": {
+                "payment_method": {
                     "type": "string"
                 },
-                "package_price_id": {
-                    "description": "One of PackagePriceID (internal) or ProviderPriceID (upstream price_id) must be supplied to select package pricing",
+                "qpay_transaction_id": {
                     "type": "string"
                 },
-                "product_id": {
+                "status": {
                     "type": "string"
                 },
-                "provider_price_id": {
-                    "type": "integer"
+                "transaction_data": {
+                    "type": "string"
                 },
-                "user_id": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "handlers.LoginRequest": {
+        "models.PricingAlert": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "action": {
+                    "description": "repriced|deactivated",
                     "type": "string"
                 },
-                "password": {
+                "api_code": {
                     "type": "string"
-                }
-            }
-        },
-        "handlers.LoginResponse": {
-            "type": "object",
-            "properties": {
-                "refresh_token": {
+                },
+                "configured_price_usd": {
+                    "type": "number"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "token": {
+                "id": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
-                }
-            }
-        },
-        "handlers.PricingInfo": {
-            "type": "object",
-            "properties": {
-                "current_exchange_rate": {
+                "margin_percent": {
+                    "description": "margin of the configured price",
                     "type": "number"
                 },
-                "default_profit_margin": {
+                "min_margin_percent": {
                     "type": "number"
                 },
-                "last_updated": {
+                "min_price_usd": {
+                    "type": "number"
+                },
+                "occurrences": {
+                    "description": "evaluations that found the violation",
+                    "type": "integer"
+                },
+                "provider_price_id": {
+                    "type": "integer"
+                },
+                "provider_price_usd": {
+                    "type": "number"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "description": "sync|admin_edit|policy_change|admin:\u003cuser_id\u003e",
+                    "type": "string"
+                },
+                "show_name": {
+                    "type": "string"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "handlers.ProductAnalyticsResponse": {
+        "models.PricingRule": {
             "type": "object",
             "properties": {
-                "active_products": {
+                "active": {
+                    "type": "boolean"
+                },
+                "add_on": {
+                    "type": "number"
+                },
+                "add_on_type": {
+                    "description": "percent|fixed",
+                    "type": "string"
+                },
+                "continent": {
+                    "description": "continent of the SKU's product, e.g. \"Asia\"",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_data_mb": {
+                    "type": "number"
+                },
+                "max_days": {
                     "type": "integer"
                 },
-                "inactive_products": {
+                "max_provider_price_usd": {
+                    "type": "number"
+                },
+                "min_data_mb": {
+                    "type": "number"
+                },
+                "min_days": {
                     "type": "integer"
                 },
-                "top_selling_products": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "additionalProperties": true
-                    }
+                "min_provider_price_usd": {
+                    "type": "number"
                 },
-                "total_products": {
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
                     "type": "integer"
+                },
+                "sku_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.ProductResponse": {
+        "models.Product": {
             "type": "object",
             "properties": {
+                "admin_price_override": {
+                    "description": "Manual price override by admin",
+                    "type": "number"
+                },
                 "base_price": {
                     "type": "number"
                 },
@@ -1786,10 +4411,8 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
-                "currency": {
-                    "type": "string"
-                },
                 "custom_price_usd": {
+                    "description": "CustomPriceUSD optional product-level USD override used for display if set",
                     "type": "number"
                 },
                 "data_limit": {
@@ -1798,10 +4421,8 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
-                "display_price": {
-                    "type": "number"
-                },
                 "exchange_rate": {
+                    "description": "USD to MNT exchange rate used",
                     "type": "number"
                 },
                 "id": {
@@ -1811,15 +4432,18 @@ const docTemplate = `{
                     "type": "boolean"
                 },
                 "last_synced_at": {
+                    "description": "When last synced from RoamWiFi",
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
                 "price_mnt": {
+                    "description": "Price in Mongolian Tugrik",
                     "type": "number"
                 },
                 "profit_margin": {
+                    "description": "Profit margin percentage",
                     "type": "number"
                 },
                 "sku_id": {
@@ -1833,161 +4457,116 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.ProductsByContinentResponse": {
+        "models.ScheduledPriceChange": {
             "type": "object",
             "properties": {
-                "Africa": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
+                "applied_at": {
+                    "type": "string"
                 },
-                "Asia": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
+                "created_at": {
+                    "type": "string"
                 },
-                "Europe": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
+                "created_by": {
+                    "type": "string"
                 },
-                "Global": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
+                "ended_at": {
+                    "description": "reverted at the end or on cancellation",
+                    "type": "string"
                 },
-                "North America": {
-                    "description": "matches inferred continent value",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
+                "ends_at": {
+                    "type": "string"
                 },
-                "Oceania": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.ProductResponse"
-                    }
-                }
-            }
-        },
-        "handlers.RegisterRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
-                "email": {
+                "id": {
                     "type": "string"
                 },
-                "first_name": {
+                "markup_percent": {
+                    "type": "number"
+                },
+                "name": {
                     "type": "string"
                 },
-                "last_name": {
+                "override_price_usd": {
+                    "type": "number"
+                },
+                "package_price_id": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "pricing_rule_id": {
+                    "type": "string"
                 },
-                "phone": {
+                "sku_id": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "handlers.SalesAnalyticsResponse": {
+        "models.SyncRun": {
             "type": "object",
             "properties": {
-                "average_order_value": {
-                    "type": "number"
-                },
-                "completed_orders": {
-                    "type": "integer"
-                },
-                "failed_orders": {
+                "changes": {
+                    "description": "catalog_changes rows recorded",
                     "type": "integer"
                 },
-                "pending_orders": {
+                "changes_flagged": {
+                    "description": "of which beyond the price change threshold",
                     "type": "integer"
                 },
-                "total_orders": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "total_sales": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.SetProductPriceRequest": {
-            "type": "object",
-            "required": [
-                "price"
-            ],
-            "properties": {
-                "price": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.UpdateExchangeRateRequest": {
-            "type": "object",
-            "required": [
-                "rate"
-            ],
-            "properties": {
-                "rate": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.UpdatePackageMarkupRequest": {
-            "type": "object",
-            "properties": {
-                "markup_percent": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.UpdatePackageOverrideRequest": {
-            "type": "object",
-            "properties": {
-                "override_price_usd": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.UpdateSettingsRequest": {
-            "type": "object",
-            "required": [
-                "settings"
-            ],
-            "properties": {
-                "settings": {
-                    "type": "object",
-                    "additionalProperties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
                         "type": "string"
                     }
-                }
-            }
-        },
-        "handlers.UpdateUserRequest": {
-            "type": "object",
-            "properties": {
-                "first_name": {
+                },
+                "finished_at": {
                     "type": "string"
                 },
-                "is_admin": {
-                    "type": "boolean"
+                "id": {
+                    "type": "string"
                 },
-                "last_name": {
+                "packages_added": {
+                    "type": "integer"
+                },
+                "packages_changed": {
+                    "type": "integer"
+                },
+                "packages_deactivated": {
+                    "type": "integer"
+                },
+                "pricing_alerts": {
+                    "description": "packages below their minimum margin",
+                    "type": "integer"
+                },
+                "skus_failed": {
+                    "type": "integer"
+                },
+                "skus_synced": {
+                    "type": "integer"
+                },
+                "skus_total": {
+                    "type": "integer"
+                },
+                "started_at": {
                     "type": "string"
                 },
-                "phone": {
+                "status": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "description": "schedule|admin:\u003cuser_id\u003e",
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
@@ -2021,6 +4600,47 @@ const docTemplate = `{
                 }
             }
         },
+        "money.Currency": {
+            "type": "string",
+            "enum": [
+                "USD",
+                "MNT",
+                "CNY",
+                "KRW"
+            ],
+            "x-enum-varnames": [
+                "USD",
+                "MNT",
+                "CNY",
+                "KRW"
+            ]
+        },
+        "services.CircuitStatus": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "last_failure": {
+                    "type": "string"
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "rejected_calls": {
+                    "type": "integer"
+                },
+                "retry_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "times_opened": {
+                    "type": "integer"
+                }
+            }
+        },
         "services.CreateProductRequest": {
             "type": "object",
             "required": [
@@ -2070,6 +4690,13 @@ const docTemplate = `{
                 "days": {
                     "type": "integer"
                 },
+                "display_original_price": {
+                    "type": "number"
+                },
+                "display_price": {
+                    "description": "DisplayPrice and DisplayOriginalPrice are the MNT prices converted to the response's\ndisplay currency",
+                    "type": "number"
+                },
                 "effective_price_mnt": {
                     "type": "number"
                 },
@@ -2113,6 +4740,13 @@ const docTemplate = `{
                 "open_card_fee": {
                     "type": "number"
                 },
+                "original_price_mnt": {
+                    "type": "number"
+                },
+                "original_price_usd": {
+                    "description": "During a sale, the regular \"was\" prices and when the sale ends",
+                    "type": "number"
+                },
                 "overlay": {
                     "type": "integer"
                 },
@@ -2134,6 +4768,9 @@ const docTemplate = `{
                 "price_source": {
                     "type": "string"
                 },
+                "sale_ends_at": {
+                    "type": "string"
+                },
                 "show_name": {
                     "type": "string"
                 },
@@ -2154,6 +4791,9 @@ const docTemplate = `{
         "services.EnrichedRoamWiFiPackagesResponse": {
             "type": "object",
             "properties": {
+                "charge_currency": {
+                    "$ref": "#/definitions/money.Currency"
+                },
                 "country_code": {
                     "type": "string"
                 },
@@ -2163,12 +4803,23 @@ const docTemplate = `{
                         "$ref": "#/definitions/services.RoamWiFiCountryImage"
                     }
                 },
+                "currency": {
+                    "description": "Prices are charged in ChargeCurrency; DisplayPrice is shown in Currency at DisplayRate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/money.Currency"
+                        }
+                    ]
+                },
                 "display": {
                     "type": "string"
                 },
                 "display_en": {
                     "type": "string"
                 },
+                "display_rate": {
+                    "$ref": "#/definitions/services.ExchangeRate"
+                },
                 "image_url": {
                     "type": "string"
                 },
@@ -2189,6 +4840,84 @@ const docTemplate = `{
                 }
             }
         },
+        "services.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "from": {
+                    "$ref": "#/definitions/money.Currency"
+                },
+                "id": {
+                    "description": "stored rate it was read from; unset for cross rates",
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "source": {
+                    "description": "bom|json|manual, joined with \"/\" for cross rates",
+                    "type": "string"
+                },
+                "to": {
+                    "$ref": "#/definitions/money.Currency"
+                }
+            }
+        },
+        "services.ExchangeRateHistory": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "$ref": "#/definitions/money.Currency"
+                },
+                "current": {
+                    "$ref": "#/definitions/services.ExchangeRate"
+                },
+                "packages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.PackageRate"
+                    }
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.RateHistoryEntry"
+                    }
+                }
+            }
+        },
+        "services.ExchangeRateStatus": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "current": {
+                    "$ref": "#/definitions/services.ExchangeRate"
+                },
+                "fallback": {
+                    "type": "boolean"
+                },
+                "last_refresh": {
+                    "$ref": "#/definitions/services.RateRefreshResult"
+                },
+                "sources": {
+                    "description": "priority order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stale": {
+                    "type": "boolean"
+                }
+            }
+        },
         "services.PackageInfo": {
             "type": "object",
             "properties": {
@@ -2212,6 +4941,172 @@ const docTemplate = `{
                 }
             }
         },
+        "services.PackageRate": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "priced at the rate in use now",
+                    "type": "boolean"
+                },
+                "effective_price_mnt": {
+                    "type": "number"
+                },
+                "effective_price_usd": {
+                    "type": "number"
+                },
+                "exchange_rate": {
+                    "type": "number"
+                },
+                "package_price_id": {
+                    "type": "string"
+                },
+                "provider_price_id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "description": "stored rate used, unknown for prices set before rates were tracked",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.ExchangeRate"
+                        }
+                    ]
+                },
+                "rounding_rule": {
+                    "type": "string"
+                },
+                "show_name": {
+                    "type": "string"
+                },
+                "sku_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ProviderStatus": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "circuit": {
+                    "$ref": "#/definitions/services.CircuitStatus"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "session": {
+                    "$ref": "#/definitions/services.RoamWiFiSessionStatus"
+                }
+            }
+        },
+        "services.RateHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "from": {
+                    "$ref": "#/definitions/money.Currency"
+                },
+                "id": {
+                    "description": "stored rate it was read from; unset for cross rates",
+                    "type": "string"
+                },
+                "packages": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "source": {
+                    "description": "bom|json|manual, joined with \"/\" for cross rates",
+                    "type": "string"
+                },
+                "to": {
+                    "$ref": "#/definitions/money.Currency"
+                }
+            }
+        },
+        "services.RateRefreshResult": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "packages_repriced": {
+                    "type": "integer"
+                },
+                "rates": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.RejectedRate"
+                    }
+                },
+                "source": {
+                    "description": "empty when no source succeeded",
+                    "type": "string"
+                }
+            }
+        },
+        "services.RefundResult": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "refund": {
+                    "$ref": "#/definitions/models.PaymentTransaction"
+                },
+                "refunded_mnt": {
+                    "description": "total refunded so far",
+                    "type": "number"
+                },
+                "remaining_mnt": {
+                    "description": "still refundable",
+                    "type": "number"
+                }
+            }
+        },
+        "services.RejectedRate": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "$ref": "#/definitions/money.Currency"
+                },
+                "deviation_percent": {
+                    "type": "number"
+                },
+                "last_rate": {
+                    "description": "last known rate it was compared to",
+                    "type": "number"
+                },
+                "rate": {
+                    "description": "MNT per unit of Currency",
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
         "services.RoamWiFiCountryImage": {
             "type": "object",
             "properties": {
@@ -2246,6 +5141,38 @@ const docTemplate = `{
                 }
             }
         },
+        "services.RoamWiFiSessionStatus": {
+            "type": "object",
+            "properties": {
+                "authenticated": {
+                    "type": "boolean"
+                },
+                "invalidations": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_error_at": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "login_count": {
+                    "type": "integer"
+                },
+                "login_failures": {
+                    "type": "integer"
+                },
+                "login_in_flight": {
+                    "type": "boolean"
+                },
+                "token_expires_at": {
+                    "type": "string"
+                }
+            }
+        },
         "services.SKUInfo": {
             "type": "object",
             "properties": {
@@ -26,8 +26,8 @@ CREATE TABLE IF NOT EXISTS products (
     validity_days INTEGER,
     countries TEXT[], -- Array of country codes
     continent VARCHAR(50),
-    base_price DECIMAL(10,2) NOT NULL,
-    custom_price DECIMAL(10,2),
+    base_price NUMERIC(18,6) NOT NULL,
+    custom_price NUMERIC(18,6),
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -40,8 +40,8 @@ CREATE TABLE IF NOT EXISTS orders (
     product_id UUID REFERENCES products(id),
     order_number VARCHAR(100) UNIQUE NOT NULL,
     qpay_invoice_id VARCHAR(100),
-    status VARCHAR(50) DEFAULT 'pending', -- pending, paid, processing, completed, failed
-    amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(50) DEFAULT 'pending', -- pending, awaiting_payment, paid, provisioning, completed, provisioning_failed, needs_attention, refunded, cancelled, expired
+    amount NUMERIC(18,6) NOT NULL,
     currency VARCHAR(3) DEFAULT 'MNT',
     customer_email VARCHAR(255),
     customer_phone VARCHAR(20),
@@ -56,7 +56,7 @@ CREATE TABLE IF NOT EXISTS payment_transactions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     order_id UUID REFERENCES orders(id),
     qpay_transaction_id VARCHAR(100),
-    amount DECIMAL(10,2) NOT NULL,
+    amount NUMERIC(18,6) NOT NULL,
     status VARCHAR(50) NOT NULL,
     payment_method VARCHAR(50),
     transaction_data JSONB,
@@ -107,5 +107,6 @@ INSERT INTO admin_settings (setting_key, setting_value, description) VALUES
 ('roamwifi_api_key', '', 'RoamWiFi API Key'),
 ('roamwifi_api_url', '', 'RoamWiFi API URL'),
 ('default_currency', 'MNT', 'Default currency for payments'),
-('profit_margin_percentage', '10', 'Default profit margin percentage')
+('profit_margin_percentage', '10', 'Default profit margin percentage'),
+('order_expiry_minutes', '30', 'Minutes an unpaid order (and its locked price) stays payable')
 ON CONFLICT (setting_key) DO NOTHING; 
\ No newline at end of file
@@ -1,22 +1,35 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	QPay     QPayConfig
-	RoamWiFi RoamWiFiConfig
-	JWT      JWTConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	Catalog    CatalogCacheConfig
+	QPay       QPayConfig
+	RoamWiFi   RoamWiFiConfig
+	Provider   ProviderConfig
+	JWT        JWTConfig
+	Reconciler ReconcilerConfig
+	Expiry     OrderExpiryConfig
+	Jobs       JobsConfig
+	Sync       CatalogSyncConfig
+	Rates      ExchangeRateConfig
+	Schedule   PriceScheduleConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+	// Durations in seconds. RequestTimeout bounds each API request; ShutdownTimeout is how
+	// long in-flight requests and jobs may take to finish before they are cancelled.
+	RequestTimeout  int
+	ShutdownTimeout int
 }
 
 type DatabaseConfig struct {
@@ -35,6 +48,16 @@ type RedisConfig struct {
 	DB       int
 }
 
+// CatalogCacheConfig controls the Redis cache of provider catalog data. TTLs are in
+// seconds; an expired entry is still served for StaleTTL while it is refreshed.
+type CatalogCacheConfig struct {
+	Enabled      bool
+	SKUTTL       int
+	ContinentTTL int
+	PackagesTTL  int
+	StaleTTL     int
+}
+
 type QPayConfig struct {
 	MerchantID       string
 	MerchantPassword string
@@ -44,13 +67,96 @@ type QPayConfig struct {
 	Password         string
 	InvoiceCode      string
 	CallbackURL      string
+	Resilience       ResilienceConfig
+}
+
+// ResilienceConfig controls retries and the circuit breaker around one upstream API.
+// Only idempotent calls are retried.
+type ResilienceConfig struct {
+	RetryAttempts    int // total attempts of an idempotent call, including the first
+	RetryBaseMS      int // first retry delay; later delays double up to RetryMaxMS, with jitter
+	RetryMaxMS       int
+	BreakerThreshold int // consecutive failures that open the circuit
+	BreakerCooldown  int // seconds the circuit stays open before a trial call is let through
+}
+
+// ReconcilerConfig controls the background QPay payment reconciliation worker.
+// Durations are in seconds.
+type ReconcilerConfig struct {
+	Enabled    bool
+	Interval   int // how often pending orders are scanned
+	MinAge     int // orders younger than this are left to the webhook
+	MaxAge     int // orders older than this are no longer polled
+	MaxBackoff int // upper bound on the per-order retry delay
+	BatchSize  int
+}
+
+// OrderExpiryConfig controls the sweeper that expires unpaid orders. The payment window
+// itself is the order_expiry_minutes admin setting.
+type OrderExpiryConfig struct {
+	SweepInterval int // seconds
+	BatchSize     int
+}
+
+// JobsConfig controls the background job worker. Durations are in seconds.
+type JobsConfig struct {
+	PollInterval int
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  int // delay before the first retry, doubled per attempt
+	MaxBackoff   int
+	LockTimeout  int // running jobs older than this are assumed abandoned and re-queued
+	Timeout      int // deadline of a single job run; must be below LockTimeout
+}
+
+// CatalogSyncConfig controls the scheduled sync of provider SKUs and package prices.
+// Durations are in seconds.
+type CatalogSyncConfig struct {
+	Enabled     bool
+	Interval    int // time between scheduled runs
+	Concurrency int // SKUs whose packages are synced in parallel
+	Timeout     int // deadline of one run; unfinished runs older than this count as abandoned
+	// PriceChangeThreshold flags provider price changes of at least this many percent
+	PriceChangeThreshold float64
+}
+
+// ExchangeRateConfig controls where exchange rates come from and how new ones are
+// checked. Durations are in seconds.
+type ExchangeRateConfig struct {
+	Sources         string // comma-separated priority order of bom, json and manual
+	RefreshInterval int    // time between scheduled refreshes; 0 disables them
+	MaxAge          int    // rates older than this are reported as stale
+	// MaxDeviation rejects a fetched rate that differs from the last known one by more
+	// than this many percent
+	MaxDeviation   float64
+	BOMURL         string // Bank of Mongolia daily rates endpoint
+	JSONURL        string // generic JSON source, e.g. exchangerate-api.com
+	TimeoutSeconds int    // per-request timeout of the source HTTP client
+}
+
+// PriceScheduleConfig controls the worker that starts and ends scheduled price changes
+type PriceScheduleConfig struct {
+	Interval int // seconds between checks; a change starts or ends at most this late
 }
 
 type RoamWiFiConfig struct {
-	APIKey      string
-	APIURL      string
-	PhoneNumber string
-	Password    string
+	APIKey          string
+	APIURL          string
+	PhoneNumber     string
+	Password        string
+	TokenTTLMinutes int // how long a login token is reused before logging in again
+	TimeoutSeconds  int // per-request timeout of the RoamWiFi HTTP client
+	Resilience      ResilienceConfig
+}
+
+// ProviderConfig selects the eSIM provider implementation. The Fake* settings only apply
+// to the in-process fake provider used for local development.
+type ProviderConfig struct {
+	Name            string // roamwifi (default) or fake
+	FakeCatalogFile string
+	FakeLatencyMS   int
+	FakeFailureRate float64
+	FakeFailOps     string // comma-separated operations that always fail, e.g. create_order
 }
 
 type JWTConfig struct {
@@ -61,8 +167,10 @@ type JWTConfig struct {
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Host: getEnv("HOST", "0.0.0.0"),
+			Port:            getEnv("PORT", "8080"),
+			Host:            getEnv("HOST", "0.0.0.0"),
+			RequestTimeout:  getEnvAsInt("REQUEST_TIMEOUT", 60),
+			ShutdownTimeout: getEnvAsInt("SHUTDOWN_TIMEOUT", 30),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -78,6 +186,13 @@ func Load() *Config {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
+		Catalog: CatalogCacheConfig{
+			Enabled:      getEnv("CATALOG_CACHE_ENABLED", "true") == "true",
+			SKUTTL:       getEnvAsInt("CATALOG_CACHE_SKU_TTL", 600),
+			ContinentTTL: getEnvAsInt("CATALOG_CACHE_CONTINENT_TTL", 600),
+			PackagesTTL:  getEnvAsInt("CATALOG_CACHE_PACKAGES_TTL", 300),
+			StaleTTL:     getEnvAsInt("CATALOG_CACHE_STALE_TTL", 3600),
+		},
 		QPay: QPayConfig{
 			MerchantID:       getEnv("QPAY_MERCHANT_ID", ""),
 			MerchantPassword: getEnv("QPAY_MERCHANT_PASSWORD", ""),
@@ -87,17 +202,92 @@ func Load() *Config {
 			Password:         getEnv("QPAY_PASSWORD", "xQF7fgDM"),
 			InvoiceCode:      getEnv("QPAY_INVOICE_CODE", "DOKIND_MN_INVOICE"),
 			CallbackURL:      getEnv("QPAY_CALLBACK_URL", ""),
+			Resilience:       loadResilience("QPAY"),
 		},
 		RoamWiFi: RoamWiFiConfig{
-			APIKey:      getEnv("ROAMWIFI_API_KEY", ""),
-			APIURL:      getEnv("ROAMWIFI_API_URL", "http://bpm.roamwifi.com"),
-			PhoneNumber: getEnv("ROAMWIFI_PHONENUMBER", ""),
-			Password:    getEnv("ROAMWIFI_PASSWORD", ""),
+			APIKey:          getEnv("ROAMWIFI_API_KEY", ""),
+			APIURL:          getEnv("ROAMWIFI_API_URL", "http://bpm.roamwifi.com"),
+			PhoneNumber:     getEnv("ROAMWIFI_PHONENUMBER", ""),
+			Password:        getEnv("ROAMWIFI_PASSWORD", ""),
+			TokenTTLMinutes: getEnvAsInt("ROAMWIFI_TOKEN_TTL_MINUTES", 1440),
+			TimeoutSeconds:  getEnvAsInt("ROAMWIFI_TIMEOUT_SECONDS", 30),
+			Resilience:      loadResilience("ROAMWIFI"),
+		},
+		Provider: ProviderConfig{
+			Name:            getEnv("ESIM_PROVIDER", "roamwifi"),
+			FakeCatalogFile: getEnv("FAKE_PROVIDER_CATALOG", ""),
+			FakeLatencyMS:   getEnvAsInt("FAKE_PROVIDER_LATENCY_MS", 0),
+			FakeFailureRate: getEnvAsFloat("FAKE_PROVIDER_FAILURE_RATE", 0),
+			FakeFailOps:     getEnv("FAKE_PROVIDER_FAIL_OPS", ""),
 		},
 		JWT: JWTConfig{
 			Secret:     getEnv("JWT_SECRET", "your-secret-key"),
 			Expiration: getEnvAsInt("JWT_EXPIRATION", 24),
 		},
+		Reconciler: ReconcilerConfig{
+			Enabled:    getEnv("PAYMENT_RECONCILE_ENABLED", "true") == "true",
+			Interval:   getEnvAsInt("PAYMENT_RECONCILE_INTERVAL", 60),
+			MinAge:     getEnvAsInt("PAYMENT_RECONCILE_MIN_AGE", 120),
+			MaxAge:     getEnvAsInt("PAYMENT_RECONCILE_MAX_AGE", 86400),
+			MaxBackoff: getEnvAsInt("PAYMENT_RECONCILE_MAX_BACKOFF", 1800),
+			BatchSize:  getEnvAsInt("PAYMENT_RECONCILE_BATCH_SIZE", 50),
+		},
+		Expiry: OrderExpiryConfig{
+			SweepInterval: getEnvAsInt("ORDER_EXPIRY_SWEEP_INTERVAL", 60),
+			BatchSize:     getEnvAsInt("ORDER_EXPIRY_BATCH_SIZE", 100),
+		},
+		Jobs: JobsConfig{
+			PollInterval: getEnvAsInt("JOBS_POLL_INTERVAL", 5),
+			BatchSize:    getEnvAsInt("JOBS_BATCH_SIZE", 10),
+			MaxAttempts:  getEnvAsInt("JOBS_MAX_ATTEMPTS", 8),
+			BaseBackoff:  getEnvAsInt("JOBS_BASE_BACKOFF", 30),
+			MaxBackoff:   getEnvAsInt("JOBS_MAX_BACKOFF", 3600),
+			LockTimeout:  getEnvAsInt("JOBS_LOCK_TIMEOUT", 600),
+			Timeout:      getEnvAsInt("JOBS_TIMEOUT", 120),
+		},
+		Sync: CatalogSyncConfig{
+			Enabled:     getEnv("CATALOG_SYNC_ENABLED", "true") == "true",
+			Interval:    getEnvAsInt("CATALOG_SYNC_INTERVAL", 21600),
+			Concurrency: getEnvAsInt("CATALOG_SYNC_CONCURRENCY", 4),
+			Timeout:     getEnvAsInt("CATALOG_SYNC_TIMEOUT", 1800),
+
+			PriceChangeThreshold: getEnvAsFloat("CATALOG_SYNC_PRICE_CHANGE_THRESHOLD", 10),
+		},
+		Rates: ExchangeRateConfig{
+			Sources:         getEnv("EXCHANGE_RATE_SOURCES", "bom,json,manual"),
+			RefreshInterval: getEnvAsInt("EXCHANGE_RATE_REFRESH_INTERVAL", 3600),
+			MaxAge:          getEnvAsInt("EXCHANGE_RATE_MAX_AGE", 86400),
+			MaxDeviation:    getEnvAsFloat("EXCHANGE_RATE_MAX_DEVIATION_PERCENT", 10),
+			BOMURL:          getEnv("EXCHANGE_RATE_BOM_URL", "https://www.mongolbank.mn/en/currency-rates/data"),
+			JSONURL:         getEnv("EXCHANGE_RATE_JSON_URL", "https://api.exchangerate-api.com/v4/latest/USD"),
+			TimeoutSeconds:  getEnvAsInt("EXCHANGE_RATE_TIMEOUT_SECONDS", 10),
+		},
+		Schedule: PriceScheduleConfig{
+			Interval: getEnvAsInt("PRICE_SCHEDULE_INTERVAL", 60),
+		},
+	}
+}
+
+// loadResilience reads <PREFIX>_RETRY_* and <PREFIX>_BREAKER_* settings
+// Validate rejects settings the server cannot run safely with
+func (c *Config) Validate() error {
+	if c.Jobs.PollInterval <= 0 {
+		return fmt.Errorf("JOBS_POLL_INTERVAL must be positive, got %d", c.Jobs.PollInterval)
+	}
+	// A job still running when its lock times out is claimed again and runs twice
+	if c.Jobs.Timeout <= 0 || c.Jobs.Timeout >= c.Jobs.LockTimeout {
+		return fmt.Errorf("JOBS_TIMEOUT (%ds) must be positive and below JOBS_LOCK_TIMEOUT (%ds)", c.Jobs.Timeout, c.Jobs.LockTimeout)
+	}
+	return nil
+}
+
+func loadResilience(prefix string) ResilienceConfig {
+	return ResilienceConfig{
+		RetryAttempts:    getEnvAsInt(prefix+"_RETRY_ATTEMPTS", 3),
+		RetryBaseMS:      getEnvAsInt(prefix+"_RETRY_BASE_MS", 200),
+		RetryMaxMS:       getEnvAsInt(prefix+"_RETRY_MAX_MS", 2000),
+		BreakerThreshold: getEnvAsInt(prefix+"_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getEnvAsInt(prefix+"_BREAKER_COOLDOWN", 30),
 	}
 }
 
@@ -108,6 +298,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -38,16 +38,101 @@ func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 		&models.Package{},
 		&models.CurrencyRate{},
 		&models.PackagePrice{},
+		&models.OrderStatusHistory{},
+		&models.WebhookEvent{},
+		&models.Job{},
+		&models.SyncRun{},
+		&models.CatalogChange{},
+		&models.MarginPolicy{},
+		&models.PricingAlert{},
+		&models.PricingRule{},
+		&models.ScheduledPriceChange{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %v", err)
 	}
+	if err := migrateMisnamedColumns(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate renamed columns: %v", err)
+	}
+	if err := migrateLegacyOrderStatuses(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate legacy order statuses: %v", err)
+	}
 
 	DB = db
 	log.Println("Database connected successfully")
 	return db, nil
 }
 
+// misnamedColumns lists columns AutoMigrate created under GORM's default names (q_pay_*,
+// roam_wi_fi_*) before the models named them as in init.sql. Only the struct fields used
+// the default names, so values written through them are copied to the real column.
+var misnamedColumns = []struct {
+	table, from, to string
+}{
+	{"orders", "q_pay_invoice_id", "qpay_invoice_id"},
+	{"orders", "roam_wi_fi_order_id", "roamwifi_order_id"},
+	{"payment_transactions", "q_pay_transaction_id", "qpay_transaction_id"},
+}
+
+// migrateMisnamedColumns copies values from misnamed columns that are missing from the
+// real ones. The old columns are left in place.
+func migrateMisnamedColumns(db *gorm.DB) error {
+	for _, c := range misnamedColumns {
+		if !db.Migrator().HasColumn(c.table, c.from) {
+			continue
+		}
+		res := db.Exec(fmt.Sprintf("UPDATE %[1]s SET %[3]s = %[2]s WHERE COALESCE(%[3]s, '') = '' AND COALESCE(%[2]s, '') <> ''", c.table, c.from, c.to))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			log.Printf("Copied %d values from %s.%s to %s", res.RowsAffected, c.table, c.from, c.to)
+		}
+	}
+	return nil
+}
+
+// legacyOrderStatuses maps statuses written before the order lifecycle existed onto it.
+// Rules are applied in order, so the first matching condition wins.
+var legacyOrderStatuses = []struct {
+	from  string
+	to    models.OrderStatus
+	where string
+}{
+	// provisioning failed after QPay confirmed the payment
+	{"failed", models.OrderStatusProvisioningFailed, "EXISTS (SELECT 1 FROM payment_transactions p WHERE p.order_id = orders.id AND p.status = 'paid')"},
+	// invoice creation failed or QPay reported the payment as failed
+	{"failed", models.OrderStatusCancelled, "TRUE"},
+	// unmapped QPay status; the invoice can still be checked and paid
+	{"unknown", models.OrderStatusAwaitingPayment, "COALESCE(qpay_invoice_id, '') <> ''"},
+	{"unknown", models.OrderStatusCancelled, "TRUE"},
+}
+
+const legacyStatusActor = "migration"
+
+// migrateLegacyOrderStatuses moves orders with pre-lifecycle statuses to lifecycle states
+// and records each change in order_status_history. It is a no-op once no legacy rows remain.
+func migrateLegacyOrderStatuses(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, rule := range legacyOrderStatuses {
+			cond := "status = ? AND " + rule.where
+			if err := tx.Exec(`INSERT INTO order_status_history (id, order_id, from_status, to_status, actor, reason, created_at)
+				SELECT gen_random_uuid(), id, status, ?, ?, ?, NOW() FROM orders WHERE `+cond,
+				rule.to, legacyStatusActor, "legacy status "+rule.from, rule.from).Error; err != nil {
+				return err
+			}
+			res := tx.Exec("UPDATE orders SET status = ?, updated_at = NOW() WHERE "+cond, rule.to, rule.from)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				log.Printf("Migrated %d orders from status %s to %s", res.RowsAffected, rule.from, rule.to)
+			}
+		}
+		return nil
+	})
+}
+
 func InitRedis(cfg config.RedisConfig) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
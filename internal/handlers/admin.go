@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
 	"esim-platform/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +21,9 @@ type AdminHandler struct {
 	orderService   *services.OrderService
 	userService    *services.UserService
 	pricingService *services.PricingService
+	jobService     *services.JobService
+	catalogSyncer  *services.CatalogSyncer
+	rateUpdater    *services.ExchangeRateUpdater
 }
 
 type UpdatePackageMarkupRequest struct {
@@ -22,11 +31,81 @@ type UpdatePackageMarkupRequest struct {
 }
 
 type UpdatePackageOverrideRequest struct {
-	OverridePriceUSD *float64 `json:"override_price_usd"`
+	OverridePriceUSD *money.Decimal `json:"override_price_usd"`
+}
+
+type UpdateMarginPolicyRequest struct {
+	MinMarginPercent *float64 `json:"min_margin_percent" binding:"required"`
+	Action           string   `json:"action"` // reprice (default) or deactivate
+}
+
+// PricingRuleRequest describes a pricing rule; omitted conditions match every package
+type PricingRuleRequest struct {
+	Name                string         `json:"name" binding:"required"`
+	Priority            int            `json:"priority"` // lower wins
+	SKUID               string         `json:"sku_id"`
+	Continent           string         `json:"continent"`
+	MinDataMB           *float64       `json:"min_data_mb"`
+	MaxDataMB           *float64       `json:"max_data_mb"`
+	MinDays             *int           `json:"min_days"`
+	MaxDays             *int           `json:"max_days"`
+	MinProviderPriceUSD *money.Decimal `json:"min_provider_price_usd"`
+	MaxProviderPriceUSD *money.Decimal `json:"max_provider_price_usd"`
+	AddOnType           string         `json:"add_on_type" binding:"required"` // percent or fixed (USD)
+	AddOn               money.Decimal  `json:"add_on"`
+	Active              *bool          `json:"active"` // default true
+}
+
+func (r PricingRuleRequest) toModel() models.PricingRule {
+	rule := models.PricingRule{
+		Name:                r.Name,
+		Priority:            r.Priority,
+		SKUID:               r.SKUID,
+		Continent:           r.Continent,
+		MinDataMB:           r.MinDataMB,
+		MaxDataMB:           r.MaxDataMB,
+		MinDays:             r.MinDays,
+		MaxDays:             r.MaxDays,
+		MinProviderPriceUSD: r.MinProviderPriceUSD,
+		MaxProviderPriceUSD: r.MaxProviderPriceUSD,
+		AddOnType:           r.AddOnType,
+		AddOn:               r.AddOn,
+		Active:              true,
+	}
+	if r.Active != nil {
+		rule.Active = *r.Active
+	}
+	return rule
+}
+
+// PreviewPricingRulesRequest optionally previews an unsaved rule, as a new rule or as a
+// change to the stored rule RuleID
+type PreviewPricingRulesRequest struct {
+	Rule   *PricingRuleRequest `json:"rule"`
+	RuleID *uuid.UUID          `json:"rule_id"`
+}
+
+// ScheduledPriceChangeRequest schedules an override or markup for one package, every
+// package of a SKU or the packages a pricing rule prices, from StartsAt until EndsAt
+type ScheduledPriceChangeRequest struct {
+	Name             string         `json:"name" binding:"required"`
+	PackagePriceID   *uuid.UUID     `json:"package_price_id"`
+	SKUID            string         `json:"sku_id"`
+	PricingRuleID    *uuid.UUID     `json:"pricing_rule_id"`
+	OverridePriceUSD *money.Decimal `json:"override_price_usd"`
+	MarkupPercent    *float64       `json:"markup_percent"` // over the provider price
+	StartsAt         time.Time      `json:"starts_at" binding:"required"`
+	EndsAt           time.Time      `json:"ends_at" binding:"required"`
 }
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type RefundOrderRequest struct {
+	Amount *money.Decimal `json:"amount"` // whole MNT; omit for a full refund
+	Reason string         `json:"reason" binding:"required"`
 }
 
 type UpdateUserRequest struct {
@@ -41,12 +120,12 @@ type UpdateSettingsRequest struct {
 }
 
 type SalesAnalyticsResponse struct {
-	TotalSales        float64 `json:"total_sales"`
-	TotalOrders       int64   `json:"total_orders"`
-	CompletedOrders   int64   `json:"completed_orders"`
-	PendingOrders     int64   `json:"pending_orders"`
-	FailedOrders      int64   `json:"failed_orders"`
-	AverageOrderValue float64 `json:"average_order_value"`
+	TotalSales        money.Decimal `json:"total_sales"` // MNT
+	TotalOrders       int64         `json:"total_orders"`
+	CompletedOrders   int64         `json:"completed_orders"`
+	PendingOrders     int64         `json:"pending_orders"`
+	FailedOrders      int64         `json:"failed_orders"`
+	AverageOrderValue money.Decimal `json:"average_order_value"` // MNT
 }
 
 type ProductAnalyticsResponse struct {
@@ -56,12 +135,15 @@ type ProductAnalyticsResponse struct {
 	TopSellingProducts []map[string]interface{} `json:"top_selling_products"`
 }
 
-func NewAdminHandler(productService *services.ProductService, orderService *services.OrderService, userService *services.UserService, pricingService *services.PricingService) *AdminHandler {
+func NewAdminHandler(productService *services.ProductService, orderService *services.OrderService, userService *services.UserService, pricingService *services.PricingService, jobService *services.JobService, catalogSyncer *services.CatalogSyncer, rateUpdater *services.ExchangeRateUpdater) *AdminHandler {
 	return &AdminHandler{
 		productService: productService,
 		orderService:   orderService,
 		userService:    userService,
 		pricingService: pricingService,
+		jobService:     jobService,
+		catalogSyncer:  catalogSyncer,
+		rateUpdater:    rateUpdater,
 	}
 }
 
@@ -71,17 +153,18 @@ func NewAdminHandler(productService *services.ProductService, orderService *serv
 // @Tags Admin,Packages
 // @Produce json
 // @Param skuId path string true "SKU ID"
-// @Success 200 {object} map[string]interface{} "Packages synced"
+// @Success 200 {object} map[string]interface{} "Packages synced, with added/changed/deactivated counts and the catalog changes found"
 // @Failure 500 {object} map[string]interface{} "Internal error"
 // @Security Bearer
 // @Router /admin/skus/{skuId}/packages/sync [post]
 func (h *AdminHandler) SyncPackagePrices(c *gin.Context) {
 	skuID := c.Param("skuId")
-	if err := h.productService.SyncPackagePrices(skuID); err != nil {
+	result, err := h.catalogSyncer.SyncSKU(c.Request.Context(), skuID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "packages synced"})
+	c.JSON(http.StatusOK, gin.H{"message": "packages synced", "result": result})
 }
 
 // UpdatePackageMarkup godoc
@@ -94,6 +177,7 @@ func (h *AdminHandler) SyncPackagePrices(c *gin.Context) {
 // @Param body body handlers.UpdatePackageMarkupRequest true "Markup payload"
 // @Success 200 {object} map[string]interface{} "Updated"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 422 {object} map[string]interface{} "Price below the minimum margin"
 // @Failure 500 {object} map[string]interface{} "Internal error"
 // @Security Bearer
 // @Router /admin/packages/{priceId}/markup [put]
@@ -117,8 +201,8 @@ func (h *AdminHandler) UpdatePackageMarkup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "markup_percent out of range"})
 		return
 	}
-	if err := h.productService.SetPackageMarkup(priceID, *req.MarkupPercent); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.productService.SetPackageMarkup(c.Request.Context(), priceID, *req.MarkupPercent); err != nil {
+		writePackagePricingError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "markup updated"})
@@ -134,6 +218,7 @@ func (h *AdminHandler) UpdatePackageMarkup(c *gin.Context) {
 // @Param body body handlers.UpdatePackageOverrideRequest true "Override payload (null to clear)"
 // @Success 200 {object} map[string]interface{} "Updated"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 422 {object} map[string]interface{} "Price below the minimum margin"
 // @Failure 500 {object} map[string]interface{} "Internal error"
 // @Security Bearer
 // @Router /admin/packages/{priceId}/override [put]
@@ -149,13 +234,448 @@ func (h *AdminHandler) UpdatePackageOverride(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.productService.SetPackageOverride(priceID, req.OverridePriceUSD); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.productService.SetPackageOverride(c.Request.Context(), priceID, req.OverridePriceUSD); err != nil {
+		writePackagePricingError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "override updated"})
 }
 
+// writePackagePricingError reports a price below the minimum margin with the lowest
+// acceptable price
+func writePackagePricingError(c *gin.Context, err error) {
+	var violation *services.MarginViolationError
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":              err.Error(),
+			"min_price_usd":      violation.MinPriceUSD,
+			"min_margin_percent": violation.MinMarginPercent,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GetMarginPolicies godoc
+// @Summary Get margin policies (Admin)
+// @Description Global minimum margin over the provider price and per-SKU overrides (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Global and per-SKU policies"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/margin-policy [get]
+func (h *AdminHandler) GetMarginPolicies(c *gin.Context) {
+	global, perSKU, err := h.productService.GetMarginPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"global": global, "skus": perSKU})
+}
+
+// UpdateGlobalMarginPolicy godoc
+// @Summary Update global margin policy (Admin)
+// @Description Set the minimum margin for SKUs without their own policy and re-price stored packages (admin only)
+// @Tags Admin,Packages
+// @Accept json
+// @Produce json
+// @Param body body handlers.UpdateMarginPolicyRequest true "Margin policy"
+// @Success 200 {object} models.MarginPolicy "Saved policy"
+// @Failure 400 {object} map[string]interface{} "Invalid policy"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/margin-policy [put]
+func (h *AdminHandler) UpdateGlobalMarginPolicy(c *gin.Context) {
+	h.updateMarginPolicy(c, "")
+}
+
+// UpdateSKUMarginPolicy godoc
+// @Summary Update SKU margin policy (Admin)
+// @Description Set the minimum margin of one SKU, overriding the global policy, and re-price its packages (admin only)
+// @Tags Admin,Packages
+// @Accept json
+// @Produce json
+// @Param skuId path string true "SKU ID"
+// @Param body body handlers.UpdateMarginPolicyRequest true "Margin policy"
+// @Success 200 {object} models.MarginPolicy "Saved policy"
+// @Failure 400 {object} map[string]interface{} "Invalid policy"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/skus/{skuId}/margin-policy [put]
+func (h *AdminHandler) UpdateSKUMarginPolicy(c *gin.Context) {
+	h.updateMarginPolicy(c, c.Param("skuId"))
+}
+
+func (h *AdminHandler) updateMarginPolicy(c *gin.Context, skuID string) {
+	var req UpdateMarginPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	policy, err := h.productService.SetMarginPolicy(c.Request.Context(), skuID, *req.MinMarginPercent, req.Action)
+	if err != nil {
+		writeMarginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteSKUMarginPolicy godoc
+// @Summary Delete SKU margin policy (Admin)
+// @Description Remove a SKU's own policy so the global policy applies, and re-price its packages (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param skuId path string true "SKU ID"
+// @Success 200 {object} map[string]interface{} "Deleted"
+// @Failure 404 {object} map[string]interface{} "No policy for this SKU"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/skus/{skuId}/margin-policy [delete]
+func (h *AdminHandler) DeleteSKUMarginPolicy(c *gin.Context) {
+	if err := h.productService.DeleteMarginPolicy(c.Request.Context(), c.Param("skuId")); err != nil {
+		writeMarginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "margin policy deleted"})
+}
+
+// GetPricingAlerts godoc
+// @Summary List pricing alerts (Admin)
+// @Description Packages whose override, markup or provider price is below their minimum margin, most recent first (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Filter by status (open, resolved)"
+// @Param sku_id query string false "Filter by SKU ID"
+// @Success 200 {object} map[string]interface{} "Pricing alerts with pagination"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/alerts [get]
+func (h *AdminHandler) GetPricingAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	alerts, total, err := h.productService.ListPricingAlerts(c.Request.Context(), page, limit, c.Query("status"), c.Query("sku_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// ResolvePricingAlert godoc
+// @Summary Resolve a pricing alert (Admin)
+// @Description Close an alert by hand; the next sync opens it again if the package is still below its margin (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param id path string true "Alert ID (UUID)"
+// @Success 200 {object} models.PricingAlert "Resolved alert"
+// @Failure 400 {object} map[string]interface{} "Invalid alert ID"
+// @Failure 404 {object} map[string]interface{} "Alert not found"
+// @Security Bearer
+// @Router /admin/pricing/alerts/{id}/resolve [post]
+func (h *AdminHandler) ResolvePricingAlert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	alert, err := h.productService.ResolvePricingAlert(c.Request.Context(), id, services.AdminActor(fmt.Sprint(userID)))
+	if err != nil {
+		writeMarginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetPricingRules godoc
+// @Summary List pricing rules (Admin)
+// @Description Pricing rules in priority order, active or not (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Success 200 {array} models.PricingRule "Pricing rules"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/rules [get]
+func (h *AdminHandler) GetPricingRules(c *gin.Context) {
+	rules, err := h.productService.ListPricingRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreatePricingRule godoc
+// @Summary Create pricing rule (Admin)
+// @Description Add a rule marking up the provider price of matching packages by a percent or fixed USD amount, and re-price stored packages (admin only)
+// @Tags Admin,Pricing
+// @Accept json
+// @Produce json
+// @Param body body handlers.PricingRuleRequest true "Pricing rule"
+// @Success 201 {object} models.PricingRule "Created rule"
+// @Failure 400 {object} map[string]interface{} "Invalid rule"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/rules [post]
+func (h *AdminHandler) CreatePricingRule(c *gin.Context) {
+	var req PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := req.toModel()
+	if err := h.productService.CreatePricingRule(c.Request.Context(), &rule); err != nil {
+		writePricingRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdatePricingRule godoc
+// @Summary Update pricing rule (Admin)
+// @Description Replace a rule's conditions, priority and add-on, and re-price stored packages (admin only)
+// @Tags Admin,Pricing
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID (UUID)"
+// @Param body body handlers.PricingRuleRequest true "Pricing rule"
+// @Success 200 {object} models.PricingRule "Saved rule"
+// @Failure 400 {object} map[string]interface{} "Invalid rule"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/rules/{id} [put]
+func (h *AdminHandler) UpdatePricingRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	var req PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := h.productService.UpdatePricingRule(c.Request.Context(), id, req.toModel())
+	if err != nil {
+		writePricingRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeletePricingRule godoc
+// @Summary Delete pricing rule (Admin)
+// @Description Remove a rule and re-price the packages it priced (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Param id path string true "Rule ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid rule ID"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/rules/{id} [delete]
+func (h *AdminHandler) DeletePricingRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	if err := h.productService.DeletePricingRule(c.Request.Context(), id); err != nil {
+		writePricingRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "pricing rule deleted"})
+}
+
+// PreviewPricingRules godoc
+// @Summary Preview pricing rules (Admin)
+// @Description Show which rule wins for each package and the price it would get, next to its current price, without saving anything. An optional rule in the body is previewed as a new rule, or as a change to rule_id (admin only)
+// @Tags Admin,Pricing
+// @Accept json
+// @Produce json
+// @Param sku_id query string false "Only packages of this SKU"
+// @Param body body handlers.PreviewPricingRulesRequest false "Draft rule"
+// @Success 200 {object} map[string]interface{} "Per-package preview and the number of changed prices"
+// @Failure 400 {object} map[string]interface{} "Invalid rule"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/rules/preview [post]
+func (h *AdminHandler) PreviewPricingRules(c *gin.Context) {
+	var req PreviewPricingRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var draft *models.PricingRule
+	if req.Rule != nil {
+		rule := req.Rule.toModel()
+		if req.RuleID != nil {
+			rule.ID = *req.RuleID
+		}
+		draft = &rule
+	}
+
+	previews, err := h.productService.PreviewPricingRules(c.Request.Context(), draft, c.Query("sku_id"))
+	if err != nil {
+		writePricingRuleError(c, err)
+		return
+	}
+	changed := 0
+	for _, p := range previews {
+		if p.Changed {
+			changed++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"packages": previews, "changed": changed})
+}
+
+// writePricingRuleError maps pricing rule errors to HTTP status codes
+func writePricingRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPricingRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPricingRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetScheduledPriceChanges godoc
+// @Summary List scheduled price changes (Admin)
+// @Description Scheduled, running and finished price changes and sales, latest start first (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Filter by status (scheduled, active, ended, cancelled)"
+// @Success 200 {object} map[string]interface{} "Scheduled price changes with pagination"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/schedules [get]
+func (h *AdminHandler) GetScheduledPriceChanges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	changes, total, err := h.productService.ListScheduledPriceChanges(c.Request.Context(), page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": changes,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// CreateScheduledPriceChange godoc
+// @Summary Schedule a price change (Admin)
+// @Description Schedule an override or markup for a package, SKU or pricing rule between starts_at and ends_at. It is applied and reverted automatically, and storefront listings show the regular price as the "was" price while it runs (admin only)
+// @Tags Admin,Pricing
+// @Accept json
+// @Produce json
+// @Param body body handlers.ScheduledPriceChangeRequest true "Scheduled price change"
+// @Success 201 {object} models.ScheduledPriceChange "Scheduled change"
+// @Failure 400 {object} map[string]interface{} "Invalid change"
+// @Failure 422 {object} map[string]interface{} "Override below the package's minimum margin"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/schedules [post]
+func (h *AdminHandler) CreateScheduledPriceChange(c *gin.Context) {
+	var req ScheduledPriceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	change := models.ScheduledPriceChange{
+		Name:             req.Name,
+		PackagePriceID:   req.PackagePriceID,
+		SKUID:            req.SKUID,
+		PricingRuleID:    req.PricingRuleID,
+		OverridePriceUSD: req.OverridePriceUSD,
+		MarkupPercent:    req.MarkupPercent,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		CreatedBy:        services.AdminActor(fmt.Sprint(userID)),
+	}
+	if err := h.productService.CreateScheduledPriceChange(c.Request.Context(), &change); err != nil {
+		writeScheduledPriceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, change)
+}
+
+// CancelScheduledPriceChange godoc
+// @Summary Cancel a scheduled price change (Admin)
+// @Description Stop a change before or while it runs; a running sale is reverted right away (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Param id path string true "Scheduled change ID (UUID)"
+// @Success 200 {object} models.ScheduledPriceChange "Cancelled change"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Change not found"
+// @Failure 409 {object} map[string]interface{} "Change already ended or cancelled"
+// @Failure 500 {object} map[string]interface{} "Internal error"
+// @Security Bearer
+// @Router /admin/pricing/schedules/{id}/cancel [post]
+func (h *AdminHandler) CancelScheduledPriceChange(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled change ID"})
+		return
+	}
+	change, err := h.productService.CancelScheduledPriceChange(c.Request.Context(), id)
+	if err != nil {
+		writeScheduledPriceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+// writeScheduledPriceError maps scheduled price change errors to HTTP status codes
+func writeScheduledPriceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidScheduledPrice):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScheduledPriceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScheduledPriceFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		writePackagePricingError(c, err)
+	}
+}
+
+// writeMarginError maps margin policy and pricing alert errors to HTTP status codes
+func writeMarginError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMarginPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMarginPolicyNotFound), errors.Is(err, services.ErrPricingAlertNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // CreateProduct godoc
 // @Summary Create new product (Admin)
 // @Description Create a new eSIM product (admin only)
@@ -175,7 +695,7 @@ func (h *AdminHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.CreateProduct(req)
+	product, err := h.productService.CreateProduct(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -213,7 +733,7 @@ func (h *AdminHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.UpdateProduct(id, req)
+	product, err := h.productService.UpdateProduct(c.Request.Context(), id, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -243,7 +763,7 @@ func (h *AdminHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.DeleteProduct(id); err != nil {
+	if err := h.productService.DeleteProduct(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -261,7 +781,7 @@ func (h *AdminHandler) DeleteProduct(c *gin.Context) {
 // @Security Bearer
 // @Router /admin/products/sync [post]
 func (h *AdminHandler) SyncProductsFromRoamWiFi(c *gin.Context) {
-	count, err := h.productService.SyncProductsFromRoamWiFi()
+	count, err := h.productService.SyncProductsFromRoamWiFi(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -280,7 +800,7 @@ func (h *AdminHandler) GetAllOrders(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	status := c.Query("status")
 
-	orders, total, err := h.orderService.GetAllOrders(page, limit, status)
+	orders, total, err := h.orderService.GetAllOrders(c.Request.Context(), page, limit, status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -311,12 +831,26 @@ func (h *AdminHandler) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
 }
 
-// UpdateOrderStatus updates order status (admin only)
+// UpdateOrderStatus godoc
+// @Summary Update order status (Admin)
+// @Description Move an order to a new lifecycle status; illegal transitions are rejected (admin only)
+// @Tags Admin,Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID (UUID)"
+// @Param body body handlers.UpdateOrderStatusRequest true "Target status and reason"
+// @Success 200 {object} models.Order "Updated order"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID or request"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Transition not allowed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/orders/{id}/status [put]
 func (h *AdminHandler) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
 
 	// Parse UUID
-	_, err := uuid.Parse(orderID)
+	id, err := uuid.Parse(orderID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
@@ -328,9 +862,403 @@ func (h *AdminHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// This would need to be implemented in the order service
-	// For now, we'll return an error
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
+	userID, _ := c.Get("user_id")
+	actor := services.AdminActor(fmt.Sprint(userID))
+
+	order, err := h.orderService.UpdateOrderStatus(c.Request.Context(), id, models.OrderStatus(req.Status), actor, req.Reason)
+	if err != nil {
+		writeOrderStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrderStatusHistory godoc
+// @Summary Get order status history (Admin)
+// @Description List lifecycle transitions recorded for an order, oldest first (admin only)
+// @Tags Admin,Orders
+// @Produce json
+// @Param id path string true "Order ID (UUID)"
+// @Success 200 {array} models.OrderStatusHistory "Status history"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/orders/{id}/history [get]
+func (h *AdminHandler) GetOrderStatusHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	history, err := h.orderService.GetOrderStatusHistory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// RefundOrder godoc
+// @Summary Refund an order (Admin)
+// @Description Refund a paid order through QPay, fully or partially. A full refund moves the order to refunded (admin only)
+// @Tags Admin,Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID (UUID)"
+// @Param body body handlers.RefundOrderRequest true "Refund amount (MNT, omit for full) and reason"
+// @Success 200 {object} services.RefundResult "Refund issued"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID, request or amount"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order cannot be refunded"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/orders/{id}/refund [post]
+func (h *AdminHandler) RefundOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req RefundOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount := money.Zero
+	if req.Amount != nil {
+		if req.Amount.Sign() <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+			return
+		}
+		amount = *req.Amount
+	}
+
+	userID, _ := c.Get("user_id")
+	actor := services.AdminActor(fmt.Sprint(userID))
+
+	result, err := h.orderService.RefundOrder(c.Request.Context(), id, amount, req.Reason, actor)
+	if err != nil {
+		writeOrderStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// writeOrderStatusError maps order lifecycle errors to HTTP status codes
+func writeOrderStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRefundAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrRefundNotAllowed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetJobs godoc
+// @Summary List background jobs (Admin)
+// @Description List provisioning and email jobs, newest first (admin only)
+// @Tags Admin,Jobs
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Filter by status (queued, running, succeeded, dead)"
+// @Param type query string false "Filter by type (provision_esim, send_esim_email)"
+// @Success 200 {object} map[string]interface{} "Jobs list with pagination"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/jobs [get]
+func (h *AdminHandler) GetJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	jobs, total, err := h.jobService.ListJobs(c.Request.Context(), page, limit, c.Query("status"), c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetJob godoc
+// @Summary Get background job (Admin)
+// @Description Inspect a job including its attempts and last error (admin only)
+// @Tags Admin,Jobs
+// @Produce json
+// @Param id path string true "Job ID (UUID)"
+// @Success 200 {object} models.Job "Job"
+// @Failure 400 {object} map[string]interface{} "Invalid job ID"
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Security Bearer
+// @Router /admin/jobs/{id} [get]
+func (h *AdminHandler) GetJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob godoc
+// @Summary Retry a dead job (Admin)
+// @Description Re-queue a dead-lettered job with a fresh set of attempts (admin only)
+// @Tags Admin,Jobs
+// @Produce json
+// @Param id path string true "Job ID (UUID)"
+// @Success 200 {object} models.Job "Re-queued job"
+// @Failure 400 {object} map[string]interface{} "Invalid job ID"
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Failure 409 {object} map[string]interface{} "Job is not dead"
+// @Security Bearer
+// @Router /admin/jobs/{id}/retry [post]
+func (h *AdminHandler) RetryJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobService.RetryJob(c.Request.Context(), id)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// writeJobError maps job errors to HTTP status codes
+func writeJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrJobNotRetryable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetProviderStatus godoc
+// @Summary Get eSIM provider status (Admin)
+// @Description Report the active eSIM provider and the health of its login session (admin only)
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} services.ProviderStatus "Provider is healthy"
+// @Failure 503 {object} services.ProviderStatus "Provider session is unhealthy"
+// @Security Bearer
+// @Router /admin/provider/status [get]
+func (h *AdminHandler) GetProviderStatus(c *gin.Context) {
+	status := h.productService.ProviderStatus()
+	if !status.Healthy {
+		c.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetCatalogCacheStats godoc
+// @Summary Get catalog cache metrics (Admin)
+// @Description Hit, stale hit, miss, refresh and error counts of the catalog cache on this instance, by kind (admin only)
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Cache counters by kind; enabled=false when caching is off"
+// @Security Bearer
+// @Router /admin/catalog/cache [get]
+func (h *AdminHandler) GetCatalogCacheStats(c *gin.Context) {
+	stats := h.productService.CatalogCacheStats()
+	c.JSON(http.StatusOK, gin.H{"enabled": stats != nil, "stats": stats})
+}
+
+// InvalidateCatalogCache godoc
+// @Summary Invalidate catalog cache (Admin)
+// @Description Drop cached packages of one SKU, or the whole catalog cache when sku_id is omitted (admin only)
+// @Tags Admin
+// @Produce json
+// @Param sku_id query string false "SKU ID"
+// @Success 200 {object} map[string]interface{} "Cache invalidated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/catalog/cache [delete]
+func (h *AdminHandler) InvalidateCatalogCache(c *gin.Context) {
+	var skuIDs []string
+	if skuID := c.Query("sku_id"); skuID != "" {
+		skuIDs = append(skuIDs, skuID)
+	}
+	if err := h.productService.InvalidateCatalogCache(c.Request.Context(), skuIDs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Catalog cache invalidated"})
+}
+
+// TriggerCatalogSync godoc
+// @Summary Sync the whole catalog now (Admin)
+// @Description Start a sync of every provider SKU and its package prices in the background (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Success 202 {object} models.SyncRun "Sync run started"
+// @Failure 409 {object} map[string]interface{} "A sync is already running"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/catalog/sync [post]
+func (h *AdminHandler) TriggerCatalogSync(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	run, err := h.catalogSyncer.TriggerSync(c.Request.Context(), services.AdminActor(fmt.Sprint(userID)))
+	if err != nil {
+		writeSyncRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetSyncRuns godoc
+// @Summary List catalog sync runs (Admin)
+// @Description List scheduled and manual catalog sync runs, newest first (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Filter by status (running, succeeded, partial, failed)"
+// @Success 200 {object} map[string]interface{} "Sync runs with pagination"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/catalog/sync-runs [get]
+func (h *AdminHandler) GetSyncRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, total, err := h.catalogSyncer.ListSyncRuns(c.Request.Context(), page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetSyncRun godoc
+// @Summary Get catalog sync run (Admin)
+// @Description Inspect a sync run including its counters and per-SKU errors (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param id path string true "Sync run ID (UUID)"
+// @Success 200 {object} models.SyncRun "Sync run"
+// @Failure 400 {object} map[string]interface{} "Invalid sync run ID"
+// @Failure 404 {object} map[string]interface{} "Sync run not found"
+// @Security Bearer
+// @Router /admin/catalog/sync-runs/{id} [get]
+func (h *AdminHandler) GetSyncRun(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sync run ID"})
+		return
+	}
+
+	run, err := h.catalogSyncer.GetSyncRun(c.Request.Context(), id)
+	if err != nil {
+		writeSyncRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetCatalogChanges godoc
+// @Summary List catalog changes (Admin)
+// @Description Browse package differences found by syncs: new and removed packages, provider price changes and data/day changes, newest first (admin only)
+// @Tags Admin,Packages
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param sync_run_id query string false "Only changes found by this sync run (UUID)"
+// @Param sku_id query string false "Filter by SKU ID"
+// @Param type query string false "Filter by type (added, removed, price_changed, data_changed, days_changed)"
+// @Param flagged query bool false "Only price changes beyond the alert threshold"
+// @Param min_change_percent query number false "Only changes of at least this many percent, up or down"
+// @Success 200 {object} map[string]interface{} "Catalog changes with pagination"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/catalog/changes [get]
+func (h *AdminHandler) GetCatalogChanges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	filter := services.CatalogChangeFilter{
+		SKUID:       c.Query("sku_id"),
+		Type:        c.Query("type"),
+		FlaggedOnly: c.Query("flagged") == "true",
+	}
+	if runID := c.Query("sync_run_id"); runID != "" {
+		id, err := uuid.Parse(runID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sync run ID"})
+			return
+		}
+		filter.SyncRunID = &id
+	}
+	if minPct := c.Query("min_change_percent"); minPct != "" {
+		pct, err := strconv.ParseFloat(minPct, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_change_percent"})
+			return
+		}
+		filter.MinChangePercent = pct
+	}
+
+	changes, total, err := h.catalogSyncer.ListCatalogChanges(c.Request.Context(), page, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// writeSyncRunError maps catalog sync errors to HTTP status codes
+func writeSyncRunError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSyncRunNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCatalogSyncInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // GetAllUsers godoc
@@ -514,12 +1442,12 @@ func (h *AdminHandler) GetSalesAnalytics(c *gin.Context) {
 	// This would need to be implemented with analytics queries
 	// For now, we'll return mock data
 	c.JSON(http.StatusOK, SalesAnalyticsResponse{
-		TotalSales:        1000000.0,
+		TotalSales:        money.FromInt(1000000),
 		TotalOrders:       150,
 		CompletedOrders:   120,
 		PendingOrders:     20,
 		FailedOrders:      10,
-		AverageOrderValue: 6666.67,
+		AverageOrderValue: money.MustParse("6666.67"),
 	})
 }
 
@@ -559,6 +1487,8 @@ func (h *AdminHandler) GetProductAnalytics(c *gin.Context) {
 
 type UpdateExchangeRateRequest struct {
 	Rate float64 `json:"rate" binding:"required,gt=0"`
+	From string  `json:"from" example:"USD"` // default USD
+	To   string  `json:"to" example:"MNT"`   // default MNT
 }
 
 type UpdateProfitMarginRequest struct {
@@ -569,40 +1499,127 @@ type SetProductPriceRequest struct {
 	Price float64 `json:"price" binding:"required,gt=0"`
 }
 
+type UpdateRoundingRuleRequest struct {
+	Rule string `json:"rule" binding:"required" example:"end_900"`
+}
+
 type PricingInfo struct {
-	CurrentExchangeRate float64 `json:"current_exchange_rate"`
-	DefaultProfitMargin float64 `json:"default_profit_margin"`
-	LastUpdated         string  `json:"last_updated"`
+	// CurrentExchangeRate is zero when no USD to MNT rate is available; RateStatus says why
+	CurrentExchangeRate money.Decimal `json:"current_exchange_rate"`
+	DefaultProfitMargin float64       `json:"default_profit_margin"`
+	RoundingRule        string        `json:"rounding_rule"`
+	LastUpdated         string        `json:"last_updated"`
+	// DisplayRates are the MNT rates storefront prices are shown at, per display currency
+	DisplayRates []services.ExchangeRate `json:"display_rates"`
+	// RateStatus reports the rate source in use and alerts when it is stale or a fallback
+	RateStatus services.ExchangeRateStatus `json:"rate_status"`
 }
 
 // GetPricingInfo godoc
 // @Summary Get pricing information (Admin)
-// @Description Get current pricing information including exchange rates and profit margins (admin only)
+// @Description Get current pricing information including exchange rates, rate source alerts and profit margins (admin only)
 // @Tags Admin,Pricing
 // @Produce json
 // @Success 200 {object} PricingInfo "Current pricing information"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security Bearer
 // @Router /admin/pricing/info [get]
 func (h *AdminHandler) GetPricingInfo(c *gin.Context) {
-	rate, err := h.pricingService.GetUSDToMNTRate()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get exchange rate"})
-		return
+	status := h.rateUpdater.Status(c.Request.Context())
+	var rate money.Decimal
+	lastUpdated := ""
+	if status.Current != nil {
+		rate = status.Current.Rate
+		lastUpdated = status.Current.AsOf.Format(time.RFC3339)
 	}
 
-	margin := h.pricingService.GetDefaultProfitMargin()
+	margin := h.pricingService.GetDefaultProfitMargin(c.Request.Context())
+
+	displayRates := []services.ExchangeRate{}
+	for _, currency := range money.Currencies {
+		if currency == money.MNT {
+			continue
+		}
+		if r, err := h.pricingService.GetRate(c.Request.Context(), currency, money.MNT); err == nil {
+			displayRates = append(displayRates, r)
+		}
+	}
 
 	c.JSON(http.StatusOK, PricingInfo{
 		CurrentExchangeRate: rate,
 		DefaultProfitMargin: margin,
-		LastUpdated:         "2025-08-08T18:00:00Z", // This should be fetched from database
+		RoundingRule:        h.pricingService.GetRoundingRule(c.Request.Context()).Name,
+		LastUpdated:         lastUpdated,
+		DisplayRates:        displayRates,
+		RateStatus:          status,
 	})
 }
 
+// RefreshExchangeRates godoc
+// @Summary Refresh exchange rates now (Admin)
+// @Description Fetch rates from the configured sources in priority order and store the first acceptable set (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Success 200 {object} services.RateRefreshResult "Rates stored"
+// @Failure 502 {object} map[string]interface{} "No source returned acceptable rates, or repricing at them failed"
+// @Security Bearer
+// @Router /admin/pricing/exchange-rate/refresh [post]
+func (h *AdminHandler) RefreshExchangeRates(c *gin.Context) {
+	result, err := h.rateUpdater.Refresh(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetExchangeRateHistory godoc
+// @Summary Exchange rate history (Admin)
+// @Description List the stored MNT rates of a currency, newest first, with the number of active packages priced at each; for USD also the rate each package's MNT price was derived from (admin only)
+// @Tags Admin,Pricing
+// @Produce json
+// @Param currency query string false "Currency quoted against MNT (USD, CNY, KRW)" default(USD)
+// @Param since query string false "Rates that took effect at or after this time (RFC 3339)"
+// @Param until query string false "Rates that took effect before this time (RFC 3339)"
+// @Param limit query int false "Maximum number of rates" default(100)
+// @Param sku_id query string false "Only packages of this SKU"
+// @Success 200 {object} services.ExchangeRateHistory "Rate history and package rates"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/pricing/exchange-rate/history [get]
+func (h *AdminHandler) GetExchangeRateHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	filter := services.RateHistoryFilter{Limit: limit, SKUID: c.Query("sku_id")}
+	if param := c.Query("currency"); param != "" {
+		currency, err := money.ParseCurrency(param)
+		if err != nil || currency == money.MNT {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+			return
+		}
+		filter.Currency = currency
+	}
+	for name, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if param := c.Query(name); param != "" {
+			t, err := time.Parse(time.RFC3339, param)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ", expected RFC 3339"})
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	history, err := h.pricingService.ExchangeRateHistory(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
 // UpdateExchangeRate godoc
 // @Summary Update exchange rate (Admin)
-// @Description Manually set the USD to MNT exchange rate (admin only)
+// @Description Manually set an exchange rate, USD to MNT unless from/to are given, and reprice packages at it (admin only)
 // @Tags Admin,Pricing
 // @Accept json
 // @Produce json
@@ -619,14 +1636,42 @@ func (h *AdminHandler) UpdateExchangeRate(c *gin.Context) {
 		return
 	}
 
-	if err := h.pricingService.SetManualExchangeRate(req.Rate); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rate"})
+	from, to := money.USD, money.MNT
+	var err error
+	if req.From != "" {
+		if from, err = money.ParseCurrency(req.From); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.To != "" {
+		if to, err = money.ParseCurrency(req.To); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be different currencies"})
+		return
+	}
+
+	rate, err := money.FromFloat(req.Rate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	repriced, err := h.rateUpdater.SetManualRate(c.Request.Context(), from, to, rate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rate: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Exchange rate updated successfully",
-		"exchange_rate": req.Rate,
+		"message":           "Exchange rate updated successfully",
+		"from":              from,
+		"to":                to,
+		"exchange_rate":     req.Rate,
+		"packages_repriced": repriced,
 	})
 }
 
@@ -640,19 +1685,64 @@ func (h *AdminHandler) UpdateExchangeRate(c *gin.Context) {
 // @Security Bearer
 // @Router /admin/pricing/update-all [post]
 func (h *AdminHandler) UpdateAllProductPricing(c *gin.Context) {
-	if err := h.pricingService.UpdateAllProductPricing(); err != nil {
+	if err := h.pricingService.UpdateAllProductPricing(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product pricing"})
 		return
 	}
 
-	if err := h.pricingService.UpdateAllPackagePricing(); err != nil {
+	if err := h.pricingService.UpdateAllPackagePricing(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update package pricing"})
 		return
 	}
+	if _, err := h.productService.RepriceMNT(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update package prices"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "All product pricing updated successfully"})
 }
 
+// UpdateRoundingRule godoc
+// @Summary Update MNT rounding rule (Admin)
+// @Description Set how MNT prices are rounded (whole, up_<n> or end_<n>) and reprice stored packages (admin only)
+// @Tags Admin,Pricing
+// @Accept json
+// @Produce json
+// @Param rule body UpdateRoundingRuleRequest true "Rounding rule"
+// @Success 200 {object} map[string]interface{} "Rounding rule updated"
+// @Failure 400 {object} map[string]interface{} "Invalid rule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security Bearer
+// @Router /admin/pricing/rounding-rule [put]
+func (h *AdminHandler) UpdateRoundingRule(c *gin.Context) {
+	var req UpdateRoundingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.pricingService.SetRoundingRule(c.Request.Context(), req.Rule)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRoundingRule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	repriced, err := h.productService.RepriceMNT(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Rounding rule updated successfully",
+		"rounding_rule":     rule.Name,
+		"packages_repriced": repriced,
+	})
+}
+
 // SetProductPrice godoc
 // @Summary Set product price (Admin)
 // @Description Set a manual price override for a specific product (admin only)
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -17,18 +19,32 @@ type OrderHandler struct {
 type CreateOrderRequest struct {
 	ProductID string `json:"product_id" binding:"required"`
 	// One of PackagePriceID (internal) or ProviderPriceID (upstream price_id) must be supplied to select package pricing
-	PackagePriceID  *string  `json:"package_price_id"`
-	ProviderPriceID *int     `json:"provider_price_id"`
-	CustomerEmail   string   `json:"customer_email" binding:"required,email"`
-	CustomerPhone   string   `json:"customer_phone"`
-	UserID          *string  `json:"user_id"`
-	CustomPriceUSD  *float64 `json:"custom_price_usd"`
+	PackagePriceID  *string `json:"package_price_id"`
+	ProviderPriceID *int    `json:"provider_price_id"`
+	CustomerEmail   string  `json:"customer_email" binding:"required,email"`
+	CustomerPhone   string  `json:"customer_phone"`
+	UserID          *string `json:"user_id"`
 }
 
 type PaymentInitiationRequest struct {
 	OrderNumber string `json:"order_number" binding:"required"`
 }
 
+// writeUpstreamUnavailable answers 503 with msg when err means QPay or the eSIM provider
+// is down, adding Retry-After when the circuit breaker knows when it will try again.
+// It reports whether a response was written.
+func writeUpstreamUnavailable(c *gin.Context, err error, msg string) bool {
+	if err == nil || !services.IsUpstreamUnavailable(err) {
+		return false
+	}
+	var open *services.CircuitOpenError
+	if errors.As(err, &open) && open.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(open.RetryAfter.Seconds()))))
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": msg})
+	return true
+}
+
 func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
@@ -44,6 +60,8 @@ func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
 // @Param order body CreateOrderRequest true "Order details (include package_price_id or provider_price_id)"
 // @Success 201 {object} map[string]interface{} "Order created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 409 {object} map[string]interface{} "Package not available for sale"
+// @Failure 503 {object} map[string]interface{} "eSIM provider or QPay unavailable, or no exchange rate"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
@@ -88,9 +106,20 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		packagePriceUUID = &ppid
 	}
 
-	orderReq := services.CreateOrderRequest{ProductID: productID, PackagePriceID: packagePriceUUID, ProviderPriceID: req.ProviderPriceID, CustomerEmail: req.CustomerEmail, CustomerPhone: req.CustomerPhone, UserID: userID, CustomPriceUSD: req.CustomPriceUSD}
+	orderReq := services.CreateOrderRequest{ProductID: productID, PackagePriceID: packagePriceUUID, ProviderPriceID: req.ProviderPriceID, CustomerEmail: req.CustomerEmail, CustomerPhone: req.CustomerPhone, UserID: userID}
 
-	order, err := h.orderService.CreateOrder(orderReq)
+	order, err := h.orderService.CreateOrder(c.Request.Context(), orderReq)
+	if writeUpstreamUnavailable(c, err, "Purchases are temporarily unavailable, please try again shortly") {
+		return
+	}
+	if errors.Is(err, services.ErrPackageUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrRateUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Prices cannot be calculated right now, please try again shortly"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,7 +140,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	orderNumber := c.Param("orderNumber")
 
-	order, err := h.orderService.GetOrder(orderNumber)
+	order, err := h.orderService.GetOrder(c.Request.Context(), orderNumber)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
@@ -127,12 +156,21 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 // @Produce json
 // @Param orderNumber path string true "Order Number"
 // @Success 200 {object} map[string]interface{} "Payment initiation response"
+// @Failure 410 {object} map[string]interface{} "Order expired"
+// @Failure 503 {object} map[string]interface{} "QPay unavailable"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders/{orderNumber}/payment [post]
 func (h *OrderHandler) InitiatePayment(c *gin.Context) {
 	orderNumber := c.Param("orderNumber")
 
-	response, err := h.orderService.InitiatePayment(orderNumber)
+	response, err := h.orderService.InitiatePayment(c.Request.Context(), orderNumber)
+	if errors.Is(err, services.ErrOrderExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": "Order has expired, please place a new order"})
+		return
+	}
+	if writeUpstreamUnavailable(c, err, "Payments are temporarily unavailable, please try again shortly") {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -172,7 +210,7 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	orders, total, err := h.orderService.GetUserOrders(parsedUserID, page, limit)
+	orders, total, err := h.orderService.GetUserOrders(c.Request.Context(), parsedUserID, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -204,7 +242,7 @@ func (h *OrderHandler) GetAllOrders(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	status := c.Query("status")
 
-	orders, total, err := h.orderService.GetAllOrders(page, limit, status)
+	orders, total, err := h.orderService.GetAllOrders(c.Request.Context(), page, limit, status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -244,40 +282,3 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	// For now, we'll return an error
 	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
 }
-
-// UpdateOrderStatus godoc
-// @Summary Update order status (Admin)
-// @Description Update the status of a specific order (admin only)
-// @Tags Orders,Admin
-// @Accept json
-// @Produce json
-// @Param id path string true "Order ID (UUID)"
-// @Param status body map[string]string true "Status update"
-// @Success 200 {object} map[string]interface{} "Status updated"
-// @Failure 400 {object} map[string]interface{} "Invalid order ID or request"
-// @Failure 501 {object} map[string]interface{} "Not implemented"
-// @Security Bearer
-// @Router /admin/orders/{id}/status [put]
-func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
-	orderID := c.Param("id")
-
-	// Parse UUID
-	_, err := uuid.Parse(orderID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
-		return
-	}
-
-	var req struct {
-		Status string `json:"status" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// This would need to be implemented in the order service
-	// For now, we'll return an error
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
-}
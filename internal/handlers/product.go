@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"esim-platform/internal/models"
+	"esim-platform/internal/money"
 	"esim-platform/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -16,49 +17,52 @@ type ProductHandler struct {
 }
 
 type CreateProductRequest struct {
-	SKUID          string   `json:"sku_id" binding:"required"`
-	Name           string   `json:"name" binding:"required"`
-	Description    string   `json:"description"`
-	DataLimit      string   `json:"data_limit"`
-	ValidityDays   int      `json:"validity_days"`
-	Countries      []string `json:"countries"`
-	Continent      string   `json:"continent"`
-	BasePrice      float64  `json:"base_price" binding:"required"`
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
+	SKUID          string         `json:"sku_id" binding:"required"`
+	Name           string         `json:"name" binding:"required"`
+	Description    string         `json:"description"`
+	DataLimit      string         `json:"data_limit"`
+	ValidityDays   int            `json:"validity_days"`
+	Countries      []string       `json:"countries"`
+	Continent      string         `json:"continent"`
+	BasePrice      *money.Decimal `json:"base_price" binding:"required"`
+	CustomPriceUSD *money.Decimal `json:"custom_price_usd"`
 }
 
 type UpdateProductRequest struct {
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DataLimit      string   `json:"data_limit"`
-	ValidityDays   int      `json:"validity_days"`
-	Countries      []string `json:"countries"`
-	Continent      string   `json:"continent"`
-	BasePrice      float64  `json:"base_price"`
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
-	IsActive       *bool    `json:"is_active"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	DataLimit      string         `json:"data_limit"`
+	ValidityDays   int            `json:"validity_days"`
+	Countries      []string       `json:"countries"`
+	Continent      string         `json:"continent"`
+	BasePrice      *money.Decimal `json:"base_price"`
+	CustomPriceUSD *money.Decimal `json:"custom_price_usd"`
+	IsActive       *bool          `json:"is_active"`
 }
 
 type ProductResponse struct {
-	ID             string   `json:"id"`
-	SKUID          string   `json:"sku_id"`
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DataLimit      string   `json:"data_limit"`
-	ValidityDays   int      `json:"validity_days"`
-	Countries      []string `json:"countries"`
-	Continent      string   `json:"continent"`
-	BasePrice      float64  `json:"base_price"`
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
-	PriceMNT       *float64 `json:"price_mnt"`
-	DisplayPrice   float64  `json:"display_price"`
-	Currency       string   `json:"currency"`
-	ExchangeRate   *float64 `json:"exchange_rate,omitempty"`
-	ProfitMargin   *float64 `json:"profit_margin,omitempty"`
-	IsActive       bool     `json:"is_active"`
-	LastSyncedAt   *string  `json:"last_synced_at,omitempty"`
-	CreatedAt      string   `json:"created_at"`
-	UpdatedAt      string   `json:"updated_at"`
+	ID             string         `json:"id"`
+	SKUID          string         `json:"sku_id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	DataLimit      string         `json:"data_limit"`
+	ValidityDays   int            `json:"validity_days"`
+	Countries      []string       `json:"countries"`
+	Continent      string         `json:"continent"`
+	BasePrice      money.Decimal  `json:"base_price"`
+	CustomPriceUSD *money.Decimal `json:"custom_price_usd"`
+	PriceMNT       *money.Decimal `json:"price_mnt"`
+	DisplayPrice   money.Decimal  `json:"display_price"` // in Currency
+	Currency       string         `json:"currency"`
+	ChargeCurrency string         `json:"charge_currency"` // orders are always paid in MNT
+	// DisplayRate is the MNT per Currency rate DisplayPrice was converted at; omitted for MNT
+	DisplayRate  *services.ExchangeRate `json:"display_rate,omitempty"`
+	ExchangeRate *money.Decimal         `json:"exchange_rate,omitempty"`
+	ProfitMargin *float64               `json:"profit_margin,omitempty"`
+	IsActive     bool                   `json:"is_active"`
+	LastSyncedAt *string                `json:"last_synced_at,omitempty"`
+	CreatedAt    string                 `json:"created_at"`
+	UpdatedAt    string                 `json:"updated_at"`
 }
 
 // ProductsByContinentResponse represents products grouped by continent for documentation
@@ -78,16 +82,71 @@ func NewProductHandler(productService *services.ProductService) *ProductHandler
 	}
 }
 
+// storefrontCurrency returns the currency prices are shown in: the currency query
+// parameter, else one matching the Accept-Language header, else MNT. It answers 400 for
+// an unsupported currency.
+func storefrontCurrency(c *gin.Context) (money.Currency, bool) {
+	param := c.Query("currency")
+	if param == "" {
+		return services.CurrencyForLanguage(c.GetHeader("Accept-Language")), true
+	}
+	currency, err := money.ParseCurrency(param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "supported_currencies": money.Currencies})
+		return "", false
+	}
+	return currency, true
+}
+
+// displayRate resolves the request's display currency to a rate. Without a rate for the
+// currency prices fall back to MNT rather than failing the page.
+func (h *ProductHandler) displayRate(c *gin.Context) (*services.ExchangeRate, bool) {
+	currency, ok := storefrontCurrency(c)
+	if !ok {
+		return nil, false
+	}
+	rate, err := h.productService.DisplayRate(c.Request.Context(), currency)
+	if err != nil {
+		return nil, true
+	}
+	return rate, true
+}
+
 // GetSKUList godoc
 // @Summary Get available SKUs
 // @Description Public: Retrieve the raw list of available eSIM SKUs from provider (for client SKU selection)
 // @Tags Products
 // @Produce json
 // @Success 200 {array} services.SKUInfo "List of SKUs"
+// @Failure 503 {object} map[string]interface{} "Provider unavailable"
 // @Failure 500 {object} map[string]interface{} "Failed to retrieve SKUs"
 // @Router /products/skus [get]
 func (h *ProductHandler) GetSKUList(c *gin.Context) {
-	skuList, err := h.productService.GetSKUList()
+	skuList, err := h.productService.GetSKUList(c.Request.Context())
+	if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, skuList)
+}
+
+// GetSKUsByContinent godoc
+// @Summary Get SKUs grouped by continent
+// @Description Public: Retrieve the provider SKU list grouped by continent
+// @Tags Products
+// @Produce json
+// @Success 200 {array} services.SKUInfo "List of SKUs"
+// @Failure 503 {object} map[string]interface{} "Provider unavailable"
+// @Failure 500 {object} map[string]interface{} "Failed to retrieve SKUs"
+// @Router /products/skus/continents [get]
+func (h *ProductHandler) GetSKUsByContinent(c *gin.Context) {
+	skuList, err := h.productService.GetSKUsByContinent(c.Request.Context())
+	if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -103,11 +162,15 @@ func (h *ProductHandler) GetSKUList(c *gin.Context) {
 // @Param skuId path string true "SKU ID"
 // @Success 200 {object} services.SKUInfo "SKU details"
 // @Failure 404 {object} map[string]interface{} "SKU not found"
+// @Failure 503 {object} map[string]interface{} "Provider unavailable"
 // @Failure 500 {object} map[string]interface{} "Failed to retrieve SKU"
 // @Router /products/sku/{skuId} [get]
 func (h *ProductHandler) GetSKU(c *gin.Context) {
 	skuID := c.Param("skuId")
-	sku, err := h.productService.GetSKUByID(skuID)
+	sku, err := h.productService.GetSKUByID(c.Request.Context(), skuID)
+	if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -115,8 +178,9 @@ func (h *ProductHandler) GetSKU(c *gin.Context) {
 	c.JSON(http.StatusOK, sku)
 }
 
-// convertToProductResponse converts a Product model to ProductResponse
-func (h *ProductHandler) convertToProductResponse(product models.Product) ProductResponse {
+// convertToProductResponse converts a Product model to ProductResponse, showing its
+// price in the rate's currency (MNT for a nil rate)
+func (h *ProductHandler) convertToProductResponse(product models.Product, rate *services.ExchangeRate) ProductResponse {
 	response := ProductResponse{
 		ID:             product.ID.String(),
 		SKUID:          product.SKUID,
@@ -130,7 +194,8 @@ func (h *ProductHandler) convertToProductResponse(product models.Product) Produc
 		CustomPriceUSD: product.CustomPriceUSD,
 		PriceMNT:       product.PriceMNT,
 		DisplayPrice:   product.GetDisplayPrice(),
-		Currency:       "MNT", // Default to MNT for Mongolian users
+		Currency:       string(money.MNT), // Default to MNT for Mongolian users
+		ChargeCurrency: string(money.MNT),
 		ExchangeRate:   product.ExchangeRate,
 		ProfitMargin:   product.ProfitMargin,
 		IsActive:       product.IsActive,
@@ -138,6 +203,12 @@ func (h *ProductHandler) convertToProductResponse(product models.Product) Produc
 		UpdatedAt:      product.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
+	if rate != nil {
+		response.DisplayPrice = rate.Convert(money.New(response.DisplayPrice, money.MNT)).Amount
+		response.Currency = string(rate.From)
+		response.DisplayRate = rate
+	}
+
 	if product.LastSyncedAt != nil {
 		syncTime := product.LastSyncedAt.Format("2006-01-02T15:04:05Z")
 		response.LastSyncedAt = &syncTime
@@ -148,14 +219,17 @@ func (h *ProductHandler) convertToProductResponse(product models.Product) Produc
 
 // GetProducts godoc
 // @Summary Get all products
-// @Description Retrieve list of all available eSIM products with MNT pricing
+// @Description Retrieve list of all available eSIM products with MNT pricing, shown in the requested currency
 // @Tags Products
 // @Produce json
 // @Param continent query string false "Filter by continent" Enums(Asia, Europe, Africa, Americas, Oceania)
 // @Param active query string false "Filter by active status" Enums(true, false)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(20)
+// @Param currency query string false "Display currency (default from Accept-Language, else MNT)" Enums(MNT, USD, CNY, KRW)
+// @Param Accept-Language header string false "Picks the display currency when currency is omitted"
 // @Success 200 {object} map[string]interface{} "List of products"
+// @Failure 400 {object} map[string]interface{} "Unsupported currency"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
@@ -164,9 +238,13 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	active := c.Query("active")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	rate, ok := h.displayRate(c)
+	if !ok {
+		return
+	}
 
 	// Get products from service
-	products, total, err := h.productService.GetProducts(page, limit, continent, active)
+	products, total, err := h.productService.GetProducts(c.Request.Context(), page, limit, continent, active)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -175,7 +253,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Convert to response format with MNT pricing
 	var productResponses []ProductResponse
 	for _, product := range products {
-		productResponses = append(productResponses, h.convertToProductResponse(product))
+		productResponses = append(productResponses, h.convertToProductResponse(product, rate))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -192,11 +270,18 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 // @Description Retrieve active products grouped by continent
 // @Tags Products
 // @Produce json
+// @Param currency query string false "Display currency (default from Accept-Language, else MNT)" Enums(MNT, USD, CNY, KRW)
+// @Param Accept-Language header string false "Picks the display currency when currency is omitted"
 // @Success 200 {object} handlers.ProductsByContinentResponse "Products grouped by continent"
+// @Failure 400 {object} map[string]interface{} "Unsupported currency"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/continents [get]
 func (h *ProductHandler) GetProductsByContinent(c *gin.Context) {
-	products, err := h.productService.GetProductsByContinent()
+	rate, ok := h.displayRate(c)
+	if !ok {
+		return
+	}
+	products, err := h.productService.GetProductsByContinent(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -207,7 +292,7 @@ func (h *ProductHandler) GetProductsByContinent(c *gin.Context) {
 	for continent, continentProducts := range products {
 		var productResponses []ProductResponse
 		for _, product := range continentProducts {
-			productResponses = append(productResponses, h.convertToProductResponse(product))
+			productResponses = append(productResponses, h.convertToProductResponse(product, rate))
 		}
 		responseMap[continent] = productResponses
 	}
@@ -222,8 +307,10 @@ func (h *ProductHandler) GetProductsByContinent(c *gin.Context) {
 // @Tags Products
 // @Produce json
 // @Param id path string true "Product ID (UUID)"
+// @Param currency query string false "Display currency (default from Accept-Language, else MNT)" Enums(MNT, USD, CNY, KRW)
+// @Param Accept-Language header string false "Picks the display currency when currency is omitted"
 // @Success 200 {object} handlers.ProductResponse "Product details"
-// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID or unsupported currency"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProduct(c *gin.Context) {
@@ -235,15 +322,19 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
 		return
 	}
+	rate, ok := h.displayRate(c)
+	if !ok {
+		return
+	}
 
-	product, err := h.productService.GetProduct(id)
+	product, err := h.productService.GetProduct(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
 
 	// Convert to response format with MNT pricing
-	response := h.convertToProductResponse(*product)
+	response := h.convertToProductResponse(*product, rate)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -255,23 +346,37 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 // @Produce json
 // @Param skuId path string true "SKU ID"
 // @Param detailed query bool false "If true returns detailed provider package structure"
+// @Param currency query string false "Display currency of detailed package prices (default from Accept-Language, else MNT)" Enums(MNT, USD, CNY, KRW)
+// @Param Accept-Language header string false "Picks the display currency when currency is omitted"
 // @Success 200 {array} services.PackageInfo "Basic list of packages"
 // @Success 200 {object} services.EnrichedRoamWiFiPackagesResponse "Detailed packages with pricing when detailed=true"
+// @Failure 400 {object} map[string]interface{} "Unsupported currency"
+// @Failure 503 {object} map[string]interface{} "Provider unavailable and no cached packages"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/sku/{skuId}/packages [get]
 func (h *ProductHandler) GetPackagesBySKU(c *gin.Context) {
 	skuID := c.Param("skuId")
 	if c.Query("detailed") == "true" || c.Query("detailed") == "1" {
-		resp, err := h.productService.GetPackagesDetailed(skuID)
+		rate, ok := h.displayRate(c)
+		if !ok {
+			return
+		}
+		resp, err := h.productService.GetPackagesDetailed(c.Request.Context(), skuID)
+		if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, resp)
+		c.JSON(http.StatusOK, resp.InCurrency(rate))
 		return
 	}
 	if c.Query("raw") == "true" { // return raw legacy structure
-		raw, err := h.productService.GetPackagesRaw(skuID)
+		raw, err := h.productService.GetPackagesRaw(c.Request.Context(), skuID)
+		if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -279,7 +384,10 @@ func (h *ProductHandler) GetPackagesBySKU(c *gin.Context) {
 		c.JSON(http.StatusOK, raw)
 		return
 	}
-	packages, err := h.productService.GetPackagesBySKU(skuID)
+	packages, err := h.productService.GetPackagesBySKU(c.Request.Context(), skuID)
+	if writeUpstreamUnavailable(c, err, "The eSIM catalog is temporarily unavailable, please try again shortly") {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -307,7 +415,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		CustomPriceUSD: req.CustomPriceUSD,
 	}
 
-	product, err := h.productService.CreateProduct(serviceReq)
+	product, err := h.productService.CreateProduct(c.Request.Context(), serviceReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -345,7 +453,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		IsActive:       req.IsActive,
 	}
 
-	product, err := h.productService.UpdateProduct(id, serviceReq)
+	product, err := h.productService.UpdateProduct(c.Request.Context(), id, serviceReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -365,7 +473,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.DeleteProduct(id); err != nil {
+	if err := h.productService.DeleteProduct(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -375,7 +483,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 // SyncProductsFromRoamWiFi syncs products from RoamWiFi API (admin only)
 func (h *ProductHandler) SyncProductsFromRoamWiFi(c *gin.Context) {
-	count, err := h.productService.SyncProductsFromRoamWiFi()
+	count, err := h.productService.SyncProductsFromRoamWiFi(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
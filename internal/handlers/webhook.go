@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"esim-platform/internal/services"
@@ -28,9 +29,10 @@ func NewWebhookHandler(orderService *services.OrderService, qpayService *service
 // @Accept json
 // @Produce json
 // @Param webhook body map[string]interface{} true "QPay webhook data"
-// @Success 200 {object} map[string]interface{} "Webhook processed successfully"
+// @Success 200 {object} map[string]interface{} "Webhook processed successfully (or already processed)"
 // @Failure 400 {object} map[string]interface{} "Invalid webhook data"
 // @Failure 401 {object} map[string]interface{} "Invalid webhook signature"
+// @Failure 422 {object} map[string]interface{} "Payment not confirmed by QPay"
 // @Failure 500 {object} map[string]interface{} "Failed to process webhook"
 // @Router /webhooks/qpay [post]
 func (h *WebhookHandler) HandleQPayWebhook(c *gin.Context) {
@@ -63,7 +65,19 @@ func (h *WebhookHandler) HandleQPayWebhook(c *gin.Context) {
 	}
 
 	// Process the payment webhook
-	if err := h.orderService.ProcessPaymentWebhook(qpayWebhookData); err != nil {
+	if err := h.orderService.ProcessPaymentWebhook(c.Request.Context(), qpayWebhookData, webhookBytes); err != nil {
+		if errors.Is(err, services.ErrDuplicateWebhook) {
+			// Already handled; acknowledge so QPay stops retrying
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "duplicate",
+				"message": "Webhook already processed",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrPaymentRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Payment could not be verified with QPay"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment webhook"})
 		return
 	}
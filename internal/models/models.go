@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"esim-platform/internal/money"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
@@ -81,25 +83,52 @@ type User struct {
 }
 
 type Product struct {
-	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	SKUID        string      `json:"sku_id" gorm:"column:sku_id;not null"`
-	Name         string      `json:"name" gorm:"not null"`
-	Description  string      `json:"description"`
-	DataLimit    string      `json:"data_limit"`
-	ValidityDays int         `json:"validity_days"`
-	Countries    StringArray `json:"countries" gorm:"type:text[]"`
-	Continent    string      `json:"continent"`
-	BasePrice    float64     `json:"base_price" gorm:"not null"`
+	ID           uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SKUID        string        `json:"sku_id" gorm:"column:sku_id;not null"`
+	Name         string        `json:"name" gorm:"not null"`
+	Description  string        `json:"description"`
+	DataLimit    string        `json:"data_limit"`
+	ValidityDays int           `json:"validity_days"`
+	Countries    StringArray   `json:"countries" gorm:"type:text[]"`
+	Continent    string        `json:"continent"`
+	BasePrice    money.Decimal `json:"base_price" gorm:"not null"`
 	// CustomPriceUSD optional product-level USD override used for display if set
-	CustomPriceUSD     *float64   `json:"custom_price_usd"`
-	PriceMNT           *float64   `json:"price_mnt"`            // Price in Mongolian Tugrik
-	ExchangeRate       *float64   `json:"exchange_rate"`        // USD to MNT exchange rate used
-	ProfitMargin       *float64   `json:"profit_margin"`        // Profit margin percentage
-	AdminPriceOverride *float64   `json:"admin_price_override"` // Manual price override by admin
-	IsActive           bool       `json:"is_active" gorm:"default:true"`
-	LastSyncedAt       *time.Time `json:"last_synced_at"` // When last synced from RoamWiFi
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	CustomPriceUSD     *money.Decimal `json:"custom_price_usd"`
+	PriceMNT           *money.Decimal `json:"price_mnt"`            // Price in Mongolian Tugrik
+	ExchangeRate       *money.Decimal `json:"exchange_rate"`        // USD to MNT exchange rate used
+	ProfitMargin       *float64       `json:"profit_margin"`        // Profit margin percentage
+	AdminPriceOverride *money.Decimal `json:"admin_price_override"` // Manual price override by admin
+	IsActive           bool           `json:"is_active" gorm:"default:true"`
+	LastSyncedAt       *time.Time     `json:"last_synced_at"` // When last synced from RoamWiFi
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+// OrderStatus is a state in the order lifecycle
+type OrderStatus string
+
+const (
+	OrderStatusPending            OrderStatus = "pending"
+	OrderStatusAwaitingPayment    OrderStatus = "awaiting_payment"
+	OrderStatusPaid               OrderStatus = "paid"
+	OrderStatusProvisioning       OrderStatus = "provisioning"
+	OrderStatusCompleted          OrderStatus = "completed"
+	OrderStatusProvisioningFailed OrderStatus = "provisioning_failed"
+	OrderStatusNeedsAttention     OrderStatus = "needs_attention" // provider outcome unknown; never refunded automatically
+	OrderStatusRefunded           OrderStatus = "refunded"
+	OrderStatusCancelled          OrderStatus = "cancelled"
+	OrderStatusExpired            OrderStatus = "expired"
+)
+
+// IsValid reports whether s is a known order status
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusAwaitingPayment, OrderStatusPaid, OrderStatusProvisioning,
+		OrderStatusCompleted, OrderStatusProvisioningFailed, OrderStatusNeedsAttention, OrderStatusRefunded,
+		OrderStatusCancelled, OrderStatusExpired:
+		return true
+	}
+	return false
 }
 
 type Order struct {
@@ -113,29 +142,176 @@ type Order struct {
 	PackagePrice        *PackagePrice        `json:"package_price"`
 	ProviderPriceID     *int                 `json:"provider_price_id" gorm:"index"`
 	OrderNumber         string               `json:"order_number" gorm:"uniqueIndex;not null"`
-	QPayInvoiceID       string               `json:"qpay_invoice_id"`
-	Status              string               `json:"status" gorm:"default:'pending'"`
-	Amount              float64              `json:"amount" gorm:"not null"`
+	QPayInvoiceID       string               `json:"qpay_invoice_id" gorm:"column:qpay_invoice_id"`
+	Status              OrderStatus          `json:"status" gorm:"default:'pending'"`
+	Amount              money.Decimal        `json:"amount" gorm:"not null"`
 	Currency            string               `json:"currency" gorm:"default:'MNT'"`
 	CustomerEmail       string               `json:"customer_email"`
 	CustomerPhone       string               `json:"customer_phone"`
-	RoamWiFiOrderID     string               `json:"roamwifi_order_id"`
+	RoamWiFiOrderID     string               `json:"roamwifi_order_id" gorm:"column:roamwifi_order_id"`
 	ESIMData            *string              `json:"esim_data" gorm:"type:jsonb"`
+	ProvisionAttempt    *time.Time           `json:"provision_attempt,omitempty"` // RoamWiFi order sent, outcome not yet known
+	ExpiresAt           *time.Time           `json:"expires_at" gorm:"index"`     // end of the payment window and price lock
 	PaymentTransactions []PaymentTransaction `json:"payment_transactions,omitempty"`
 	CreatedAt           time.Time            `json:"created_at"`
 	UpdatedAt           time.Time            `json:"updated_at"`
 }
 
+// IsExpired reports whether the order's payment window has passed. Orders without an
+// expiry (created before expiry existed) never expire.
+func (o *Order) IsExpired(now time.Time) bool {
+	return o.ExpiresAt != nil && now.After(*o.ExpiresAt)
+}
+
 type PaymentTransaction struct {
-	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	OrderID           uuid.UUID `json:"order_id"`
-	Order             Order     `json:"order,omitempty"`
-	QPayTransactionID string    `json:"qpay_transaction_id"`
-	Amount            float64   `json:"amount" gorm:"not null"`
-	Status            string    `json:"status" gorm:"not null"`
-	PaymentMethod     string    `json:"payment_method"`
-	TransactionData   string    `json:"transaction_data" gorm:"type:jsonb"`
-	CreatedAt         time.Time `json:"created_at"`
+	ID                uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID           uuid.UUID     `json:"order_id"`
+	Order             Order         `json:"order,omitempty"`
+	QPayTransactionID string        `json:"qpay_transaction_id" gorm:"column:qpay_transaction_id"`
+	Amount            money.Decimal `json:"amount" gorm:"not null"` // MNT, negative for refunds
+	Status            string        `json:"status" gorm:"not null"`
+	Type              string        `json:"type" gorm:"not null;default:'payment'"`
+	PaymentMethod     string        `json:"payment_method"`
+	TransactionData   string        `json:"transaction_data" gorm:"type:jsonb"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+// PaymentTransaction types
+const (
+	PaymentTransactionTypePayment = "payment"
+	PaymentTransactionTypeRefund  = "refund"
+)
+
+// OrderStatusHistory records a single order lifecycle transition
+type OrderStatusHistory struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID    uuid.UUID   `json:"order_id" gorm:"type:uuid;index;not null"`
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status" gorm:"not null"`
+	Actor      string      `json:"actor"` // system|qpay_webhook|admin:<user_id>
+	Reason     string      `json:"reason"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// TableName keeps the history table name singular
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// Webhook event processing states
+const (
+	WebhookEventReceived  = "received"
+	WebhookEventProcessed = "processed"
+	WebhookEventIgnored   = "ignored"  // recorded but did not change the order (duplicate/out-of-order)
+	WebhookEventRejected  = "rejected" // QPay did not confirm the payment (status or amount mismatch); re-verified on redelivery
+	WebhookEventFailed    = "failed"   // processing errored; a redelivery will be retried
+)
+
+// WebhookEvent stores each distinct provider callback so it is processed exactly once
+type WebhookEvent struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Provider      string     `json:"provider" gorm:"uniqueIndex:uniq_webhook_event;not null"`
+	EventKey      string     `json:"event_key" gorm:"uniqueIndex:uniq_webhook_event;not null"` // invoice:transaction:status
+	InvoiceID     string     `json:"invoice_id" gorm:"index"`
+	TransactionID string     `json:"transaction_id"`
+	OrderNumber   string     `json:"order_number" gorm:"index"`
+	PaymentStatus string     `json:"payment_status"`
+	Payload       string     `json:"payload" gorm:"type:jsonb"`
+	Status        string     `json:"status" gorm:"not null;default:'received'"`
+	Result        string     `json:"result"` // ignore reason or processing error
+	Attempts      int        `json:"attempts"`
+	ProcessedAt   *time.Time `json:"processed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Background job types
+const (
+	JobTypeProvisionESIM = "provision_esim"
+	JobTypeSendESIMEmail = "send_esim_email"
+)
+
+// Background job states
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead" // retries exhausted; needs an admin to inspect or retry
+)
+
+// Job is a durable unit of background work, retried with backoff until it succeeds or dies
+type Job struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type        string     `json:"type" gorm:"index;not null"`
+	OrderID     *uuid.UUID `json:"order_id" gorm:"type:uuid;index"`
+	Payload     string     `json:"payload" gorm:"type:jsonb"`
+	Status      string     `json:"status" gorm:"index:idx_jobs_due,priority:1;not null;default:'queued'"`
+	RunAt       time.Time  `json:"run_at" gorm:"index:idx_jobs_due,priority:2;not null"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	LastError   string     `json:"last_error"`
+	LockedAt    *time.Time `json:"locked_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Catalog sync run states
+const (
+	SyncRunRunning   = "running"
+	SyncRunSucceeded = "succeeded"
+	SyncRunPartial   = "partial" // some SKUs failed to sync
+	SyncRunFailed    = "failed"  // the SKU list could not be synced, or the run was abandoned
+)
+
+// SyncRun records one synchronization of the provider catalog into products and package prices
+type SyncRun struct {
+	ID                  uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Trigger             string      `json:"trigger" gorm:"not null"` // schedule|admin:<user_id>
+	Status              string      `json:"status" gorm:"index;not null;default:'running'"`
+	StartedAt           time.Time   `json:"started_at" gorm:"index;not null"`
+	FinishedAt          *time.Time  `json:"finished_at"`
+	SKUsTotal           int         `json:"skus_total" gorm:"column:skus_total"`
+	SKUsSynced          int         `json:"skus_synced" gorm:"column:skus_synced"`
+	SKUsFailed          int         `json:"skus_failed" gorm:"column:skus_failed"`
+	PackagesAdded       int         `json:"packages_added"`
+	PackagesChanged     int         `json:"packages_changed"`
+	PackagesDeactivated int         `json:"packages_deactivated"`
+	Changes             int         `json:"changes"`         // catalog_changes rows recorded
+	ChangesFlagged      int         `json:"changes_flagged"` // of which beyond the price change threshold
+	PricingAlerts       int         `json:"pricing_alerts"`  // packages below their minimum margin
+	Errors              StringArray `json:"errors" gorm:"type:text[]"`
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+}
+
+// Catalog change types
+const (
+	CatalogChangeAdded   = "added"   // new, or previously deactivated, package
+	CatalogChangeRemoved = "removed" // no longer returned by the provider; deactivated
+	CatalogChangePrice   = "price_changed"
+	CatalogChangeData    = "data_changed" // data allowance (flows/unit)
+	CatalogChangeDays    = "days_changed"
+)
+
+// CatalogChange is one difference between the provider catalog and the stored package
+// prices found by a sync. OldValue/NewValue hold the provider price (USD) for added,
+// removed and price changes, the data allowance for data changes and the validity for
+// day changes.
+type CatalogChange struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SyncRunID       *uuid.UUID `json:"sync_run_id" gorm:"type:uuid;index"` // nil for single-SKU syncs
+	SKUID           string     `json:"sku_id" gorm:"column:sku_id;index;not null"`
+	ProviderPriceID int        `json:"provider_price_id" gorm:"index"`
+	APICode         string     `json:"api_code"`
+	ShowName        string     `json:"show_name"`
+	Type            string     `json:"type" gorm:"index;not null"`
+	OldValue        *float64   `json:"old_value"`
+	NewValue        *float64   `json:"new_value"`
+	ChangePercent   *float64   `json:"change_percent"`
+	Detail          string     `json:"detail"`
+	Flagged         bool       `json:"flagged" gorm:"index"` // price change beyond the alert threshold
+	CreatedAt       time.Time  `json:"created_at" gorm:"index"`
 }
 
 type AdminSetting struct {
@@ -162,45 +338,53 @@ type AuditLog struct {
 
 // Package represents an eSIM package offered by RoamWiFi
 type Package struct {
-	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	SKUID              string     `json:"sku_id" gorm:"column:sku_id;not null"`
-	PackageID          string     `json:"package_id" gorm:"not null"`
-	PackageName        string     `json:"package_name" gorm:"not null"`
-	DataLimit          string     `json:"data_limit"`
-	ValidityDays       int        `json:"validity_days"`
-	Countries          []string   `json:"countries" gorm:"type:text[]"`
-	BasePrice          float64    `json:"base_price" gorm:"not null"`
-	PriceMNT           *float64   `json:"price_mnt"`
-	ExchangeRate       *float64   `json:"exchange_rate"`
-	ProfitMargin       *float64   `json:"profit_margin"`
-	AdminPriceOverride *float64   `json:"admin_price_override"`
-	IsActive           bool       `json:"is_active" gorm:"default:true"`
-	LastSyncedAt       *time.Time `json:"last_synced_at"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	ID                 uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SKUID              string         `json:"sku_id" gorm:"column:sku_id;not null"`
+	PackageID          string         `json:"package_id" gorm:"not null"`
+	PackageName        string         `json:"package_name" gorm:"not null"`
+	DataLimit          string         `json:"data_limit"`
+	ValidityDays       int            `json:"validity_days"`
+	Countries          []string       `json:"countries" gorm:"type:text[]"`
+	BasePrice          money.Decimal  `json:"base_price" gorm:"not null"`
+	PriceMNT           *money.Decimal `json:"price_mnt"`
+	ExchangeRate       *money.Decimal `json:"exchange_rate"`
+	ProfitMargin       *float64       `json:"profit_margin"`
+	AdminPriceOverride *money.Decimal `json:"admin_price_override"`
+	IsActive           bool           `json:"is_active" gorm:"default:true"`
+	LastSyncedAt       *time.Time     `json:"last_synced_at"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
 }
 
 // PackagePrice stores pricing & override data for provider package (using provider price_id)
 type PackagePrice struct {
-	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	SKUID             string     `json:"sku_id" gorm:"column:sku_id;index;not null"`
-	ProviderPriceID   int        `json:"provider_price_id" gorm:"uniqueIndex:uniq_provider_price"`
-	APICode           string     `json:"api_code" gorm:"index"`
-	ShowName          string     `json:"show_name"`
-	Flows             float64    `json:"flows"`
-	Unit              string     `json:"unit"`
-	Days              int        `json:"days"`
-	RawProviderPrice  float64    `json:"raw_provider_price"`
-	MarkupPercent     *float64   `json:"markup_percent"`
-	OverridePriceUSD  *float64   `json:"override_price_usd"`
-	EffectivePriceUSD float64    `json:"effective_price_usd"`
-	EffectivePriceMNT *float64   `json:"effective_price_mnt"`
-	ExchangeRate      *float64   `json:"exchange_rate"`
-	PriceSource       string     `json:"price_source"` // base|markup|override
-	Active            bool       `json:"active" gorm:"default:true"`
-	LastSyncedAt      *time.Time `json:"last_synced_at"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
+	ID                uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SKUID             string         `json:"sku_id" gorm:"column:sku_id;index;not null"`
+	ProviderPriceID   int            `json:"provider_price_id" gorm:"uniqueIndex:uniq_provider_price"`
+	APICode           string         `json:"api_code" gorm:"index"`
+	ShowName          string         `json:"show_name"`
+	Flows             float64        `json:"flows"`
+	Unit              string         `json:"unit"`
+	Days              int            `json:"days"`
+	RawProviderPrice  money.Decimal  `json:"raw_provider_price"`
+	MarkupPercent     *float64       `json:"markup_percent"`
+	OverridePriceUSD  *money.Decimal `json:"override_price_usd"`
+	PricingRuleID     *uuid.UUID     `json:"pricing_rule_id" gorm:"type:uuid;index"` // rule that priced it when price_source is rule
+	EffectivePriceUSD money.Decimal  `json:"effective_price_usd"`
+	EffectivePriceMNT *money.Decimal `json:"effective_price_mnt"`
+	// While a scheduled price change is running, the regular prices it replaced
+	RegularPriceUSD   *money.Decimal `json:"regular_price_usd"`
+	RegularPriceMNT   *money.Decimal `json:"regular_price_mnt"`
+	ScheduledChangeID *uuid.UUID     `json:"scheduled_change_id" gorm:"type:uuid;index"`
+	ExchangeRate      *money.Decimal `json:"exchange_rate"`
+	ExchangeRateID    *uuid.UUID     `json:"exchange_rate_id" gorm:"type:uuid;index"`
+	RoundingRule      string         `json:"rounding_rule"` // MNT rounding applied to EffectivePriceMNT
+	PriceSource       string         `json:"price_source"`  // base|rule|markup|override|margin_floor|sale
+	Active            bool           `json:"active" gorm:"default:true"`
+	InactiveReason    string         `json:"inactive_reason,omitempty"` // removed|margin
+	LastSyncedAt      *time.Time     `json:"last_synced_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
 }
 
 func (pp *PackagePrice) BeforeCreate(tx *gorm.DB) error {
@@ -210,16 +394,125 @@ func (pp *PackagePrice) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// Reasons a package price is inactive
+const (
+	PackageInactiveRemoved = "removed" // the provider no longer offers the package
+	PackageInactiveMargin  = "margin"  // deactivated by the margin policy
+)
+
+// Margin policy actions for packages whose price falls below the minimum margin
+const (
+	MarginActionReprice    = "reprice"    // sell at the minimum price instead
+	MarginActionDeactivate = "deactivate" // stop selling until the price is fixed
+)
+
+// MarginPolicy sets the minimum margin over the provider price for one SKU, or the
+// default for every SKU when SKUID is empty
+type MarginPolicy struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SKUID            string    `json:"sku_id" gorm:"column:sku_id;uniqueIndex"`
+	MinMarginPercent float64   `json:"min_margin_percent" gorm:"not null"`
+	Action           string    `json:"action" gorm:"not null;default:'reprice'"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Pricing rule add-on types
+const (
+	PricingAddOnPercent = "percent" // percent of the provider price
+	PricingAddOnFixed   = "fixed"   // USD amount
+)
+
+// PricingRule marks up the provider price of the packages it matches. Empty conditions
+// match every package and ranges are inclusive; among the active rules matching a package
+// the one with the lowest priority number wins.
+type PricingRule struct {
+	ID                  uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name                string         `json:"name" gorm:"not null"`
+	Priority            int            `json:"priority" gorm:"not null;index"`
+	SKUID               string         `json:"sku_id" gorm:"column:sku_id"`
+	Continent           string         `json:"continent"` // continent of the SKU's product, e.g. "Asia"
+	MinDataMB           *float64       `json:"min_data_mb"`
+	MaxDataMB           *float64       `json:"max_data_mb"`
+	MinDays             *int           `json:"min_days"`
+	MaxDays             *int           `json:"max_days"`
+	MinProviderPriceUSD *money.Decimal `json:"min_provider_price_usd"`
+	MaxProviderPriceUSD *money.Decimal `json:"max_provider_price_usd"`
+	AddOnType           string         `json:"add_on_type" gorm:"not null"` // percent|fixed
+	AddOn               money.Decimal  `json:"add_on" gorm:"not null"`
+	Active              bool           `json:"active" gorm:"default:true"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
+// Scheduled price change states. Whether a change applies is decided by its time window;
+// the state records what the scheduler has done.
+const (
+	ScheduledPriceScheduled = "scheduled"
+	ScheduledPriceActive    = "active"
+	ScheduledPriceEnded     = "ended"
+	ScheduledPriceCancelled = "cancelled"
+)
+
+// ScheduledPriceChange overrides or marks up the price of one package, every package of a
+// SKU, or every package a pricing rule wins for, from StartsAt until EndsAt
+type ScheduledPriceChange struct {
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name             string         `json:"name" gorm:"not null"`
+	PackagePriceID   *uuid.UUID     `json:"package_price_id" gorm:"type:uuid"`
+	SKUID            string         `json:"sku_id" gorm:"column:sku_id"`
+	PricingRuleID    *uuid.UUID     `json:"pricing_rule_id" gorm:"type:uuid"`
+	OverridePriceUSD *money.Decimal `json:"override_price_usd"`
+	MarkupPercent    *float64       `json:"markup_percent"`
+	StartsAt         time.Time      `json:"starts_at" gorm:"not null;index"`
+	EndsAt           time.Time      `json:"ends_at" gorm:"not null;index"`
+	Status           string         `json:"status" gorm:"not null;default:'scheduled';index"`
+	AppliedAt        *time.Time     `json:"applied_at"`
+	EndedAt          *time.Time     `json:"ended_at"` // reverted at the end or on cancellation
+	CreatedBy        string         `json:"created_by"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Pricing alert states
+const (
+	PricingAlertOpen     = "open"
+	PricingAlertResolved = "resolved"
+)
+
+// PricingAlert records a package whose configured price (override, markup or provider
+// price) is below its minimum margin. One alert stays open per package until the price
+// complies again or an admin resolves it.
+type PricingAlert struct {
+	ID                 uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SKUID              string        `json:"sku_id" gorm:"column:sku_id;index;not null"`
+	ProviderPriceID    int           `json:"provider_price_id" gorm:"index"`
+	APICode            string        `json:"api_code"`
+	ShowName           string        `json:"show_name"`
+	ProviderPriceUSD   money.Decimal `json:"provider_price_usd"`
+	ConfiguredPriceUSD money.Decimal `json:"configured_price_usd"`
+	MinPriceUSD        money.Decimal `json:"min_price_usd"`
+	MarginPercent      float64       `json:"margin_percent"` // margin of the configured price
+	MinMarginPercent   float64       `json:"min_margin_percent"`
+	Action             string        `json:"action"` // repriced|deactivated
+	Status             string        `json:"status" gorm:"index;not null;default:'open'"`
+	Occurrences        int           `json:"occurrences"` // evaluations that found the violation
+	ResolvedAt         *time.Time    `json:"resolved_at"`
+	ResolvedBy         string        `json:"resolved_by"` // sync|admin_edit|policy_change|admin:<user_id>
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+}
+
 // CurrencyRate represents exchange rates for different currencies
 type CurrencyRate struct {
-	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	FromCurrency string    `json:"from_currency" gorm:"not null"` // e.g., "USD"
-	ToCurrency   string    `json:"to_currency" gorm:"not null"`   // e.g., "MNT"
-	Rate         float64   `json:"rate" gorm:"not null"`
-	Source       string    `json:"source"` // e.g., "manual", "api", etc.
-	LastUpdated  time.Time `json:"last_updated"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FromCurrency string        `json:"from_currency" gorm:"not null;index:idx_currency_rates_pair"` // e.g., "USD"
+	ToCurrency   string        `json:"to_currency" gorm:"not null;index:idx_currency_rates_pair"`   // e.g., "MNT"
+	Rate         money.Decimal `json:"rate" gorm:"not null"`
+	Source       string        `json:"source"`       // bom|json|manual
+	LastUpdated  time.Time     `json:"last_updated"` // last confirmed by the source; CreatedAt is when it took effect
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
 // JSONB is a custom type for PostgreSQL JSONB
@@ -287,6 +580,30 @@ func (pt *PaymentTransaction) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeCreate hook for OrderStatusHistory
+func (h *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook for WebhookEvent
+func (we *WebhookEvent) BeforeCreate(tx *gorm.DB) error {
+	if we.ID == uuid.Nil {
+		we.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook for Job
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
 // BeforeCreate hook for AdminSetting
 func (as *AdminSetting) BeforeCreate(tx *gorm.DB) error {
 	if as.ID == uuid.Nil {
@@ -320,7 +637,7 @@ func (cr *CurrencyRate) BeforeCreate(tx *gorm.DB) error {
 }
 
 // GetDisplayPrice returns the price to display to customers in MNT
-func (p *Product) GetDisplayPrice() float64 {
+func (p *Product) GetDisplayPrice() money.Decimal {
 	// If admin has set a manual override, use that
 	if p.AdminPriceOverride != nil {
 		return *p.AdminPriceOverride
@@ -341,7 +658,7 @@ func (p *Product) GetDisplayPrice() float64 {
 }
 
 // GetDisplayPrice returns the price to display to customers in MNT
-func (pkg *Package) GetDisplayPrice() float64 {
+func (pkg *Package) GetDisplayPrice() money.Decimal {
 	// If admin has set a manual override, use that
 	if pkg.AdminPriceOverride != nil {
 		return *pkg.AdminPriceOverride
@@ -357,12 +674,12 @@ func (pkg *Package) GetDisplayPrice() float64 {
 }
 
 // CalculateMNTPrice calculates the MNT price based on USD base price and exchange rate
-func (p *Product) CalculateMNTPrice(usdToMntRate float64, profitMarginPercent float64) {
-	mntPrice := p.BasePrice * usdToMntRate
+func (p *Product) CalculateMNTPrice(usdToMntRate money.Decimal, profitMarginPercent float64) {
+	mntPrice := p.BasePrice.Mul(usdToMntRate)
 
 	// Apply profit margin if specified
 	if profitMarginPercent > 0 {
-		mntPrice = mntPrice * (1 + profitMarginPercent/100)
+		mntPrice = mntPrice.AddPercent(profitMarginPercent)
 	}
 
 	p.PriceMNT = &mntPrice
@@ -371,12 +688,12 @@ func (p *Product) CalculateMNTPrice(usdToMntRate float64, profitMarginPercent fl
 }
 
 // CalculateMNTPrice calculates the MNT price based on USD base price and exchange rate
-func (pkg *Package) CalculateMNTPrice(usdToMntRate float64, profitMarginPercent float64) {
-	mntPrice := pkg.BasePrice * usdToMntRate
+func (pkg *Package) CalculateMNTPrice(usdToMntRate money.Decimal, profitMarginPercent float64) {
+	mntPrice := pkg.BasePrice.Mul(usdToMntRate)
 
 	// Apply profit margin if specified
 	if profitMarginPercent > 0 {
-		mntPrice = mntPrice * (1 + profitMarginPercent/100)
+		mntPrice = mntPrice.AddPercent(profitMarginPercent)
 	}
 
 	pkg.PriceMNT = &mntPrice
@@ -0,0 +1,387 @@
+// Package money provides an exact decimal type for prices, exchange rates and payment
+// amounts, and currency-tagged amounts built on it.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Places is the number of fractional digits a Decimal keeps. Six digits hold exchange
+// rates and provider prices exactly; amounts are rounded to their currency when settled.
+const Places = 6
+
+const scale = 1_000_000 // 10^Places
+
+var bigScale = big.NewInt(scale)
+
+// Decimal is an exact decimal number with Places fractional digits. The zero value is 0.
+// Arithmetic never goes through float64; results with more digits than Places are
+// rounded half away from zero. Arithmetic that overflows the range panics, so values
+// from outside must be range-checked on the way in by Parse or FromFloat.
+type Decimal struct {
+	units int64 // value * 10^Places
+}
+
+// Zero is the zero Decimal
+var Zero = Decimal{}
+
+// maxFloatUnits is the largest power of two below math.MaxInt64; float64 units at or beyond
+// it do not convert to int64
+const maxFloatUnits = 1 << 63
+
+// FromInt returns n as a Decimal
+func FromInt(n int64) Decimal {
+	if !intInRange(n) {
+		panic(errOverflow)
+	}
+	return Decimal{units: n * scale}
+}
+
+// intInRange reports whether FromInt(n) fits the Decimal range
+func intInRange(n int64) bool {
+	return n <= math.MaxInt64/scale && n >= math.MinInt64/scale
+}
+
+// FromFloat converts f, rounded to Places digits. Use it only at boundaries where a value
+// arrives as a float, such as decoded provider responses; NaN, infinities and values
+// outside the Decimal range are rejected.
+func FromFloat(f float64) (Decimal, error) {
+	units := math.Round(f * scale)
+	if math.IsNaN(units) || units >= maxFloatUnits || units < -maxFloatUnits {
+		return Zero, fmt.Errorf("money: %g is out of range", f)
+	}
+	return Decimal{units: int64(units)}, nil
+}
+
+// Limits on Parse input. Parse reads request bodies, and big.Rat expands an exponent in
+// full, so "1e1000000" would cost a million-digit number before the range check.
+const (
+	maxDecimalLen = 64
+	maxExponent   = 30
+)
+
+// Parse reads a decimal string such as "71243.57", "-0.5" or "1e-3". Digits beyond Places
+// are rounded half away from zero.
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > maxDecimalLen {
+		return Zero, fmt.Errorf("money: decimal longer than %d characters", maxDecimalLen)
+	}
+	mantissa := s
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return Zero, fmt.Errorf("money: invalid decimal %q", s)
+		}
+		mantissa = s[:i]
+	}
+	// Only plain decimals: no fractions, hex or binary exponents
+	if strings.Trim(mantissa, "+-.0123456789") != "" {
+		return Zero, fmt.Errorf("money: invalid decimal %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Zero, fmt.Errorf("money: invalid decimal %q", s)
+	}
+	return fromRat(r)
+}
+
+// MustParse is like Parse but panics on invalid input; for constants and tests
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func fromRat(r *big.Rat) (Decimal, error) {
+	num := new(big.Int).Mul(r.Num(), bigScale)
+	units := divRound(num, r.Denom())
+	if !units.IsInt64() {
+		return Zero, errors.New("money: decimal is out of range")
+	}
+	return Decimal{units: units.Int64()}, nil
+}
+
+// divRound divides a by b (b > 0) rounding half away from zero
+func divRound(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(b) >= 0 {
+		if a.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+var errOverflow = errors.New("money: decimal overflow")
+
+func mustInt64(v *big.Int) int64 {
+	if !v.IsInt64() {
+		panic(errOverflow)
+	}
+	return v.Int64()
+}
+
+// Add returns d + e
+func (d Decimal) Add(e Decimal) Decimal {
+	sum := d.units + e.units
+	if (e.units > 0 && sum < d.units) || (e.units < 0 && sum > d.units) {
+		panic(errOverflow)
+	}
+	return Decimal{units: sum}
+}
+
+// Sub returns d - e
+func (d Decimal) Sub(e Decimal) Decimal {
+	diff := d.units - e.units
+	if (e.units > 0 && diff > d.units) || (e.units < 0 && diff < d.units) {
+		panic(errOverflow)
+	}
+	return Decimal{units: diff}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	if d.units == math.MinInt64 {
+		panic(errOverflow)
+	}
+	return Decimal{units: -d.units}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	if d.units < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Mul returns d * e rounded to Places digits
+func (d Decimal) Mul(e Decimal) Decimal {
+	p := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(e.units))
+	return Decimal{units: mustInt64(divRound(p, bigScale))}
+}
+
+// Div returns d / e rounded to Places digits. It panics if e is zero.
+func (d Decimal) Div(e Decimal) Decimal {
+	if e.units == 0 {
+		panic("money: division by zero")
+	}
+	n := new(big.Int).Mul(big.NewInt(d.units), bigScale)
+	m := big.NewInt(e.units)
+	if e.units < 0 {
+		n.Neg(n)
+		m.Neg(m)
+	}
+	return Decimal{units: mustInt64(divRound(n, m))}
+}
+
+// Mod returns the remainder of d / e, with the sign of d. It panics if e is zero.
+func (d Decimal) Mod(e Decimal) Decimal {
+	if e.units == 0 {
+		panic("money: division by zero")
+	}
+	return Decimal{units: d.units % e.units}
+}
+
+// AddPercent returns d increased by pct percent, e.g. 5 with 20 is 6. Callers validate
+// pct; a percentage outside the Decimal range panics like any other overflow.
+func (d Decimal) AddPercent(pct float64) Decimal {
+	factor, err := FromFloat(100 + pct)
+	if err != nil {
+		panic(err)
+	}
+	return d.Mul(factor).Div(FromInt(100))
+}
+
+// PercentChange returns how far e is above d in percent, rounded to two decimals; d must
+// not be zero
+func (d Decimal) PercentChange(e Decimal) float64 {
+	return e.Sub(d).Mul(FromInt(100)).Div(d).Round(2).Float64()
+}
+
+// Round rounds to places fractional digits (0..Places), half away from zero
+func (d Decimal) Round(places int32) Decimal {
+	step := placesStep(places)
+	q, r := d.units/step, d.units%step
+	if 2*abs(r) >= step {
+		if d.units < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Decimal{units: q * step}
+}
+
+// Ceil rounds up, towards positive infinity, to places fractional digits
+func (d Decimal) Ceil(places int32) Decimal {
+	step := placesStep(places)
+	q, r := d.units/step, d.units%step
+	if r > 0 {
+		q++
+	}
+	return Decimal{units: q * step}
+}
+
+// Floor rounds down, towards negative infinity, to places fractional digits
+func (d Decimal) Floor(places int32) Decimal {
+	step := placesStep(places)
+	q, r := d.units/step, d.units%step
+	if r < 0 {
+		q--
+	}
+	return Decimal{units: q * step}
+}
+
+func placesStep(places int32) int64 {
+	if places < 0 || places > Places {
+		panic(fmt.Sprintf("money: cannot round to %d places", places))
+	}
+	step := int64(scale)
+	for i := int32(0); i < places; i++ {
+		step /= 10
+	}
+	return step
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Cmp compares d and e and returns -1, 0 or +1
+func (d Decimal) Cmp(e Decimal) int {
+	switch {
+	case d.units < e.units:
+		return -1
+	case d.units > e.units:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether d == e
+func (d Decimal) Equal(e Decimal) bool { return d.units == e.units }
+
+// LessThan reports whether d < e
+func (d Decimal) LessThan(e Decimal) bool { return d.units < e.units }
+
+// GreaterThan reports whether d > e
+func (d Decimal) GreaterThan(e Decimal) bool { return d.units > e.units }
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int { return d.Cmp(Zero) }
+
+// IntPart returns the integer part of d, truncated towards zero
+func (d Decimal) IntPart() int64 { return d.units / scale }
+
+// Float64 returns the nearest float64, for display and for APIs that take floats
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d without trailing fractional zeros, e.g. "71900" or "5.5"
+func (d Decimal) String() string {
+	s := d.StringFixed(Places)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed formats d rounded to places fractional digits, e.g. "5.50"
+func (d Decimal) StringFixed(places int32) string {
+	r := d.Round(places)
+	sign := ""
+	if r.units < 0 {
+		sign = "-"
+	}
+	u := abs(r.units)
+	s := sign + strconv.FormatInt(u/scale, 10)
+	if places > 0 {
+		frac := fmt.Sprintf("%06d", u%scale)
+		s += "." + frac[:places]
+	}
+	return s
+}
+
+// MarshalJSON encodes d as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string; null leaves d unchanged
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	v, err := Parse(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value stores d as a decimal string so numeric columns receive it exactly
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan reads numeric, float and integer columns
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		if !intInRange(v) {
+			return fmt.Errorf("money: %d is out of range", v)
+		}
+		*d = FromInt(v)
+	case float64:
+		f, err := FromFloat(v)
+		if err != nil {
+			return err
+		}
+		*d = f
+	default:
+		return fmt.Errorf("money: cannot scan %T into Decimal", src)
+	}
+	return nil
+}
+
+func (d *Decimal) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// GormDataType makes GORM create Decimal columns as numeric
+func (Decimal) GormDataType() string {
+	return "numeric(18,6)"
+}
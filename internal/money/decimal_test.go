@@ -0,0 +1,141 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalParseAndString(t *testing.T) {
+	for in, want := range map[string]string{
+		"71243.57":   "71243.57",
+		"-0.50":      "-0.5",
+		"3450.12345": "3450.12345",
+		"1e-3":       "0.001",
+		"0.0000005":  "0.000001", // rounded to Places
+		"15000":      "15000",
+	} {
+		d, err := Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, d.String(), in)
+	}
+	for _, in := range []string{"", "abc", "1/3", "1.2.3", "0x10", "0x1p3", "1e", "1e31", "1e-31", "1e99999999999999999999"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+	assert.Equal(t, "5.50", MustParse("5.5").StringFixed(2))
+	assert.Equal(t, "-3", MustParse("-2.5").StringFixed(0))
+}
+
+func TestDecimalArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 is not 0.3 in float64
+	assert.Equal(t, MustParse("0.3"), MustParse("0.1").Add(MustParse("0.2")))
+
+	// 20.65 USD at 3450.5 MNT/USD
+	assert.Equal(t, MustParse("71252.825"), MustParse("20.65").Mul(MustParse("3450.5")))
+	assert.Equal(t, MustParse("0.333333"), FromInt(1).Div(FromInt(3)))
+	assert.Equal(t, MustParse("0.666667"), FromInt(2).Div(FromInt(3)))
+	assert.Equal(t, MustParse("-0.666667"), FromInt(2).Div(FromInt(-3)))
+	assert.Equal(t, MustParse("3.8295"), MustParse("3.33").AddPercent(15))
+	assert.Equal(t, 20.0, FromInt(5).PercentChange(FromInt(6)))
+	assert.Equal(t, MustParse("43.57"), MustParse("71243.57").Mod(FromInt(100)))
+
+	// Large MNT amounts times rates do not overflow the intermediate product
+	assert.Equal(t, MustParse("245784900000"), FromInt(71242000).Mul(MustParse("3450")))
+	assert.Panics(t, func() { FromInt(1).Div(Zero) })
+}
+
+func TestDecimalRange(t *testing.T) {
+	d, err := FromFloat(4.5)
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("4.5"), d)
+	for _, f := range []float64{1e13, -1e13, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := FromFloat(f)
+		assert.Error(t, err, f)
+	}
+	_, err = Parse("1e13")
+	assert.Error(t, err)
+	_, err = Parse("1e30")
+	assert.EqualError(t, err, "money: decimal is out of range")
+	_, err = Parse("1" + strings.Repeat("0", 64))
+	assert.EqualError(t, err, "money: decimal longer than 64 characters")
+	var parsed Decimal
+	assert.Error(t, json.Unmarshal([]byte("1e1000000"), &parsed))
+	assert.Error(t, parsed.Scan(int64(math.MaxInt64)))
+
+	// Every operation panics on overflow instead of wrapping around
+	largest := Decimal{units: math.MaxInt64}
+	smallest := Decimal{units: math.MinInt64}
+	assert.Panics(t, func() { FromInt(math.MaxInt64 / 100) })
+	assert.Panics(t, func() { largest.Add(MustParse("0.000001")) })
+	assert.Panics(t, func() { smallest.Sub(MustParse("0.000001")) })
+	assert.Panics(t, func() { smallest.Neg() })
+	assert.Panics(t, func() { largest.Mul(FromInt(2)) })
+	assert.Panics(t, func() { largest.Div(MustParse("0.5")) })
+	assert.Equal(t, largest, largest.Sub(Zero).Add(Zero))
+}
+
+func TestDecimalRounding(t *testing.T) {
+	assert.Equal(t, FromInt(71244), MustParse("71243.5").Round(0))
+	assert.Equal(t, FromInt(71243), MustParse("71243.49").Round(0))
+	assert.Equal(t, FromInt(-3), MustParse("-2.5").Round(0))
+	assert.Equal(t, MustParse("3.83"), MustParse("3.8201").Ceil(2))
+	assert.Equal(t, MustParse("-3.82"), MustParse("-3.8299").Ceil(2))
+	assert.Equal(t, MustParse("3.82"), MustParse("3.8299").Floor(2))
+	assert.Equal(t, MustParse("-3.83"), MustParse("-3.8201").Floor(2))
+	assert.Equal(t, MustParse("3.83"), MustParse("3.83").Ceil(2))
+}
+
+func TestDecimalJSONAndSQL(t *testing.T) {
+	var v struct {
+		Amount Decimal  `json:"amount"`
+		Rate   *Decimal `json:"rate"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 71900, "rate": "3450.25"}`), &v))
+	assert.Equal(t, FromInt(71900), v.Amount)
+	assert.Equal(t, MustParse("3450.25"), *v.Rate)
+
+	out, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 71900, "rate": 3450.25}`, string(out))
+
+	stored, err := MustParse("71243.57").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "71243.57", stored)
+
+	var d Decimal
+	require.NoError(t, d.Scan([]byte("71243.570000")))
+	assert.Equal(t, MustParse("71243.57"), d)
+	require.NoError(t, d.Scan(float64(4.5)))
+	assert.Equal(t, MustParse("4.5"), d)
+	require.NoError(t, d.Scan(int64(-15000)))
+	assert.Equal(t, FromInt(-15000), d)
+	assert.Error(t, d.Scan(true))
+}
+
+func TestMoney(t *testing.T) {
+	usd := New(MustParse("20.65"), USD)
+	mnt := usd.Convert(MustParse("3450.5"), MNT)
+	assert.Equal(t, MNT, mnt.Currency)
+	assert.Equal(t, "71253 MNT", mnt.Settled().String())
+	assert.Equal(t, "20.65 USD", usd.Settled().String())
+	assert.Equal(t, "1.01 USD", New(MustParse("1.005"), USD).Settled().String())
+
+	assert.Equal(t, New(FromInt(30000), MNT), New(FromInt(45000), MNT).Sub(New(FromInt(15000), MNT)))
+	assert.Panics(t, func() { usd.Add(mnt) })
+}
+
+func TestParseCurrency(t *testing.T) {
+	c, err := ParseCurrency(" krw")
+	require.NoError(t, err)
+	assert.Equal(t, KRW, c)
+	_, err = ParseCurrency("EUR")
+	assert.Error(t, err)
+
+	assert.Equal(t, "9800 KRW", New(MustParse("9799.6"), KRW).Settled().String())
+	assert.Equal(t, "52.34 CNY", New(MustParse("52.335"), CNY).Settled().String())
+}
@@ -0,0 +1,86 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	USD Currency = "USD"
+	MNT Currency = "MNT"
+	CNY Currency = "CNY"
+	KRW Currency = "KRW"
+)
+
+// Currencies lists the currencies prices can be shown in
+var Currencies = []Currency{MNT, USD, CNY, KRW}
+
+// ParseCurrency reads a supported currency code, case-insensitively
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	for _, known := range Currencies {
+		if c == known {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("money: unsupported currency %q", s)
+}
+
+// SettlementPlaces is the number of fractional digits amounts in c are charged and paid
+// in. Tugrik is settled in whole units since QPay accepts no decimals; won has no minor
+// unit in use.
+func (c Currency) SettlementPlaces() int32 {
+	switch c {
+	case MNT, KRW:
+		return 0
+	}
+	return 2
+}
+
+// Money is an amount in a currency
+type Money struct {
+	Amount   Decimal  `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// New returns amount in currency c, unrounded
+func New(amount Decimal, c Currency) Money {
+	return Money{Amount: amount, Currency: c}
+}
+
+// Settled rounds m half away from zero to the currency's settlement places; every amount
+// charged, refunded or reported must be settled
+func (m Money) Settled() Money {
+	return Money{Amount: m.Amount.Round(m.Currency.SettlementPlaces()), Currency: m.Currency}
+}
+
+// Convert converts m at rate (units of to per unit of m's currency), unrounded
+func (m Money) Convert(rate Decimal, to Currency) Money {
+	return Money{Amount: m.Amount.Mul(rate), Currency: to}
+}
+
+// Add returns m + o. It panics if the currencies differ.
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: m.Currency}
+}
+
+// Sub returns m - o. It panics if the currencies differ.
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: m.Currency}
+}
+
+func (m Money) mustMatch(o Money) {
+	if m.Currency != o.Currency {
+		panic(fmt.Sprintf("money: %s and %s amounts cannot be combined", m.Currency, o.Currency))
+	}
+}
+
+// String formats m as e.g. "71900 MNT"
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
@@ -0,0 +1,43 @@
+package qpaysim
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var adminPage = template.Must(template.New("admin").Parse(`<!DOCTYPE html>
+<html>
+<head><title>QPay simulator</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+form { display: inline; }
+</style>
+</head>
+<body>
+<h1>QPay simulator</h1>
+<p>API base: <code>/v2</code>. Paying an invoice delivers a signed callback to its callback URL.</p>
+<table>
+<tr><th>Invoice</th><th>Order</th><th>Amount</th><th>Refunded</th><th>Status</th><th>Transaction</th><th>Last callback</th><th></th></tr>
+{{range .}}
+<tr id="{{.InvoiceID}}">
+<td>{{.InvoiceID}}</td><td>{{.SenderInvoiceNo}}</td><td>{{printf "%.0f" .Amount}}</td><td>{{printf "%.0f" .RefundedAmount}}</td>
+<td>{{.Status}}</td><td>{{.TransactionID}}</td><td>{{.LastCallback}}</td>
+<td>{{if eq .Status "PENDING"}}
+<form method="post" action="/admin/invoices/{{.InvoiceID}}/pay?redirect=1"><button>Mark paid</button></form>
+<form method="post" action="/admin/invoices/{{.InvoiceID}}/cancel?redirect=1"><button>Cancel</button></form>
+{{end}}</td>
+</tr>
+{{else}}
+<tr><td colspan="8">No invoices yet</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	adminPage.Execute(w, s.Invoices())
+}
@@ -0,0 +1,458 @@
+// Package qpaysim simulates the subset of the QPay merchant v2 API used by QPayService so the
+// payment flow can run offline. It can be embedded in tests via httptest or run standalone
+// with cmd/qpaysim.
+package qpaysim
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Invoice states
+const (
+	StatusPending   = "PENDING"
+	StatusPaid      = "PAID"
+	StatusCancelled = "CANCELLED"
+	StatusRefunded  = "REFUNDED"
+)
+
+// Options configures a simulator
+type Options struct {
+	// Username and Password are the merchant credentials accepted by /auth/token. Password is
+	// also the webhook signing secret, matching QPAY_PASSWORD on the server side.
+	Username string
+	Password string
+	// CallbackURL is used when an invoice was created without a callback_url
+	CallbackURL string
+	// TokenTTL is the lifetime of issued access tokens (default one hour)
+	TokenTTL time.Duration
+	// HTTPClient delivers callbacks (default 10s timeout)
+	HTTPClient *http.Client
+}
+
+// Invoice is a simulated QPay invoice
+type Invoice struct {
+	InvoiceID       string    `json:"invoice_id"`
+	InvoiceCode     string    `json:"invoice_code"`
+	SenderInvoiceNo string    `json:"sender_invoice_no"`
+	Receiver        string    `json:"invoice_receiver"`
+	Description     string    `json:"invoice_description"`
+	Amount          float64   `json:"amount"`
+	PaidAmount      float64   `json:"paid_amount"`
+	RefundedAmount  float64   `json:"refunded_amount,omitempty"`
+	CallbackURL     string    `json:"callback_url"`
+	Status          string    `json:"payment_status"`
+	TransactionID   string    `json:"transaction_id,omitempty"`
+	PaymentDate     string    `json:"payment_date,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	// LastCallback describes the most recent callback delivery
+	LastCallback string `json:"last_callback,omitempty"`
+}
+
+// Server is an http.Handler implementing the simulated QPay API plus an admin UI
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu            sync.Mutex
+	invoices      map[string]*Invoice
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]bool
+	seq           int
+}
+
+// NewServer creates a simulator. Mount it at the root; the QPay API lives under /v2 so
+// QPAY_ENDPOINT should be "<simulator URL>/v2".
+func NewServer(opts Options) *Server {
+	if opts.TokenTTL == 0 {
+		opts.TokenTTL = time.Hour
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &Server{
+		opts:          opts,
+		mux:           http.NewServeMux(),
+		invoices:      make(map[string]*Invoice),
+		accessTokens:  make(map[string]time.Time),
+		refreshTokens: make(map[string]bool),
+	}
+
+	s.mux.HandleFunc("POST /v2/auth/token", s.handleToken)
+	s.mux.HandleFunc("POST /v2/auth/refresh", s.handleRefresh)
+	s.mux.HandleFunc("POST /v2/invoice", s.authorized(s.handleCreateInvoice))
+	s.mux.HandleFunc("DELETE /v2/invoice/{id}", s.authorized(s.handleCancelInvoice))
+	s.mux.HandleFunc("POST /v2/payment/check", s.authorized(s.handleCheckPayment))
+	s.mux.HandleFunc("DELETE /v2/payment/refund/{id}", s.authorized(s.handleRefund))
+
+	s.mux.HandleFunc("GET /{$}", s.handleAdminPage)
+	s.mux.HandleFunc("GET /admin/invoices", s.handleListInvoices)
+	s.mux.HandleFunc("POST /admin/invoices/{id}/pay", s.handleMarkPaid)
+	s.mux.HandleFunc("POST /admin/invoices/{id}/cancel", s.handleAdminCancel)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Invoices returns a snapshot of all invoices, oldest first
+func (s *Server) Invoices() []Invoice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Invoice, 0, len(s.invoices))
+	for _, inv := range s.invoices {
+		out = append(out, *inv)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// FindInvoice returns the invoice created for an order number
+func (s *Server) FindInvoice(senderInvoiceNo string) (*Invoice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found *Invoice
+	for _, inv := range s.invoices {
+		if inv.SenderInvoiceNo == senderInvoiceNo && (found == nil || inv.CreatedAt.After(found.CreatedAt)) {
+			found = inv
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	inv := *found
+	return &inv, true
+}
+
+// MarkPaid pays an invoice (amount <= 0 pays it in full) and delivers the callback
+func (s *Server) MarkPaid(invoiceID string, amount float64) (*Invoice, error) {
+	s.mu.Lock()
+	inv, ok := s.invoices[invoiceID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("invoice %s not found", invoiceID)
+	}
+	if inv.Status != StatusPending {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("invoice %s is %s", invoiceID, inv.Status)
+	}
+	if amount <= 0 {
+		amount = inv.Amount
+	}
+	s.seq++
+	inv.Status = StatusPaid
+	inv.PaidAmount = amount
+	inv.TransactionID = fmt.Sprintf("SIMTX%06d", s.seq)
+	inv.PaymentDate = time.Now().Format(time.RFC3339)
+	snapshot := *inv
+	s.mu.Unlock()
+
+	result := s.sendCallback(&snapshot)
+
+	s.mu.Lock()
+	inv.LastCallback = result
+	snapshot.LastCallback = result
+	s.mu.Unlock()
+	return &snapshot, nil
+}
+
+// CancelInvoice cancels a pending invoice without a callback, as if it lapsed at QPay
+func (s *Server) CancelInvoice(invoiceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceID]
+	if !ok {
+		return fmt.Errorf("invoice %s not found", invoiceID)
+	}
+	if inv.Status != StatusPending {
+		return fmt.Errorf("invoice %s is %s", invoiceID, inv.Status)
+	}
+	inv.Status = StatusCancelled
+	return nil
+}
+
+// Sign computes the X-QPay-Signature header value the server verifies for a callback
+func Sign(invoiceID string, amount float64, paymentStatus, secret string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s%.2f%s%s", invoiceID, amount, paymentStatus, secret))))
+}
+
+// sendCallback posts the payment notification and reports the outcome
+func (s *Server) sendCallback(inv *Invoice) string {
+	url := inv.CallbackURL
+	if url == "" {
+		url = s.opts.CallbackURL
+	}
+	if url == "" {
+		return "no callback URL configured"
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"invoice_id":        inv.InvoiceID,
+		"sender_invoice_no": inv.SenderInvoiceNo,
+		"transaction_id":    inv.TransactionID,
+		"payment_status":    inv.Status,
+		"amount":            inv.Amount,
+		"paid_amount":       inv.PaidAmount,
+		"payment_date":      inv.PaymentDate,
+	})
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Sprintf("callback failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-QPay-Signature", Sign(inv.InvoiceID, inv.Amount, inv.Status, s.opts.Password))
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		log.Printf("qpaysim: callback for %s failed: %v", inv.InvoiceID, err)
+		return fmt.Sprintf("callback failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	log.Printf("qpaysim: callback for %s -> %d %s", inv.InvoiceID, resp.StatusCode, respBody)
+	return fmt.Sprintf("%d %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+}
+
+// --- QPay API ---
+
+func (s *Server) newToken(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d-%d", prefix, s.seq, time.Now().UnixNano())
+}
+
+func (s *Server) issueTokens(w http.ResponseWriter) {
+	s.mu.Lock()
+	access := s.newToken("sim-access")
+	refresh := s.newToken("sim-refresh")
+	expires := time.Now().Add(s.opts.TokenTTL)
+	s.accessTokens[access] = expires
+	s.refreshTokens[refresh] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_type":         "bearer",
+		"access_token":       access,
+		"expires_in":         expires.Unix(),
+		"refresh_token":      refresh,
+		"refresh_expires_in": time.Now().Add(24 * time.Hour).Unix(),
+		"scope":              "qpaysim",
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != s.opts.Username || pass != s.opts.Password {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "AUTHENTICATION_FAILED"})
+		return
+	}
+	s.issueTokens(w)
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	valid := s.refreshTokens[token]
+	s.mu.Unlock()
+	if !valid {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "NO_CREDENTIALS"})
+		return
+	}
+	s.issueTokens(w)
+}
+
+// authorized rejects requests without a live access token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		expires, ok := s.accessTokens[token]
+		s.mu.Unlock()
+		if !ok || time.Now().After(expires) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "NO_CREDENTIALS"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		InvoiceCode        string  `json:"invoice_code"`
+		SenderInvoiceNo    string  `json:"sender_invoice_no"`
+		InvoiceReceiver    string  `json:"invoice_receiver"`
+		InvoiceDescription string  `json:"invoice_description"`
+		Amount             float64 `json:"amount"`
+		CallbackURL        string  `json:"callback_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SenderInvoiceNo == "" || req.Amount <= 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 400, "message": "invalid invoice request"})
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	inv := &Invoice{
+		InvoiceID:       fmt.Sprintf("sim-inv-%06d", s.seq),
+		InvoiceCode:     req.InvoiceCode,
+		SenderInvoiceNo: req.SenderInvoiceNo,
+		Receiver:        req.InvoiceReceiver,
+		Description:     req.InvoiceDescription,
+		Amount:          req.Amount,
+		CallbackURL:     req.CallbackURL,
+		Status:          StatusPending,
+		CreatedAt:       time.Now(),
+	}
+	s.invoices[inv.InvoiceID] = inv
+	s.mu.Unlock()
+
+	payURL := fmt.Sprintf("http://%s/#%s", r.Host, inv.InvoiceID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"code":    0,
+		"message": "success",
+		"data": map[string]interface{}{
+			"invoice_id": inv.InvoiceID,
+			"qr_code":    "qpaysim:" + inv.InvoiceID,
+			"urls":       map[string]string{"web": payURL, "app": payURL},
+		},
+	})
+}
+
+func (s *Server) handleCheckPayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		InvoiceID     string `json:"invoice_id"`
+		CheckPassword string `json:"check_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 400, "message": "invalid request"})
+		return
+	}
+	if req.CheckPassword != fmt.Sprintf("%x", md5.Sum([]byte(s.opts.Password))) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 401, "message": "invalid check_password"})
+		return
+	}
+
+	s.mu.Lock()
+	inv, ok := s.invoices[req.InvoiceID]
+	var snapshot Invoice
+	if ok {
+		snapshot = *inv
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 404, "message": "invoice not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"code":    0,
+		"message": "success",
+		"data": map[string]interface{}{
+			"invoice_id":        snapshot.InvoiceID,
+			"sender_invoice_no": snapshot.SenderInvoiceNo,
+			"transaction_id":    snapshot.TransactionID,
+			"payment_status":    snapshot.Status,
+			"amount":            snapshot.Amount,
+			"paid_amount":       snapshot.PaidAmount,
+			"payment_date":      snapshot.PaymentDate,
+		},
+	})
+}
+
+func (s *Server) handleCancelInvoice(w http.ResponseWriter, r *http.Request) {
+	if err := s.CancelInvoice(r.PathValue("id")); err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 400, "message": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "message": "success"})
+}
+
+// handleRefund refunds part or all of a payment. Refunds add up per payment and may not
+// exceed the paid amount; the invoice becomes REFUNDED once nothing is left.
+func (s *Server) handleRefund(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Amount *float64 `json:"amount"` // omitted for a full refund of what is left
+	}
+	if body, _ := io.ReadAll(r.Body); len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"code": 400, "message": "invalid refund request"})
+			return
+		}
+	}
+
+	paymentID := r.PathValue("id")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, inv := range s.invoices {
+		if inv.TransactionID != paymentID || inv.Status != StatusPaid {
+			continue
+		}
+		remaining := inv.PaidAmount - inv.RefundedAmount
+		amount := remaining
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+		if amount <= 0 || amount > remaining {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"code": 400, "message": fmt.Sprintf("refund of %.2f exceeds the %.2f left of the payment", amount, remaining)})
+			return
+		}
+		inv.RefundedAmount += amount
+		if inv.RefundedAmount >= inv.PaidAmount {
+			inv.Status = StatusRefunded
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "message": "success"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 404, "message": "payment not found"})
+}
+
+// --- Admin ---
+
+func (s *Server) handleListInvoices(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Invoices())
+}
+
+func (s *Server) handleMarkPaid(w http.ResponseWriter, r *http.Request) {
+	var amount float64
+	if v := r.URL.Query().Get("amount"); v != "" {
+		amount, _ = strconv.ParseFloat(v, 64)
+	}
+	inv, err := s.MarkPaid(r.PathValue("id"), amount)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.URL.Query().Get("redirect") != "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func (s *Server) handleAdminCancel(w http.ResponseWriter, r *http.Request) {
+	if err := s.CancelInvoice(r.PathValue("id")); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.URL.Query().Get("redirect") != "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
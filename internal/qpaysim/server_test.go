@@ -0,0 +1,90 @@
+package qpaysim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/money"
+	"esim-platform/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQPayServiceAgainstSimulator(t *testing.T) {
+	qpayCfg := config.QPayConfig{Username: "merchant", Password: "secret", InvoiceCode: "TEST"}
+	qpay := services.NewQPayService(qpayCfg)
+
+	// Stand-in for the webhook handler: verify the signature like the real one does
+	callbacks := make(chan map[string]interface{}, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		assert.True(t, qpay.VerifyWebhookSignature(data, r.Header.Get("X-QPay-Signature")))
+		callbacks <- data
+	}))
+	defer webhook.Close()
+
+	sim := NewServer(Options{Username: "merchant", Password: "secret", CallbackURL: webhook.URL})
+	simServer := httptest.NewServer(sim)
+	defer simServer.Close()
+
+	qpayCfg.Endpoint = simServer.URL + "/v2"
+	qpay = services.NewQPayService(qpayCfg)
+
+	invoice, err := qpay.CreateInvoice(context.Background(), "ESIM1", "test eSIM", "a@example.com", money.FromInt(15000))
+	require.NoError(t, err)
+	invoiceID := invoice.Data.InvoiceID
+
+	check, err := qpay.CheckPayment(context.Background(), invoiceID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, check.Data.PaymentStatus)
+
+	paid, err := sim.MarkPaid(invoiceID, 0)
+	require.NoError(t, err)
+	data := <-callbacks
+	assert.Equal(t, "ESIM1", data["sender_invoice_no"])
+	assert.Equal(t, StatusPaid, data["payment_status"])
+
+	check, err = qpay.CheckPayment(context.Background(), invoiceID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPaid, check.Data.PaymentStatus)
+	assert.Equal(t, money.FromInt(15000), check.Data.PaidAmount)
+
+	// Partial refunds add up and may not exceed the paid amount
+	require.NoError(t, qpay.RefundPayment(context.Background(), paid.TransactionID, money.FromInt(5000), "partial refund"))
+	assert.Equal(t, StatusPaid, sim.Invoices()[0].Status)
+	assert.Error(t, qpay.RefundPayment(context.Background(), paid.TransactionID, money.FromInt(10001), "too much"))
+	require.NoError(t, qpay.RefundPayment(context.Background(), paid.TransactionID, money.Zero, "test refund"))
+	assert.Equal(t, StatusRefunded, sim.Invoices()[0].Status)
+	assert.Equal(t, float64(15000), sim.Invoices()[0].RefundedAmount)
+	assert.Error(t, qpay.RefundPayment(context.Background(), paid.TransactionID, money.FromInt(1), "already refunded"))
+	assert.Error(t, qpay.CancelInvoice(context.Background(), invoiceID))
+}
+
+func TestSimulatorCancelInvoice(t *testing.T) {
+	sim := NewServer(Options{Username: "merchant", Password: "secret"})
+	simServer := httptest.NewServer(sim)
+	defer simServer.Close()
+
+	qpay := services.NewQPayService(config.QPayConfig{Endpoint: simServer.URL + "/v2", Username: "merchant", Password: "secret"})
+	invoice, err := qpay.CreateInvoice(context.Background(), "ESIM2", "test eSIM", "", money.FromInt(5000))
+	require.NoError(t, err)
+
+	require.NoError(t, qpay.CancelInvoice(context.Background(), invoice.Data.InvoiceID))
+	_, err = sim.MarkPaid(invoice.Data.InvoiceID, 0)
+	assert.Error(t, err)
+}
+
+func TestSimulatorRejectsBadCredentials(t *testing.T) {
+	simServer := httptest.NewServer(NewServer(Options{Username: "merchant", Password: "secret"}))
+	defer simServer.Close()
+
+	qpay := services.NewQPayService(config.QPayConfig{Endpoint: simServer.URL + "/v2", Username: "merchant", Password: "wrong"})
+	_, err := qpay.CreateInvoice(context.Background(), "ESIM3", "test eSIM", "", money.FromInt(5000))
+	assert.Error(t, err)
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"esim-platform/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Catalog cache kinds, used for TTLs and metrics
+const (
+	CatalogCacheSKUs       = "skus"
+	CatalogCacheContinents = "continents"
+	CatalogCachePackages   = "packages"
+)
+
+const (
+	catalogCachePrefix      = "catalog:"
+	catalogCacheRefreshLock = 30 * time.Second
+)
+
+var errCacheMiss = errors.New("cache miss")
+
+// CatalogStore is the storage behind CatalogCache
+type CatalogStore interface {
+	Get(ctx context.Context, key string) ([]byte, error) // errCacheMiss when absent
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	DelPrefix(ctx context.Context, prefix string) error
+}
+
+// redisCatalogStore keeps catalog entries in Redis so every server instance shares them
+type redisCatalogStore struct {
+	client *redis.Client
+}
+
+func NewRedisCatalogStore(client *redis.Client) CatalogStore {
+	return &redisCatalogStore{client: client}
+}
+
+func (s *redisCatalogStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errCacheMiss
+	}
+	return b, err
+}
+
+func (s *redisCatalogStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisCatalogStore) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, 1, ttl).Result()
+}
+
+func (s *redisCatalogStore) Del(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func (s *redisCatalogStore) DelPrefix(ctx context.Context, prefix string) error {
+	iter := s.client.Scan(ctx, 0, prefix+"*", 200).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// catalogEntry is the stored form of a cached value
+type catalogEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CatalogCacheStats counts cache outcomes of one kind in this process
+type CatalogCacheStats struct {
+	Hits      int64 `json:"hits"`
+	StaleHits int64 `json:"stale_hits"`
+	Misses    int64 `json:"misses"`
+	Refreshes int64 `json:"refreshes"`
+	Errors    int64 `json:"errors"`
+}
+
+type catalogCacheCounters struct {
+	hits, staleHits, misses, refreshes, errors atomic.Int64
+}
+
+// CatalogCache caches provider catalog responses. An entry is fresh for its kind's TTL;
+// after that it is still served for StaleTTL while one instance refreshes it in the
+// background. Store errors fall back to loading from the provider.
+type CatalogCache struct {
+	store  CatalogStore
+	config config.CatalogCacheConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*catalogCacheCounters
+}
+
+func NewCatalogCache(store CatalogStore, cfg config.CatalogCacheConfig) *CatalogCache {
+	c := &CatalogCache{store: store, config: cfg, now: time.Now, counters: make(map[string]*catalogCacheCounters)}
+	for _, kind := range []string{CatalogCacheSKUs, CatalogCacheContinents, CatalogCachePackages} {
+		c.counters[kind] = &catalogCacheCounters{}
+	}
+	return c
+}
+
+func (c *CatalogCache) ttl(kind string) time.Duration {
+	switch kind {
+	case CatalogCacheSKUs:
+		return time.Duration(c.config.SKUTTL) * time.Second
+	case CatalogCacheContinents:
+		return time.Duration(c.config.ContinentTTL) * time.Second
+	default:
+		return time.Duration(c.config.PackagesTTL) * time.Second
+	}
+}
+
+func (c *CatalogCache) counter(kind string) *catalogCacheCounters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctr, ok := c.counters[kind]
+	if !ok {
+		ctr = &catalogCacheCounters{}
+		c.counters[kind] = ctr
+	}
+	return ctr
+}
+
+// Stats returns the hit/miss counters of this process by kind
+func (c *CatalogCache) Stats() map[string]CatalogCacheStats {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]CatalogCacheStats, len(c.counters))
+	for kind, ctr := range c.counters {
+		stats[kind] = CatalogCacheStats{
+			Hits:      ctr.hits.Load(),
+			StaleHits: ctr.staleHits.Load(),
+			Misses:    ctr.misses.Load(),
+			Refreshes: ctr.refreshes.Load(),
+			Errors:    ctr.errors.Load(),
+		}
+	}
+	return stats
+}
+
+// InvalidateSKULists drops the cached SKU lists
+func (c *CatalogCache) InvalidateSKULists(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.Del(ctx, catalogSKUsKey, catalogContinentsKey)
+}
+
+// InvalidatePackages drops the cached packages of the given SKUs, or of every SKU when
+// none are given
+func (c *CatalogCache) InvalidatePackages(ctx context.Context, skuIDs ...string) error {
+	if c == nil {
+		return nil
+	}
+	if len(skuIDs) == 0 {
+		return c.store.DelPrefix(ctx, catalogPackagesPrefix)
+	}
+	keys := make([]string, 0, 2*len(skuIDs))
+	for _, id := range skuIDs {
+		keys = append(keys, catalogPackagesKey(id, "basic"), catalogPackagesKey(id, "detailed"))
+	}
+	return c.store.Del(ctx, keys...)
+}
+
+// InvalidateAll drops every cached catalog entry
+func (c *CatalogCache) InvalidateAll(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.DelPrefix(ctx, catalogCachePrefix)
+}
+
+const (
+	catalogSKUsKey        = catalogCachePrefix + "skus"
+	catalogContinentsKey  = catalogCachePrefix + "continents"
+	catalogPackagesPrefix = catalogCachePrefix + "packages:"
+)
+
+func catalogPackagesKey(skuID, variant string) string {
+	return fmt.Sprintf("%s%s:%s", catalogPackagesPrefix, skuID, variant)
+}
+
+// cachedLoad returns the cached value of key, calling load on a miss. A nil cache
+// always calls load.
+func cachedLoad[T any](ctx context.Context, c *CatalogCache, kind, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	if c == nil {
+		return load(ctx)
+	}
+	ctr := c.counter(kind)
+	ttl := c.ttl(kind)
+
+	raw, err := c.store.Get(ctx, key)
+	switch {
+	case err == nil:
+		var entry catalogEntry
+		var value T
+		if json.Unmarshal(raw, &entry) == nil && json.Unmarshal(entry.Data, &value) == nil {
+			if c.now().Sub(entry.FetchedAt) < ttl {
+				ctr.hits.Add(1)
+				return value, nil
+			}
+			ctr.staleHits.Add(1)
+			refreshCatalogEntry(ctx, c, ctr, key, ttl, load)
+			return value, nil
+		}
+	case !errors.Is(err, errCacheMiss):
+		ctr.errors.Add(1)
+		logrus.WithError(err).WithField("key", key).Warn("Catalog cache read failed")
+	}
+
+	ctr.misses.Add(1)
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	if err := c.put(ctx, key, ttl, value); err != nil {
+		ctr.errors.Add(1)
+		logrus.WithError(err).WithField("key", key).Warn("Catalog cache write failed")
+	}
+	return value, nil
+}
+
+// refreshCatalogEntry reloads a stale entry in the background. A short-lived lock key
+// makes sure only one instance refreshes it at a time.
+func refreshCatalogEntry[T any](ctx context.Context, c *CatalogCache, ctr *catalogCacheCounters, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) {
+	lockKey := key + ":refreshing"
+	if ok, err := c.store.SetNX(ctx, lockKey, catalogCacheRefreshLock); err != nil || !ok {
+		return
+	}
+	go func() {
+		ctx, cancel := workContext(ctx, backgroundOpTimeout)
+		defer cancel()
+		defer c.store.Del(ctx, lockKey)
+
+		value, err := load(ctx)
+		if err != nil {
+			ctr.errors.Add(1)
+			logrus.WithError(err).WithField("key", key).Warn("Catalog cache refresh failed, serving stale entry")
+			return
+		}
+		if err := c.put(ctx, key, ttl, value); err != nil {
+			ctr.errors.Add(1)
+			return
+		}
+		ctr.refreshes.Add(1)
+	}()
+}
+
+func (c *CatalogCache) put(ctx context.Context, key string, ttl time.Duration, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(catalogEntry{FetchedAt: c.now(), Data: data})
+	if err != nil {
+		return err
+	}
+	return c.store.Set(ctx, key, raw, ttl+time.Duration(c.config.StaleTTL)*time.Second)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCatalogStore is an in-process CatalogStore; expiry is left to the cache entries
+type memoryCatalogStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryCatalogStore() *memoryCatalogStore {
+	return &memoryCatalogStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryCatalogStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.data[key]
+	if !ok {
+		return nil, errCacheMiss
+	}
+	return b, nil
+}
+
+func (s *memoryCatalogStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memoryCatalogStore) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok {
+		return false, nil
+	}
+	s.data[key] = []byte("1")
+	return true, nil
+}
+
+func (s *memoryCatalogStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.data, key)
+	}
+	return nil
+}
+
+func (s *memoryCatalogStore) DelPrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.data, key)
+		}
+	}
+	return nil
+}
+
+func (s *memoryCatalogStore) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data[key]
+	return ok
+}
+
+// countingProvider counts SKU list calls that reach the provider
+type countingProvider struct {
+	ESIMProvider
+	skuCalls atomic.Int32
+}
+
+func (p *countingProvider) GetSKUList(ctx context.Context) ([]SKUInfo, error) {
+	p.skuCalls.Add(1)
+	return p.ESIMProvider.GetSKUList(ctx)
+}
+
+func testCatalogCache(store CatalogStore) *CatalogCache {
+	return NewCatalogCache(store, config.CatalogCacheConfig{Enabled: true, SKUTTL: 60, ContinentTTL: 60, PackagesTTL: 30, StaleTTL: 600})
+}
+
+func TestCatalogCacheHitsAndStaleRefresh(t *testing.T) {
+	fake := &countingProvider{ESIMProvider: NewFakeESIMProvider(FakeProviderOptions{})}
+	cache := testCatalogCache(newMemoryCatalogStore())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	cache.now = func() time.Time { mu.Lock(); defer mu.Unlock(); return now }
+	products := NewProductService(nil, fake, cache)
+	ctx := context.Background()
+
+	first, err := products.GetSKUList(ctx)
+	require.NoError(t, err)
+	second, err := products.GetSKUList(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), fake.skuCalls.Load())
+
+	stats := cache.Stats()[CatalogCacheSKUs]
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Hits)
+
+	// Past the TTL the stale list is served while it is refreshed in the background
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	stale, err := products.GetSKUList(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, stale)
+	require.Eventually(t, func() bool { return cache.Stats()[CatalogCacheSKUs].Refreshes == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), fake.skuCalls.Load())
+	assert.Equal(t, int64(1), cache.Stats()[CatalogCacheSKUs].StaleHits)
+
+	_, err = products.GetSKUList(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cache.Stats()[CatalogCacheSKUs].Hits)
+}
+
+func TestCatalogCacheStaleSurvivesProviderFailure(t *testing.T) {
+	fake := NewFakeESIMProvider(FakeProviderOptions{})
+	cache := testCatalogCache(newMemoryCatalogStore())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	cache.now = func() time.Time { mu.Lock(); defer mu.Unlock(); return now }
+	products := NewProductService(nil, fake, cache)
+	ctx := context.Background()
+
+	packages, err := products.GetPackagesBySKU(ctx, "9001")
+	require.NoError(t, err)
+
+	fake.FailAlways(FakeOpGetPackagesBySKU, errors.New("provider down"))
+	mu.Lock()
+	now = now.Add(time.Minute)
+	mu.Unlock()
+	stale, err := products.GetPackagesBySKU(ctx, "9001")
+	require.NoError(t, err)
+	assert.Equal(t, packages, stale)
+	require.Eventually(t, func() bool { return cache.Stats()[CatalogCachePackages].Errors == 1 }, time.Second, 10*time.Millisecond)
+
+	_, err = products.GetPackagesBySKU(ctx, "9002")
+	assert.Error(t, err)
+}
+
+func TestCatalogCacheInvalidation(t *testing.T) {
+	store := newMemoryCatalogStore()
+	cache := testCatalogCache(store)
+	products := NewProductService(nil, NewFakeESIMProvider(FakeProviderOptions{}), cache)
+	ctx := context.Background()
+
+	_, err := products.GetSKUList(ctx)
+	require.NoError(t, err)
+	for _, sku := range []string{"9001", "9002"} {
+		_, err = products.GetPackagesBySKU(ctx, sku)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, products.InvalidateCatalogCache(ctx, "9001"))
+	assert.False(t, store.has(catalogPackagesKey("9001", "basic")))
+	assert.True(t, store.has(catalogPackagesKey("9002", "basic")))
+	assert.True(t, store.has(catalogSKUsKey))
+
+	require.NoError(t, cache.InvalidatePackages(ctx))
+	assert.False(t, store.has(catalogPackagesKey("9002", "basic")))
+	assert.True(t, store.has(catalogSKUsKey))
+
+	require.NoError(t, products.InvalidateCatalogCache(ctx))
+	assert.False(t, store.has(catalogSKUsKey))
+}
+
+func TestCatalogCacheDisabled(t *testing.T) {
+	fake := &countingProvider{ESIMProvider: NewFakeESIMProvider(FakeProviderOptions{})}
+	products := NewProductService(nil, fake, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := products.GetSKUList(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), fake.skuCalls.Load())
+	assert.Empty(t, products.CatalogCacheStats())
+	assert.NoError(t, products.InvalidateCatalogCache(ctx))
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// defaultPriceChangeThreshold applies when CATALOG_SYNC_PRICE_CHANGE_THRESHOLD is not set
+const defaultPriceChangeThreshold = 10.0
+
+// newCatalogChange describes a change to a stored package price. Values are copied.
+func newCatalogChange(pp *models.PackagePrice, changeType string, oldValue, newValue *float64, detail string) models.CatalogChange {
+	change := models.CatalogChange{
+		SKUID:           pp.SKUID,
+		ProviderPriceID: pp.ProviderPriceID,
+		APICode:         pp.APICode,
+		ShowName:        pp.ShowName,
+		Type:            changeType,
+		Detail:          detail,
+	}
+	if oldValue != nil {
+		v := *oldValue
+		change.OldValue = &v
+	}
+	if newValue != nil {
+		v := *newValue
+		change.NewValue = &v
+	}
+	if oldValue != nil && newValue != nil {
+		change.ChangePercent = changePercent(*oldValue, *newValue)
+	}
+	return change
+}
+
+// diffPackagePrice lists what the provider package, priced at price, changes about a stored
+// package price. It must be called before existing is updated.
+func diffPackagePrice(existing *models.PackagePrice, skuID string, pkg RoamWiFiPackage, price money.Decimal) []models.CatalogChange {
+	current := *existing
+	current.SKUID = skuID
+	current.APICode = pkg.APICode
+	current.ShowName = pkg.ShowName
+
+	var changes []models.CatalogChange
+	if !existing.Active && existing.InactiveReason != models.PackageInactiveMargin {
+		changes = append(changes, newCatalogChange(&current, models.CatalogChangeAdded, nil, &pkg.Price, "reactivated"))
+	}
+	if !existing.RawProviderPrice.Equal(price) {
+		oldPrice, newPrice := existing.RawProviderPrice.Float64(), price.Float64()
+		changes = append(changes, newCatalogChange(&current, models.CatalogChangePrice, &oldPrice, &newPrice,
+			fmt.Sprintf("%s USD -> %s USD", existing.RawProviderPrice.StringFixed(2), price.StringFixed(2))))
+	}
+	if existing.Flows != pkg.Flows || existing.Unit != pkg.Unit {
+		detail := fmt.Sprintf("%g %s -> %g %s", existing.Flows, existing.Unit, pkg.Flows, pkg.Unit)
+		change := newCatalogChange(&current, models.CatalogChangeData, &existing.Flows, &pkg.Flows, detail)
+		if existing.Unit != pkg.Unit {
+			// Amounts in different units are not comparable
+			change.ChangePercent = nil
+		}
+		changes = append(changes, change)
+	}
+	if existing.Days != pkg.Days {
+		oldDays, newDays := float64(existing.Days), float64(pkg.Days)
+		changes = append(changes, newCatalogChange(&current, models.CatalogChangeDays, &oldDays, &newDays,
+			fmt.Sprintf("%d days -> %d days", existing.Days, pkg.Days)))
+	}
+	return changes
+}
+
+// changePercent returns the relative change from old to new in percent, rounded to two
+// decimals, or nil when old is zero
+func changePercent(old, new float64) *float64 {
+	if old == 0 {
+		return nil
+	}
+	pct := math.Round((new-old)/old*10000) / 100
+	return &pct
+}
+
+// priceChangeThreshold is the absolute price change, in percent, from which a change is flagged
+func (s *CatalogSyncer) priceChangeThreshold() float64 {
+	if s.config.PriceChangeThreshold <= 0 {
+		return defaultPriceChangeThreshold
+	}
+	return s.config.PriceChangeThreshold
+}
+
+// flagChanges marks price changes at or beyond the threshold and returns how many it marked
+func flagChanges(changes []models.CatalogChange, threshold float64) int {
+	flagged := 0
+	for i := range changes {
+		c := &changes[i]
+		c.Flagged = c.Type == models.CatalogChangePrice && c.ChangePercent != nil && math.Abs(*c.ChangePercent) >= threshold
+		if c.Flagged {
+			flagged++
+		}
+	}
+	return flagged
+}
+
+// recordChanges flags and stores the changes found by a sync, linked to runID when the
+// sync is part of a run
+func (s *CatalogSyncer) recordChanges(ctx context.Context, runID *uuid.UUID, changes []models.CatalogChange) (int, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	flagged := flagChanges(changes, s.priceChangeThreshold())
+	for i := range changes {
+		changes[i].SyncRunID = runID
+	}
+	if err := s.db.WithContext(ctx).Create(&changes).Error; err != nil {
+		return 0, fmt.Errorf("failed to record catalog changes: %v", err)
+	}
+	return flagged, nil
+}
+
+// SyncSKU syncs the packages of one SKU outside of a run and records the changes found
+func (s *CatalogSyncer) SyncSKU(ctx context.Context, skuID string) (PackageSyncResult, error) {
+	result, err := s.products.SyncPackagePrices(ctx, skuID)
+	if err != nil {
+		return result, err
+	}
+	if _, err := s.recordChanges(ctx, nil, result.Changes); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// CatalogChangeFilter narrows ListCatalogChanges; zero values match everything
+type CatalogChangeFilter struct {
+	SyncRunID        *uuid.UUID
+	SKUID            string
+	Type             string
+	FlaggedOnly      bool
+	MinChangePercent float64 // absolute change percent, price/data/day changes only
+}
+
+// ListCatalogChanges returns recorded catalog changes newest first
+func (s *CatalogSyncer) ListCatalogChanges(ctx context.Context, page, limit int, filter CatalogChangeFilter) ([]models.CatalogChange, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.CatalogChange{})
+	if filter.SyncRunID != nil {
+		query = query.Where("sync_run_id = ?", *filter.SyncRunID)
+	}
+	if filter.SKUID != "" {
+		query = query.Where("sku_id = ?", filter.SKUID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.FlaggedOnly {
+		query = query.Where("flagged = ?", true)
+	}
+	if filter.MinChangePercent > 0 {
+		query = query.Where("ABS(change_percent) >= ?", filter.MinChangePercent)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var changes []models.CatalogChange
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get catalog changes: %v", err)
+	}
+	return changes, total, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPackagePrice(t *testing.T) {
+	existing := models.PackagePrice{SKUID: "9001", ProviderPriceID: 11, APICode: "A1", ShowName: "Japan 1GB", Flows: 1, Unit: "GB", Days: 7, RawProviderPrice: money.FromInt(4), Active: true}
+	pkg := RoamWiFiPackage{APICode: "A1", ShowName: "Japan 1GB", Flows: 1, Unit: "GB", Days: 7, Price: 4, PriceID: 11}
+	assert.Empty(t, diffPackagePrice(&existing, "9001", pkg, money.FromInt(4)))
+
+	pkg.Price = 5
+	pkg.Flows = 2
+	pkg.Days = 10
+	changes := diffPackagePrice(&existing, "9001", pkg, money.FromInt(5))
+	require.Len(t, changes, 3)
+
+	price := changes[0]
+	assert.Equal(t, models.CatalogChangePrice, price.Type)
+	assert.Equal(t, 11, price.ProviderPriceID)
+	assert.Equal(t, 4.0, *price.OldValue)
+	assert.Equal(t, 5.0, *price.NewValue)
+	assert.Equal(t, 25.0, *price.ChangePercent)
+
+	assert.Equal(t, models.CatalogChangeData, changes[1].Type)
+	assert.Equal(t, "1 GB -> 2 GB", changes[1].Detail)
+	assert.Equal(t, 100.0, *changes[1].ChangePercent)
+	assert.Equal(t, models.CatalogChangeDays, changes[2].Type)
+	assert.Equal(t, 10.0, *changes[2].NewValue)
+
+	// A unit change is reported without a percentage
+	pkg = RoamWiFiPackage{APICode: "A1", ShowName: "Japan 1GB", Flows: 1024, Unit: "MB", Days: 7, Price: 4, PriceID: 11}
+	changes = diffPackagePrice(&existing, "9001", pkg, money.FromInt(4))
+	require.Len(t, changes, 1)
+	assert.Nil(t, changes[0].ChangePercent)
+
+	// A package the provider offers again is reported as added
+	existing.Active = false
+	pkg = RoamWiFiPackage{APICode: "A1", ShowName: "Japan 1GB", Flows: 1, Unit: "GB", Days: 7, Price: 4, PriceID: 11}
+	changes = diffPackagePrice(&existing, "9001", pkg, money.FromInt(4))
+	require.Len(t, changes, 1)
+	assert.Equal(t, models.CatalogChangeAdded, changes[0].Type)
+	assert.Equal(t, "reactivated", changes[0].Detail)
+}
+
+func TestFlagChanges(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	changes := []models.CatalogChange{
+		{Type: models.CatalogChangePrice, ChangePercent: pct(12.5)},
+		{Type: models.CatalogChangePrice, ChangePercent: pct(-10)},
+		{Type: models.CatalogChangePrice, ChangePercent: pct(9.99)},
+		{Type: models.CatalogChangeDays, ChangePercent: pct(-50)},
+		{Type: models.CatalogChangeRemoved},
+	}
+	assert.Equal(t, 2, flagChanges(changes, 10))
+	assert.True(t, changes[0].Flagged)
+	assert.True(t, changes[1].Flagged)
+	assert.False(t, changes[2].Flagged)
+	assert.False(t, changes[3].Flagged)
+
+	assert.Nil(t, changePercent(0, 5))
+	assert.Equal(t, -33.33, *changePercent(3, 2))
+}
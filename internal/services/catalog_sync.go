@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCatalogSyncInProgress is returned when a sync is requested while another one runs
+	ErrCatalogSyncInProgress = errors.New("a catalog sync is already running")
+	// ErrSyncRunNotFound is returned when a sync run does not exist
+	ErrSyncRunNotFound = errors.New("sync run not found")
+)
+
+// SyncTriggerSchedule is the trigger recorded for runs started by the scheduler; admin
+// runs record AdminActor(userID)
+const SyncTriggerSchedule = "schedule"
+
+const (
+	defaultCatalogSyncTimeout = 30 * time.Minute
+	// maxSyncRunErrors caps the error messages kept on a run; SKUsFailed still counts all
+	maxSyncRunErrors = 50
+)
+
+// CatalogSyncer periodically syncs every provider SKU into products and every SKU's
+// packages into package prices, recording each run in sync_runs. One run executes at a
+// time across all instances.
+type CatalogSyncer struct {
+	db       *gorm.DB
+	products *ProductService
+	config   config.CatalogSyncConfig
+	now      func() time.Time
+
+	mu      sync.Mutex
+	busy    bool
+	pending chan *models.SyncRun
+}
+
+func NewCatalogSyncer(db *gorm.DB, products *ProductService, cfg config.CatalogSyncConfig) *CatalogSyncer {
+	return &CatalogSyncer{
+		db:       db,
+		products: products,
+		config:   cfg,
+		now:      time.Now,
+		pending:  make(chan *models.SyncRun, 1),
+	}
+}
+
+func (s *CatalogSyncer) timeout() time.Duration {
+	if s.config.Timeout <= 0 {
+		return defaultCatalogSyncTimeout
+	}
+	return time.Duration(s.config.Timeout) * time.Second
+}
+
+func (s *CatalogSyncer) concurrency() int {
+	if s.config.Concurrency < 1 {
+		return 1
+	}
+	return s.config.Concurrency
+}
+
+// Run executes scheduled runs every interval, and runs triggered by admins, until ctx is
+// cancelled. Admin triggers are served even when the schedule is disabled.
+func (s *CatalogSyncer) Run(ctx context.Context) {
+	var tick <-chan time.Time
+	if s.config.Enabled && s.config.Interval > 0 {
+		interval := time.Duration(s.config.Interval) * time.Second
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		logrus.Infof("Catalog sync scheduled every %s", interval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Catalog syncer stopped")
+			return
+		case <-tick:
+			if _, err := s.SyncAll(ctx, SyncTriggerSchedule); err != nil && !errors.Is(err, ErrCatalogSyncInProgress) {
+				logrus.Errorf("Catalog sync: %v", err)
+			}
+		case run := <-s.pending:
+			s.execute(ctx, run)
+		}
+	}
+}
+
+// SyncAll runs a full catalog sync and returns the finished run
+func (s *CatalogSyncer) SyncAll(ctx context.Context, trigger string) (*models.SyncRun, error) {
+	run, err := s.begin(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+	s.execute(ctx, run)
+	return run, nil
+}
+
+// TriggerSync records a run and hands it to the Run goroutine, returning without waiting
+func (s *CatalogSyncer) TriggerSync(ctx context.Context, trigger string) (*models.SyncRun, error) {
+	run, err := s.begin(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+	// begin admits one run at a time, so the buffered channel always has room
+	s.pending <- run
+	return run, nil
+}
+
+// begin claims the syncer and records a running sync run. Runs older than the timeout
+// that never finished were cut off by a restart and are marked failed first.
+func (s *CatalogSyncer) begin(ctx context.Context, trigger string) (*models.SyncRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy {
+		return nil, ErrCatalogSyncInProgress
+	}
+
+	now := s.now()
+	if err := s.db.WithContext(ctx).Model(&models.SyncRun{}).
+		Where("status = ? AND started_at < ?", models.SyncRunRunning, now.Add(-s.timeout())).
+		Updates(map[string]interface{}{"status": models.SyncRunFailed, "finished_at": now}).Error; err != nil {
+		return nil, fmt.Errorf("failed to close abandoned sync runs: %v", err)
+	}
+	var running int64
+	if err := s.db.WithContext(ctx).Model(&models.SyncRun{}).Where("status = ?", models.SyncRunRunning).Count(&running).Error; err != nil {
+		return nil, fmt.Errorf("failed to check running sync runs: %v", err)
+	}
+	if running > 0 {
+		return nil, ErrCatalogSyncInProgress
+	}
+
+	run := &models.SyncRun{Trigger: trigger, Status: models.SyncRunRunning, StartedAt: now}
+	if err := s.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record sync run: %v", err)
+	}
+	s.busy = true
+	return run, nil
+}
+
+// execute syncs the SKU list, then the packages of each SKU with bounded concurrency.
+// Cancelling ctx stops dispatching SKUs; those already being synced finish.
+func (s *CatalogSyncer) execute(ctx context.Context, run *models.SyncRun) {
+	defer func() {
+		s.mu.Lock()
+		s.busy = false
+		s.mu.Unlock()
+	}()
+
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	opCtx, opCancel := workContext(runCtx, backgroundOpTimeout)
+	skus, _, err := s.products.syncProducts(opCtx)
+	opCancel()
+	if err != nil {
+		addSyncRunError(run, err.Error())
+		s.finish(ctx, run, 0)
+		return
+	}
+	run.SKUsTotal = len(skus)
+
+	var mu sync.Mutex
+	skuIDs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for skuID := range skuIDs {
+				opCtx, opCancel := workContext(runCtx, backgroundOpTimeout)
+				result, err := s.products.SyncPackagePrices(opCtx, skuID)
+				flagged := 0
+				if err == nil {
+					flagged, err = s.recordChanges(opCtx, &run.ID, result.Changes)
+				}
+				opCancel()
+
+				mu.Lock()
+				if err != nil {
+					run.SKUsFailed++
+					addSyncRunError(run, fmt.Sprintf("sku %s: %v", skuID, err))
+				} else {
+					run.SKUsSynced++
+					run.PackagesAdded += result.Added
+					run.PackagesChanged += result.Changed
+					run.PackagesDeactivated += result.Deactivated
+					run.Changes += len(result.Changes)
+					run.ChangesFlagged += flagged
+					run.PricingAlerts += result.PricingAlerts
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	skipped := 0
+dispatch:
+	for i, sku := range skus {
+		select {
+		case skuIDs <- fmt.Sprintf("%d", sku.SKUID):
+		case <-runCtx.Done():
+			skipped = len(skus) - i
+			break dispatch
+		}
+	}
+	close(skuIDs)
+	wg.Wait()
+
+	if skipped > 0 {
+		addSyncRunError(run, fmt.Sprintf("stopped before syncing %d SKUs: %v", skipped, runCtx.Err()))
+	}
+	s.finish(ctx, run, skipped)
+}
+
+// finish stores the outcome of a run
+func (s *CatalogSyncer) finish(ctx context.Context, run *models.SyncRun, skipped int) {
+	finishedAt := s.now()
+	run.FinishedAt = &finishedAt
+	run.Status = syncRunStatus(run, skipped)
+
+	saveCtx, cancel := workContext(ctx, backgroundOpTimeout)
+	defer cancel()
+	if err := s.db.WithContext(saveCtx).Save(run).Error; err != nil {
+		logrus.Errorf("Catalog sync: failed to record run %s: %v", run.ID, err)
+	}
+	logrus.Infof("Catalog sync %s (%s): %d/%d SKUs, packages +%d ~%d -%d, %d changes (%d flagged), %d failed",
+		run.Status, run.Trigger, run.SKUsSynced, run.SKUsTotal,
+		run.PackagesAdded, run.PackagesChanged, run.PackagesDeactivated, run.Changes, run.ChangesFlagged, run.SKUsFailed)
+	if run.ChangesFlagged > 0 {
+		logrus.Warnf("Catalog sync %s: %d provider price changes of %.0f%% or more", run.ID, run.ChangesFlagged, s.priceChangeThreshold())
+	}
+}
+
+// syncRunStatus derives the final status of a run from its counters
+func syncRunStatus(run *models.SyncRun, skipped int) string {
+	switch {
+	case run.SKUsTotal == 0 && len(run.Errors) > 0:
+		return models.SyncRunFailed
+	case run.SKUsFailed > 0 || skipped > 0:
+		if run.SKUsSynced == 0 {
+			return models.SyncRunFailed
+		}
+		return models.SyncRunPartial
+	default:
+		return models.SyncRunSucceeded
+	}
+}
+
+func addSyncRunError(run *models.SyncRun, msg string) {
+	if len(run.Errors) < maxSyncRunErrors {
+		run.Errors = append(run.Errors, msg)
+	}
+}
+
+// ListSyncRuns returns sync runs newest first, optionally filtered by status
+func (s *CatalogSyncer) ListSyncRuns(ctx context.Context, page, limit int, status string) ([]models.SyncRun, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.SyncRun{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var runs []models.SyncRun
+	offset := (page - 1) * limit
+	if err := query.Order("started_at DESC").Offset(offset).Limit(limit).Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get sync runs: %v", err)
+	}
+	return runs, total, nil
+}
+
+// GetSyncRun returns a single sync run
+func (s *CatalogSyncer) GetSyncRun(ctx context.Context, id uuid.UUID) (*models.SyncRun, error) {
+	var run models.SyncRun
+	if err := s.db.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSyncRunNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
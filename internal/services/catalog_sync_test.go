@@ -0,0 +1,42 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncRunStatus(t *testing.T) {
+	assert.Equal(t, models.SyncRunSucceeded, syncRunStatus(&models.SyncRun{SKUsTotal: 3, SKUsSynced: 3}, 0))
+	assert.Equal(t, models.SyncRunSucceeded, syncRunStatus(&models.SyncRun{}, 0))
+	assert.Equal(t, models.SyncRunPartial, syncRunStatus(&models.SyncRun{SKUsTotal: 3, SKUsSynced: 2, SKUsFailed: 1}, 0))
+	assert.Equal(t, models.SyncRunPartial, syncRunStatus(&models.SyncRun{SKUsTotal: 3, SKUsSynced: 1}, 2))
+	assert.Equal(t, models.SyncRunFailed, syncRunStatus(&models.SyncRun{SKUsTotal: 2, SKUsFailed: 2}, 0))
+	assert.Equal(t, models.SyncRunFailed, syncRunStatus(&models.SyncRun{Errors: models.StringArray{"provider down"}}, 0))
+}
+
+func TestAddSyncRunErrorIsCapped(t *testing.T) {
+	run := &models.SyncRun{}
+	for i := 0; i < maxSyncRunErrors+10; i++ {
+		addSyncRunError(run, fmt.Sprintf("sku %d: failed", i))
+	}
+	assert.Len(t, run.Errors, maxSyncRunErrors)
+	assert.Equal(t, "sku 0: failed", run.Errors[0])
+}
+
+func TestPackagePriceChanged(t *testing.T) {
+	pkg := RoamWiFiPackage{APICode: "A1", ShowName: "Japan 1GB", Flows: 1, Unit: "GB", Days: 7, Price: 4.5, PriceID: 11}
+	existing := models.PackagePrice{SKUID: "9001", APICode: "A1", ShowName: "Japan 1GB", Flows: 1, Unit: "GB", Days: 7, RawProviderPrice: money.MustParse("4.5"), Active: true}
+	assert.False(t, packagePriceChanged(&existing, "9001", pkg, money.MustParse("4.5")))
+
+	pkg.Price = 5
+	assert.True(t, packagePriceChanged(&existing, "9001", pkg, money.FromInt(5)))
+
+	pkg.Price = 4.5
+	existing.Active = false
+	assert.True(t, packagePriceChanged(&existing, "9001", pkg, money.MustParse("4.5")))
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// ErrRateUnavailable is returned when no exchange rate is known for a currency pair
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// ExchangeRate is the rate between two currencies, in units of To per unit of From, and
+// when and where it was set
+type ExchangeRate struct {
+	ID     *uuid.UUID     `json:"id,omitempty"` // stored rate it was read from; unset for cross rates
+	From   money.Currency `json:"from"`
+	To     money.Currency `json:"to"`
+	Rate   money.Decimal  `json:"rate"`
+	Source string         `json:"source"` // bom|json|manual, joined with "/" for cross rates
+	AsOf   time.Time      `json:"as_of"`
+}
+
+func exchangeRateFromModel(r models.CurrencyRate) ExchangeRate {
+	id := r.ID
+	return ExchangeRate{
+		ID:     &id,
+		From:   money.Currency(r.FromCurrency),
+		To:     money.Currency(r.ToCurrency),
+		Rate:   r.Rate,
+		Source: r.Source,
+		AsOf:   r.LastUpdated,
+	}
+}
+
+// Convert converts m, in either currency of the pair, to the other one and settles it.
+// It panics if m is in neither currency.
+func (r ExchangeRate) Convert(m money.Money) money.Money {
+	switch m.Currency {
+	case r.From:
+		return m.Convert(r.Rate, r.To).Settled()
+	case r.To:
+		// Dividing keeps the precision a rounded inverse rate would lose
+		return money.New(m.Amount.Div(r.Rate), r.From).Settled()
+	}
+	panic(fmt.Sprintf("services: cannot convert %s with a %s/%s rate", m.Currency, r.From, r.To))
+}
+
+// crossRate derives the rate between two currencies from their MNT rates. It is as old
+// as the older of the two.
+func crossRate(fromMNT, toMNT ExchangeRate) ExchangeRate {
+	rate := ExchangeRate{
+		From:   fromMNT.From,
+		To:     toMNT.From,
+		Rate:   fromMNT.Rate.Div(toMNT.Rate),
+		Source: fromMNT.Source,
+		AsOf:   fromMNT.AsOf,
+	}
+	if toMNT.Source != fromMNT.Source {
+		rate.Source += "/" + toMNT.Source
+	}
+	if toMNT.AsOf.Before(rate.AsOf) {
+		rate.AsOf = toMNT.AsOf
+	}
+	return rate
+}
+
+// languageCurrencies is the storefront currency shown for a browser language
+var languageCurrencies = map[string]money.Currency{
+	"mn": money.MNT,
+	"en": money.USD,
+	"zh": money.CNY,
+	"ko": money.KRW,
+}
+
+// CurrencyForLanguage picks the storefront currency for an Accept-Language header: the
+// currency of the most preferred language we have one for, else MNT
+func CurrencyForLanguage(acceptLanguage string) money.Currency {
+	type langPref struct {
+		currency money.Currency
+		q        float64
+	}
+	var prefs []langPref
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(lang, "-_"); i >= 0 {
+			lang = lang[:i]
+		}
+		currency, ok := languageCurrencies[lang]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, langPref{currency: currency, q: q})
+		}
+	}
+	if len(prefs) == 0 {
+		return money.MNT
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	return prefs[0].currency
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeRateConvert(t *testing.T) {
+	usdMNT := ExchangeRate{From: money.USD, To: money.MNT, Rate: money.MustParse("3450.5")}
+	assert.Equal(t, "71253 MNT", usdMNT.Convert(money.New(money.MustParse("20.65"), money.USD)).String())
+	// MNT to USD divides by the rate instead of multiplying by a rounded inverse
+	assert.Equal(t, "20.84 USD", usdMNT.Convert(money.New(money.FromInt(71900), money.MNT)).String())
+	assert.Panics(t, func() { usdMNT.Convert(money.New(money.FromInt(1), money.KRW)) })
+}
+
+func TestCrossRate(t *testing.T) {
+	older := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	usdMNT := ExchangeRate{From: money.USD, To: money.MNT, Rate: money.FromInt(3450), Source: "manual", AsOf: older.Add(time.Hour)}
+	cnyMNT := ExchangeRate{From: money.CNY, To: money.MNT, Rate: money.FromInt(480), Source: "bom", AsOf: older}
+
+	usdCNY := crossRate(usdMNT, cnyMNT)
+	assert.Equal(t, money.USD, usdCNY.From)
+	assert.Equal(t, money.CNY, usdCNY.To)
+	assert.Equal(t, money.MustParse("7.1875"), usdCNY.Rate)
+	assert.Equal(t, "manual/bom", usdCNY.Source)
+	assert.Equal(t, older, usdCNY.AsOf)
+	assert.Equal(t, "143.75 CNY", usdCNY.Convert(money.New(money.FromInt(20), money.USD)).String())
+	assert.Equal(t, "20 USD", usdCNY.Convert(money.New(money.MustParse("143.75"), money.CNY)).String())
+}
+
+func TestCurrencyForLanguage(t *testing.T) {
+	for header, want := range map[string]money.Currency{
+		"":                            money.MNT,
+		"mn-MN,mn;q=0.9":              money.MNT,
+		"en-US,en;q=0.9":              money.USD,
+		"ko-KR,ko;q=0.9,en-US;q=0.8":  money.KRW,
+		"fr-FR,en;q=0.5,zh-CN;q=0.8":  money.CNY,
+		"zh_TW":                       money.CNY,
+		"de-DE,fr;q=0.9":              money.MNT,
+		"en;q=0,ko;q=0.1":             money.KRW,
+		"EN-gb, ko;q=1":               money.USD,
+		"ko;q=abc, en;q=0.5":          money.KRW,
+		"ru-RU, ja-JP;q=0.8, *;q=0.1": money.MNT,
+	} {
+		assert.Equal(t, want, CurrencyForLanguage(header), header)
+	}
+}
+
+func TestEnrichedPackagesInCurrency(t *testing.T) {
+	mnt, was := money.FromInt(71900), money.FromInt(89900)
+	resp := &EnrichedRoamWiFiPackagesResponse{Packages: []EnrichedRoamWiFiPackage{
+		{PriceID: 1, EffectivePriceUSD: money.MustParse("20.65"), EffectivePriceMNT: &mnt, OriginalPriceMNT: &was},
+		{PriceID: 2, EffectivePriceUSD: money.MustParse("5")},
+	}}
+
+	inMNT := resp.InCurrency(nil)
+	assert.Equal(t, money.MNT, inMNT.Currency)
+	assert.Nil(t, inMNT.DisplayRate)
+	assert.Equal(t, mnt, *inMNT.Packages[0].DisplayPrice)
+	assert.Nil(t, inMNT.Packages[1].DisplayPrice)
+	assert.Equal(t, was, *inMNT.Packages[0].DisplayOriginalPrice)
+	assert.Nil(t, inMNT.Packages[1].DisplayOriginalPrice)
+
+	rate := &ExchangeRate{From: money.KRW, To: money.MNT, Rate: money.MustParse("2.5")}
+	inKRW := resp.InCurrency(rate)
+	assert.Equal(t, money.KRW, inKRW.Currency)
+	assert.Equal(t, money.MNT, inKRW.ChargeCurrency)
+	assert.Equal(t, money.FromInt(28760), *inKRW.Packages[0].DisplayPrice)
+	assert.Equal(t, money.FromInt(35960), *inKRW.Packages[0].DisplayOriginalPrice)
+	// The cached response is left untouched
+	assert.Nil(t, resp.Packages[0].DisplayPrice)
+	assert.Empty(t, resp.Currency)
+}
+
+func TestPackageRate(t *testing.T) {
+	oldID, currentID := uuid.New(), uuid.New()
+	asOf := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	rates := map[uuid.UUID]models.CurrencyRate{
+		oldID: {ID: oldID, FromCurrency: "USD", ToCurrency: "MNT", Rate: money.FromInt(3400), Source: "bom", LastUpdated: asOf},
+	}
+	current := &ExchangeRate{ID: &currentID, From: money.USD, To: money.MNT, Rate: money.FromInt(3450), Source: "bom"}
+
+	stale := packageRate(models.PackagePrice{ExchangeRateID: &oldID}, rates, current)
+	require.NotNil(t, stale.Rate)
+	assert.Equal(t, money.FromInt(3400), stale.Rate.Rate)
+	assert.Equal(t, asOf, stale.Rate.AsOf)
+	assert.False(t, stale.Current)
+
+	assert.True(t, packageRate(models.PackagePrice{ExchangeRateID: &currentID}, rates, current).Current)
+
+	untracked := packageRate(models.PackagePrice{}, rates, current)
+	assert.Nil(t, untracked.Rate)
+	assert.False(t, untracked.Current)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const defaultRateHistoryLimit = 100
+
+// RateHistoryFilter narrows ExchangeRateHistory; zero values match everything
+type RateHistoryFilter struct {
+	Currency money.Currency // rates of this currency against MNT, USD when empty
+	Since    *time.Time     // rates that took effect at or after Since
+	Until    *time.Time     // rates that took effect before Until
+	Limit    int
+	SKUID    string // only this SKU's packages
+}
+
+// RateHistoryEntry is one stored rate: when it took effect, when its source last confirmed
+// it (as_of) and how many active packages are priced with it
+type RateHistoryEntry struct {
+	ExchangeRate
+	EffectiveFrom time.Time `json:"effective_from"`
+	Packages      int64     `json:"packages"`
+}
+
+// PackageRate is the rate a package's MNT price was derived from
+type PackageRate struct {
+	PackagePriceID    uuid.UUID      `json:"package_price_id"`
+	ProviderPriceID   int            `json:"provider_price_id"`
+	SKUID             string         `json:"sku_id"`
+	ShowName          string         `json:"show_name"`
+	EffectivePriceUSD money.Decimal  `json:"effective_price_usd"`
+	EffectivePriceMNT *money.Decimal `json:"effective_price_mnt"`
+	ExchangeRate      *money.Decimal `json:"exchange_rate"`
+	RoundingRule      string         `json:"rounding_rule"`
+	Rate              *ExchangeRate  `json:"rate,omitempty"` // stored rate used, unknown for prices set before rates were tracked
+	Current           bool           `json:"current"`        // priced at the rate in use now
+}
+
+// ExchangeRateHistory is the time series of a currency's MNT rate, newest first, and for
+// USD the rate each active package's MNT price was derived from
+type ExchangeRateHistory struct {
+	Currency money.Currency     `json:"currency"`
+	Current  *ExchangeRate      `json:"current,omitempty"`
+	Rates    []RateHistoryEntry `json:"rates"`
+	Packages []PackageRate      `json:"packages"`
+}
+
+// ExchangeRateHistory returns the stored MNT rates of a currency and which of them the
+// active package prices were calculated with
+func (p *PricingService) ExchangeRateHistory(ctx context.Context, filter RateHistoryFilter) (*ExchangeRateHistory, error) {
+	if filter.Currency == "" {
+		filter.Currency = money.USD
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultRateHistoryLimit
+	}
+	history := &ExchangeRateHistory{Currency: filter.Currency, Rates: []RateHistoryEntry{}, Packages: []PackageRate{}}
+	if current, err := p.GetRate(ctx, filter.Currency, money.MNT); err == nil {
+		history.Current = &current
+	}
+
+	query := p.db.WithContext(ctx).Where("from_currency = ? AND to_currency = ?", string(filter.Currency), string(money.MNT))
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	var rows []models.CurrencyRate
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rate history: %v", err)
+	}
+	if filter.Currency != money.USD {
+		// Only USD rates price packages
+		for _, row := range rows {
+			history.Rates = append(history.Rates, RateHistoryEntry{ExchangeRate: exchangeRateFromModel(row), EffectiveFrom: row.CreatedAt})
+		}
+		return history, nil
+	}
+
+	var counts []struct {
+		ExchangeRateID uuid.UUID
+		Count          int64
+	}
+	if err := p.db.WithContext(ctx).Model(&models.PackagePrice{}).
+		Select("exchange_rate_id, COUNT(*) AS count").
+		Where("active = ? AND exchange_rate_id IS NOT NULL", true).
+		Group("exchange_rate_id").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count packages per rate: %v", err)
+	}
+	perRate := make(map[uuid.UUID]int64, len(counts))
+	for _, c := range counts {
+		perRate[c.ExchangeRateID] = c.Count
+	}
+	for _, row := range rows {
+		history.Rates = append(history.Rates, RateHistoryEntry{ExchangeRate: exchangeRateFromModel(row), EffectiveFrom: row.CreatedAt, Packages: perRate[row.ID]})
+	}
+
+	pkgQuery := p.db.WithContext(ctx).Where("active = ?", true)
+	if filter.SKUID != "" {
+		pkgQuery = pkgQuery.Where("sku_id = ?", filter.SKUID)
+	}
+	var prices []models.PackagePrice
+	if err := pkgQuery.Order("sku_id, provider_price_id").Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to load package prices: %v", err)
+	}
+	usedRates, err := p.ratesByID(ctx, prices)
+	if err != nil {
+		return nil, err
+	}
+	for _, pp := range prices {
+		history.Packages = append(history.Packages, packageRate(pp, usedRates, history.Current))
+	}
+	return history, nil
+}
+
+// ratesByID loads the stored rates the given packages were priced with
+func (p *PricingService) ratesByID(ctx context.Context, prices []models.PackagePrice) (map[uuid.UUID]models.CurrencyRate, error) {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, pp := range prices {
+		if pp.ExchangeRateID != nil && !seen[*pp.ExchangeRateID] {
+			seen[*pp.ExchangeRateID] = true
+			ids = append(ids, *pp.ExchangeRateID)
+		}
+	}
+	rates := make(map[uuid.UUID]models.CurrencyRate, len(ids))
+	if len(ids) == 0 {
+		return rates, nil
+	}
+	var rows []models.CurrencyRate
+	if err := p.db.WithContext(ctx).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load package rates: %v", err)
+	}
+	for _, row := range rows {
+		rates[row.ID] = row
+	}
+	return rates, nil
+}
+
+func packageRate(pp models.PackagePrice, rates map[uuid.UUID]models.CurrencyRate, current *ExchangeRate) PackageRate {
+	pr := PackageRate{
+		PackagePriceID:    pp.ID,
+		ProviderPriceID:   pp.ProviderPriceID,
+		SKUID:             pp.SKUID,
+		ShowName:          pp.ShowName,
+		EffectivePriceUSD: pp.EffectivePriceUSD,
+		EffectivePriceMNT: pp.EffectivePriceMNT,
+		ExchangeRate:      pp.ExchangeRate,
+		RoundingRule:      pp.RoundingRule,
+	}
+	if pp.ExchangeRateID != nil {
+		if row, ok := rates[*pp.ExchangeRateID]; ok {
+			rate := exchangeRateFromModel(row)
+			pr.Rate = &rate
+		}
+		pr.Current = current != nil && current.ID != nil && *current.ID == *pp.ExchangeRateID
+	}
+	return pr
+}
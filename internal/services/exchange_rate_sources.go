@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRateSource provides current exchange rates. Sources quote every rate against
+// the tugrik, in units of MNT per unit of the currency, whatever base their upstream uses.
+type ExchangeRateSource interface {
+	Name() string
+	FetchRates(ctx context.Context) (map[money.Currency]money.Decimal, error)
+}
+
+// Exchange rate source names accepted by EXCHANGE_RATE_SOURCES
+const (
+	RateSourceBankOfMongolia = "bom"
+	RateSourceJSON           = "json"
+	RateSourceManual         = "manual"
+)
+
+var (
+	_ ExchangeRateSource = (*BankOfMongoliaSource)(nil)
+	_ ExchangeRateSource = (*JSONRateSource)(nil)
+	_ ExchangeRateSource = (*ManualRateSource)(nil)
+)
+
+// NewExchangeRateSources builds the sources listed in configuration, in priority order
+func NewExchangeRateSources(db *gorm.DB, cfg config.ExchangeRateConfig) ([]ExchangeRateSource, error) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var sources []ExchangeRateSource
+	seen := map[string]bool{}
+	for _, name := range strings.Split(cfg.Sources, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case RateSourceBankOfMongolia:
+			sources = append(sources, &BankOfMongoliaSource{url: cfg.BOMURL, client: client, now: time.Now})
+		case RateSourceJSON:
+			sources = append(sources, &JSONRateSource{url: cfg.JSONURL, client: client})
+		case RateSourceManual:
+			sources = append(sources, &ManualRateSource{db: db})
+		default:
+			return nil, fmt.Errorf("unknown exchange rate source %q (want %s, %s or %s)", name, RateSourceBankOfMongolia, RateSourceJSON, RateSourceManual)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no exchange rate sources configured")
+	}
+	return sources, nil
+}
+
+// getRatesJSON fetches url and decodes its JSON body into v
+func getRatesJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rate request failed: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// BankOfMongoliaSource reads the official daily rates of the Bank of Mongolia. The
+// endpoint returns one entry per day of the requested range, quoted in MNT per unit with
+// thousands separators:
+//
+//	{"success": true, "data": [{"RATE_DATE": "2025-08-11", "USD": "3,580.52", "CNY": "498.61", "KRW": "2.59"}]}
+//
+// The last week is requested so weekends and holidays still return the latest rate.
+type BankOfMongoliaSource struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+}
+
+func (s *BankOfMongoliaSource) Name() string { return RateSourceBankOfMongolia }
+
+func (s *BankOfMongoliaSource) FetchRates(ctx context.Context) (map[money.Currency]money.Decimal, error) {
+	end := s.now()
+	query := url.Values{}
+	query.Set("startDate", end.AddDate(0, 0, -7).Format("2006-01-02"))
+	query.Set("endDate", end.Format("2006-01-02"))
+
+	var resp struct {
+		Success bool                     `json:"success"`
+		Data    []map[string]interface{} `json:"data"`
+	}
+	if err := getRatesJSON(ctx, s.client, s.url+"?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success || len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no rates in Bank of Mongolia response")
+	}
+
+	// Dates are ISO formatted, so the latest day sorts last
+	latest := resp.Data[0]
+	for _, day := range resp.Data[1:] {
+		if fmt.Sprint(day["RATE_DATE"]) > fmt.Sprint(latest["RATE_DATE"]) {
+			latest = day
+		}
+	}
+	rates := make(map[money.Currency]money.Decimal)
+	for _, c := range money.Currencies {
+		v, ok := latest[string(c)]
+		if !ok || c == money.MNT {
+			continue
+		}
+		rate, err := money.Parse(strings.ReplaceAll(fmt.Sprint(v), ",", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Bank of Mongolia %s rate: %v", c, err)
+		}
+		if rate.Sign() > 0 {
+			rates[c] = rate
+		}
+	}
+	return rates, nil
+}
+
+// JSONRateSource reads a JSON document listing rates against a base currency, in the
+// format of exchangerate-api.com and similar services:
+//
+//	{"base": "USD", "rates": {"MNT": 3580.5, "CNY": 7.18, "KRW": 1384.2}}
+//
+// "base_code" and "conversion_rates" are accepted as well.
+type JSONRateSource struct {
+	url    string
+	client *http.Client
+}
+
+func (s *JSONRateSource) Name() string { return RateSourceJSON }
+
+func (s *JSONRateSource) FetchRates(ctx context.Context) (map[money.Currency]money.Decimal, error) {
+	var resp struct {
+		Base            string                   `json:"base"`
+		BaseCode        string                   `json:"base_code"`
+		Rates           map[string]money.Decimal `json:"rates"`
+		ConversionRates map[string]money.Decimal `json:"conversion_rates"`
+	}
+	if err := getRatesJSON(ctx, s.client, s.url, &resp); err != nil {
+		return nil, err
+	}
+	base, quoted := resp.Base, resp.Rates
+	if base == "" {
+		base = resp.BaseCode
+	}
+	if len(quoted) == 0 {
+		quoted = resp.ConversionRates
+	}
+	return ratesAgainstMNT(money.Currency(strings.ToUpper(base)), quoted)
+}
+
+// ratesAgainstMNT turns rates quoted in currency per unit of base into MNT per unit of
+// each supported currency
+func ratesAgainstMNT(base money.Currency, quoted map[string]money.Decimal) (map[money.Currency]money.Decimal, error) {
+	perBase := func(c money.Currency) (money.Decimal, bool) {
+		if c == base {
+			return money.FromInt(1), true
+		}
+		r, ok := quoted[string(c)]
+		return r, ok && r.Sign() > 0
+	}
+	mnt, ok := perBase(money.MNT)
+	if !ok {
+		return nil, fmt.Errorf("MNT rate not found in %s based response", base)
+	}
+	rates := make(map[money.Currency]money.Decimal)
+	for _, c := range money.Currencies {
+		if r, ok := perBase(c); ok && c != money.MNT {
+			rates[c] = mnt.Div(r)
+		}
+	}
+	return rates, nil
+}
+
+// ManualRateSource serves the latest rates admins entered through the exchange rate
+// endpoint. Listed first it pins those rates; listed last it is the fallback when every
+// upstream is down.
+type ManualRateSource struct {
+	db *gorm.DB
+}
+
+func (s *ManualRateSource) Name() string { return RateSourceManual }
+
+func (s *ManualRateSource) FetchRates(ctx context.Context) (map[money.Currency]money.Decimal, error) {
+	rates := make(map[money.Currency]money.Decimal)
+	for _, c := range money.Currencies {
+		if c == money.MNT {
+			continue
+		}
+		var rate models.CurrencyRate
+		err := s.db.WithContext(ctx).Where("from_currency = ? AND to_currency = ? AND source = ?", string(c), string(money.MNT), RateSourceManual).
+			Order("last_updated DESC").First(&rate).Error
+		if err == nil && rate.Rate.Sign() > 0 {
+			rates[c] = rate.Rate
+		}
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no manual rates have been set")
+	}
+	return rates, nil
+}
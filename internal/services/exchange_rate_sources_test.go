@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBankOfMongoliaSource(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"success":true,"data":[
+			{"RATE_DATE":"2026-10-15","USD":"3,440.10","CNY":"478.20","KRW":"2.49"},
+			{"RATE_DATE":"2026-10-16","USD":"3,450.00","CNY":"480.00","KRW":"2.51","EUR":"3,990.00"}
+		]}`))
+	}))
+	defer server.Close()
+
+	src := &BankOfMongoliaSource{url: server.URL, client: server.Client(), now: func() time.Time {
+		return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	}}
+	rates, err := src.FetchRates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "endDate=2026-10-16&startDate=2026-10-09", query)
+	assert.Equal(t, map[money.Currency]money.Decimal{
+		money.USD: money.FromInt(3450),
+		money.CNY: money.FromInt(480),
+		money.KRW: money.MustParse("2.51"),
+	}, rates)
+}
+
+func TestBankOfMongoliaSourceErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"unsuccessful": `{"success":false,"data":[]}`,
+		"no data":      `{"success":true,"data":[]}`,
+		"bad rate":     `{"success":true,"data":[{"RATE_DATE":"2026-10-16","USD":"n/a"}]}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		src := &BankOfMongoliaSource{url: server.URL, client: server.Client(), now: time.Now}
+		_, err := src.FetchRates(context.Background())
+		assert.Error(t, err, name)
+		server.Close()
+	}
+}
+
+func TestJSONRateSource(t *testing.T) {
+	for name, body := range map[string]string{
+		"rates":            `{"base":"USD","rates":{"USD":1,"MNT":3450,"CNY":7.1875,"KRW":1380,"EUR":0.92}}`,
+		"conversion_rates": `{"base_code":"usd","conversion_rates":{"MNT":3450,"CNY":7.1875,"KRW":1380}}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		src := &JSONRateSource{url: server.URL, client: server.Client()}
+		rates, err := src.FetchRates(context.Background())
+		require.NoError(t, err, name)
+		assert.Equal(t, map[money.Currency]money.Decimal{
+			money.USD: money.FromInt(3450),
+			money.CNY: money.FromInt(480),
+			money.KRW: money.MustParse("2.5"),
+		}, rates, name)
+		server.Close()
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	_, err := (&JSONRateSource{url: server.URL, client: server.Client()}).FetchRates(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRatesAgainstMNT(t *testing.T) {
+	rates, err := ratesAgainstMNT(money.MNT, map[string]money.Decimal{"USD": money.MustParse("0.0002"), "CNY": money.MustParse("0.002")})
+	require.NoError(t, err)
+	assert.Equal(t, map[money.Currency]money.Decimal{money.USD: money.FromInt(5000), money.CNY: money.FromInt(500)}, rates)
+
+	_, err = ratesAgainstMNT(money.USD, map[string]money.Decimal{"CNY": money.MustParse("7.2")})
+	assert.Error(t, err)
+}
+
+func TestNewExchangeRateSources(t *testing.T) {
+	sources, err := NewExchangeRateSources(nil, config.ExchangeRateConfig{Sources: " BOM, manual,json,bom"})
+	require.NoError(t, err)
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{"bom", "manual", "json"}, names)
+
+	_, err = NewExchangeRateSources(nil, config.ExchangeRateConfig{Sources: "bom,ecb"})
+	assert.Error(t, err)
+	_, err = NewExchangeRateSources(nil, config.ExchangeRateConfig{Sources: " , "})
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const defaultRateMaxAge = 24 * time.Hour
+
+// RejectedRate is a fetched rate that failed the sanity bounds
+type RejectedRate struct {
+	Source           string         `json:"source"`
+	Currency         money.Currency `json:"currency"`
+	Rate             money.Decimal  `json:"rate"`      // MNT per unit of Currency
+	LastRate         money.Decimal  `json:"last_rate"` // last known rate it was compared to
+	DeviationPercent float64        `json:"deviation_percent"`
+}
+
+// RateRefreshResult describes one refresh: which source's rates were stored and why the
+// sources before it were passed over
+type RateRefreshResult struct {
+	At       time.Time                        `json:"at"`
+	Source   string                           `json:"source,omitempty"` // empty when no source succeeded
+	Rates    map[money.Currency]money.Decimal `json:"rates,omitempty"`
+	Rejected []RejectedRate                   `json:"rejected,omitempty"`
+	Errors   []string                         `json:"errors,omitempty"`
+	Repriced int                              `json:"packages_repriced"`
+}
+
+// ExchangeRateStatus reports the USD to MNT rate prices are calculated with and alerts
+// when it is stale or comes from a fallback source
+type ExchangeRateStatus struct {
+	Sources     []string           `json:"sources"` // priority order
+	Current     *ExchangeRate      `json:"current,omitempty"`
+	Stale       bool               `json:"stale"`
+	Fallback    bool               `json:"fallback"`
+	LastRefresh *RateRefreshResult `json:"last_refresh,omitempty"`
+	Alerts      []string           `json:"alerts"`
+}
+
+// ExchangeRateUpdater periodically fetches rates from the configured sources in priority
+// order and stores the first acceptable set. A source is passed over when it fails or its
+// USD rate deviates from the last known one by more than the configured percentage.
+// Whenever a new rate is stored, here or by an admin, package MNT prices are recomputed.
+type ExchangeRateUpdater struct {
+	db       *gorm.DB
+	products *ProductService
+	sources  []ExchangeRateSource
+	config   config.ExchangeRateConfig
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last *RateRefreshResult
+}
+
+func NewExchangeRateUpdater(db *gorm.DB, products *ProductService, sources []ExchangeRateSource, cfg config.ExchangeRateConfig) *ExchangeRateUpdater {
+	return &ExchangeRateUpdater{db: db, products: products, sources: sources, config: cfg, now: time.Now}
+}
+
+func (u *ExchangeRateUpdater) maxAge() time.Duration {
+	if u.config.MaxAge <= 0 {
+		return defaultRateMaxAge
+	}
+	return time.Duration(u.config.MaxAge) * time.Second
+}
+
+// Run refreshes rates at startup and then every refresh interval until ctx is cancelled
+func (u *ExchangeRateUpdater) Run(ctx context.Context) {
+	if u.config.RefreshInterval <= 0 {
+		logrus.Warn("Exchange rate updater disabled: EXCHANGE_RATE_REFRESH_INTERVAL must be positive; rates are only refreshed on demand")
+		return
+	}
+	interval := time.Duration(u.config.RefreshInterval) * time.Second
+	logrus.Infof("Exchange rates refreshed every %s from %v", interval, u.sourceNames())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := u.Refresh(ctx); err != nil {
+			logrus.Errorf("Exchange rate refresh: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			logrus.Info("Exchange rate updater stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *ExchangeRateUpdater) sourceNames() []string {
+	names := make([]string, len(u.sources))
+	for i, s := range u.sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Refresh fetches rates from the sources in priority order and stores those of the first
+// acceptable one. A rate equal to the latest stored one from the same source only moves
+// that row's last_updated, so the history holds one row per change. Packages are repriced
+// when a new rate was stored. It returns an error, along with the result, when no source
+// was usable.
+func (u *ExchangeRateUpdater) Refresh(ctx context.Context) (*RateRefreshResult, error) {
+	pricing := NewPricingService(u.db)
+	latest := make(map[money.Currency]models.CurrencyRate)
+	lastKnown := make(map[money.Currency]money.Decimal)
+	for _, c := range money.Currencies {
+		if stored, ok := pricing.latestRate(ctx, c, money.MNT); ok {
+			latest[c] = stored
+			lastKnown[c] = stored.Rate
+		}
+	}
+
+	result := selectRates(ctx, u.sources, lastKnown, u.config.MaxDeviation)
+	result.At = u.now()
+	for _, r := range result.Rejected {
+		logrus.Warnf("Exchange rate source %s: rejected %s rate %s, %.1f%% from last known %s", r.Source, r.Currency, r.Rate, r.DeviationPercent, r.LastRate)
+	}
+
+	var err error
+	stored := false
+	if result.Source == "" {
+		err = fmt.Errorf("no exchange rate source was usable: %v", result.Errors)
+	} else {
+		err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for c, rate := range result.Rates {
+				if prev, ok := latest[c]; ok && prev.Source == result.Source && prev.Rate.Equal(rate) {
+					if err := tx.Model(&prev).Update("last_updated", result.At).Error; err != nil {
+						return fmt.Errorf("failed to confirm %s rate: %v", c, err)
+					}
+					continue
+				}
+				row := models.CurrencyRate{
+					FromCurrency: string(c),
+					ToCurrency:   string(money.MNT),
+					Rate:         rate,
+					Source:       result.Source,
+					LastUpdated:  result.At,
+				}
+				if err := tx.Create(&row).Error; err != nil {
+					return fmt.Errorf("failed to store %s rate: %v", c, err)
+				}
+				stored = true
+			}
+			return nil
+		})
+	}
+	if err == nil && stored {
+		result.Repriced, err = u.reprice(ctx)
+	}
+
+	u.mu.Lock()
+	u.last = result
+	u.mu.Unlock()
+	return result, err
+}
+
+// SetManualRate stores a rate entered by an admin and reprices packages at it, returning
+// how many packages' MNT prices changed
+func (u *ExchangeRateUpdater) SetManualRate(ctx context.Context, from, to money.Currency, rate money.Decimal) (int, error) {
+	if err := NewPricingService(u.db).SetManualExchangeRate(ctx, from, to, rate); err != nil {
+		return 0, err
+	}
+	return u.reprice(ctx)
+}
+
+// reprice recomputes package MNT prices after a new rate was stored
+func (u *ExchangeRateUpdater) reprice(ctx context.Context) (int, error) {
+	repriced, err := u.products.RepriceMNT(ctx)
+	if err != nil {
+		if errors.Is(err, ErrRateUnavailable) {
+			// A rate between other currencies; nothing to reprice at yet
+			return 0, nil
+		}
+		return repriced, fmt.Errorf("rate stored but repricing failed: %w", err)
+	}
+	if repriced > 0 {
+		logrus.Infof("Repriced %d packages at the new exchange rate", repriced)
+	}
+	return repriced, nil
+}
+
+// selectRates returns the rates of the first source that answers with a USD rate within
+// maxDeviation percent of the last known one. Other currencies outside the bounds are
+// dropped from that source's rates; the last known ones stay in use for them.
+func selectRates(ctx context.Context, sources []ExchangeRateSource, lastKnown map[money.Currency]money.Decimal, maxDeviation float64) *RateRefreshResult {
+	result := &RateRefreshResult{}
+	for _, src := range sources {
+		fetched, err := src.FetchRates(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", src.Name(), err))
+			continue
+		}
+		accepted := make(map[money.Currency]money.Decimal)
+		for _, c := range money.Currencies {
+			rate, ok := fetched[c]
+			if !ok {
+				continue
+			}
+			if last, ok := lastKnown[c]; ok && maxDeviation > 0 {
+				if deviation := last.PercentChange(rate); deviation > maxDeviation || deviation < -maxDeviation {
+					result.Rejected = append(result.Rejected, RejectedRate{Source: src.Name(), Currency: c, Rate: rate, LastRate: last, DeviationPercent: deviation})
+					continue
+				}
+			}
+			accepted[c] = rate
+		}
+		if _, ok := accepted[money.USD]; !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: no acceptable USD rate", src.Name()))
+			continue
+		}
+		result.Source, result.Rates = src.Name(), accepted
+		break
+	}
+	return result
+}
+
+// Status reports the USD to MNT rate in use and whether it needs attention
+func (u *ExchangeRateUpdater) Status(ctx context.Context) ExchangeRateStatus {
+	var current *ExchangeRate
+	if rate, err := NewPricingService(u.db).GetRate(ctx, money.USD, money.MNT); err == nil {
+		current = &rate
+	}
+	u.mu.Lock()
+	last := u.last
+	u.mu.Unlock()
+	return rateStatus(current, u.sourceNames(), u.maxAge(), u.now(), last)
+}
+
+func rateStatus(current *ExchangeRate, sources []string, maxAge time.Duration, now time.Time, last *RateRefreshResult) ExchangeRateStatus {
+	status := ExchangeRateStatus{Sources: sources, Current: current, LastRefresh: last, Alerts: []string{}}
+	if current == nil {
+		status.Alerts = append(status.Alerts, "No USD/MNT exchange rate is available; MNT prices cannot be calculated and orders are refused")
+		return status
+	}
+	if age := now.Sub(current.AsOf); age > maxAge {
+		status.Stale = true
+		status.Alerts = append(status.Alerts, fmt.Sprintf("USD/MNT rate %s from %s is stale: last updated %s (%s ago)",
+			current.Rate, current.Source, current.AsOf.Format(time.RFC3339), age.Round(time.Minute)))
+	}
+	if len(sources) > 0 && current.Source != sources[0] {
+		status.Fallback = true
+		status.Alerts = append(status.Alerts, fmt.Sprintf("USD/MNT rate %s comes from %s, not the primary source %s", current.Rate, current.Source, sources[0]))
+	}
+	if last != nil {
+		for _, e := range last.Errors {
+			status.Alerts = append(status.Alerts, "Last refresh: "+e)
+		}
+		for _, r := range last.Rejected {
+			status.Alerts = append(status.Alerts, fmt.Sprintf("Last refresh: rejected %s %s rate %s (%.1f%% from %s)", r.Source, r.Currency, r.Rate, r.DeviationPercent, r.LastRate))
+		}
+	}
+	return status
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRateSource struct {
+	name  string
+	rates map[money.Currency]money.Decimal
+	err   error
+}
+
+func (s *fakeRateSource) Name() string { return s.name }
+
+func (s *fakeRateSource) FetchRates(context.Context) (map[money.Currency]money.Decimal, error) {
+	return s.rates, s.err
+}
+
+func TestSelectRatesUsesFirstAcceptableSource(t *testing.T) {
+	lastKnown := map[money.Currency]money.Decimal{money.USD: money.FromInt(3450), money.CNY: money.FromInt(480)}
+	sources := []ExchangeRateSource{
+		&fakeRateSource{name: "bom", err: errors.New("timeout")},
+		// A misplaced decimal point upstream must not reprice the catalog
+		&fakeRateSource{name: "json", rates: map[money.Currency]money.Decimal{money.USD: money.FromInt(34500)}},
+		&fakeRateSource{name: "manual", rates: map[money.Currency]money.Decimal{money.USD: money.FromInt(3500), money.CNY: money.FromInt(900), money.KRW: money.MustParse("2.5")}},
+	}
+
+	result := selectRates(context.Background(), sources, lastKnown, 10)
+	assert.Equal(t, "manual", result.Source)
+	assert.Equal(t, map[money.Currency]money.Decimal{money.USD: money.FromInt(3500), money.KRW: money.MustParse("2.5")}, result.Rates)
+	assert.Equal(t, []string{"bom: timeout", "json: no acceptable USD rate"}, result.Errors)
+	require.Len(t, result.Rejected, 2)
+	assert.Equal(t, "json", result.Rejected[0].Source)
+	assert.Equal(t, float64(900), result.Rejected[0].DeviationPercent)
+	assert.Equal(t, money.CNY, result.Rejected[1].Currency)
+}
+
+func TestSelectRatesWithoutBounds(t *testing.T) {
+	sources := []ExchangeRateSource{&fakeRateSource{name: "json", rates: map[money.Currency]money.Decimal{money.USD: money.FromInt(34500)}}}
+
+	result := selectRates(context.Background(), sources, map[money.Currency]money.Decimal{money.USD: money.FromInt(3450)}, 0)
+	assert.Equal(t, "json", result.Source)
+	assert.Empty(t, result.Rejected)
+
+	result = selectRates(context.Background(), []ExchangeRateSource{&fakeRateSource{name: "bom", err: errors.New("down")}}, nil, 10)
+	assert.Empty(t, result.Source)
+	assert.Nil(t, result.Rates)
+}
+
+func TestRateStatus(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	sources := []string{"bom", "json", "manual"}
+
+	status := rateStatus(nil, sources, 24*time.Hour, now, nil)
+	assert.Len(t, status.Alerts, 1)
+
+	fresh := &ExchangeRate{From: money.USD, To: money.MNT, Rate: money.FromInt(3450), Source: "bom", AsOf: now.Add(-time.Hour)}
+	status = rateStatus(fresh, sources, 24*time.Hour, now, nil)
+	assert.False(t, status.Stale)
+	assert.False(t, status.Fallback)
+	assert.Empty(t, status.Alerts)
+
+	old := &ExchangeRate{From: money.USD, To: money.MNT, Rate: money.FromInt(3450), Source: "json", AsOf: now.Add(-48 * time.Hour)}
+	last := &RateRefreshResult{Source: "json", Errors: []string{"bom: timeout"}}
+	status = rateStatus(old, sources, 24*time.Hour, now, last)
+	assert.True(t, status.Stale)
+	assert.True(t, status.Fallback)
+	assert.Len(t, status.Alerts, 3)
+	assert.Equal(t, "Last refresh: bom: timeout", status.Alerts[2])
+}
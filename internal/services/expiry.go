@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	orderExpirySettingKey     = "order_expiry_minutes"
+	defaultOrderExpiryMinutes = 30
+)
+
+// ErrOrderExpired is returned when paying for an order whose payment window has passed
+var ErrOrderExpired = errors.New("order has expired")
+
+// GetOrderExpiryWindow gets the payment window for new orders from settings
+func (o *OrderService) GetOrderExpiryWindow(ctx context.Context) time.Duration {
+	var setting models.AdminSetting
+	if err := o.db.WithContext(ctx).Where("setting_key = ?", orderExpirySettingKey).First(&setting).Error; err == nil {
+		if minutes, err := strconv.Atoi(setting.SettingValue); err == nil && minutes > 0 {
+			return time.Duration(minutes) * time.Minute
+		}
+	}
+	// Default expiry window if not set
+	return defaultOrderExpiryMinutes * time.Minute
+}
+
+// ExpireOrder cancels the order's QPay invoice and moves it to expired. A payment QPay
+// received before the deadline is settled instead, so a paying customer is never expired.
+func (o *OrderService) ExpireOrder(ctx context.Context, order *models.Order, actor string) (expired bool, err error) {
+	if order.QPayInvoiceID != "" {
+		check, err := o.qpayService.CheckPayment(ctx, order.QPayInvoiceID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check payment before expiry: %v", err)
+		}
+		if check.Data.PaymentStatus == "PAID" {
+			return false, o.settleFromCheck(ctx, order, check, actor)
+		}
+		if err := o.cancelUnpaidInvoice(ctx, order); err != nil {
+			return false, err
+		}
+	}
+	if err := o.TransitionOrder(ctx, order, models.OrderStatusExpired, actor, "payment window elapsed"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// OrderExpirySweeper periodically expires unpaid orders whose payment window has passed
+type OrderExpirySweeper struct {
+	db           *gorm.DB
+	orderService *OrderService
+	config       config.OrderExpiryConfig
+}
+
+func NewOrderExpirySweeper(db *gorm.DB, orderService *OrderService, cfg config.OrderExpiryConfig) *OrderExpirySweeper {
+	return &OrderExpirySweeper{
+		db:           db,
+		orderService: orderService,
+		config:       cfg,
+	}
+}
+
+// Run sweeps every interval until ctx is cancelled
+func (s *OrderExpirySweeper) Run(ctx context.Context) {
+	if s.config.SweepInterval <= 0 {
+		logrus.Warn("Order expiry sweeper disabled: ORDER_EXPIRY_SWEEP_INTERVAL must be positive")
+		return
+	}
+	interval := time.Duration(s.config.SweepInterval) * time.Second
+	logrus.Infof("Order expiry sweeper started (interval %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Order expiry sweeper stopped")
+			return
+		case <-ticker.C:
+			s.SweepOnce(ctx)
+		}
+	}
+}
+
+// SweepOnce expires one batch of overdue orders
+func (s *OrderExpirySweeper) SweepOnce(ctx context.Context) {
+	var orders []models.Order
+	if err := s.db.WithContext(ctx).Where("status IN ? AND expires_at < ?",
+		[]models.OrderStatus{models.OrderStatusPending, models.OrderStatusAwaitingPayment}, time.Now()).
+		Order("expires_at ASC").
+		Limit(s.config.BatchSize).
+		Find(&orders).Error; err != nil {
+		logrus.Errorf("Order expiry sweeper: failed to load overdue orders: %v", err)
+		return
+	}
+
+	for i := range orders {
+		if ctx.Err() != nil {
+			return
+		}
+		order := &orders[i]
+		opCtx, cancel := workContext(ctx, backgroundOpTimeout)
+		expired, err := s.orderService.ExpireOrder(opCtx, order, ActorSystem)
+		cancel()
+		switch {
+		case err != nil:
+			logrus.Warnf("Order expiry sweeper: order %s: %v", order.OrderNumber, err)
+		case expired:
+			logrus.Infof("Order %s expired", order.OrderNumber)
+		default:
+			logrus.Infof("Order %s was paid before expiry and has been settled", order.OrderNumber)
+		}
+	}
+}
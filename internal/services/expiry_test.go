@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overdue moves an order's payment window into the past
+func (e *orderTestEnv) overdue(t *testing.T, orderID interface{}) {
+	t.Helper()
+	require.NoError(t, e.db.Model(&models.Order{}).Where("id = ?", orderID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+}
+
+func newTestSweeper(env *orderTestEnv) *OrderExpirySweeper {
+	return NewOrderExpirySweeper(env.db, env.orders, config.OrderExpiryConfig{SweepInterval: 60, BatchSize: 10})
+}
+
+func TestOrderExpiryWindowSetting(t *testing.T) {
+	env := newOrderTestEnv(t)
+	require.NoError(t, env.db.Create(&models.AdminSetting{SettingKey: orderExpirySettingKey, SettingValue: "45"}).Error)
+
+	created := env.createOrder(t)
+	require.NotNil(t, created.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(45*time.Minute), *created.ExpiresAt, time.Minute)
+}
+
+func TestExpirySweeperExpiresOverdueOrder(t *testing.T) {
+	env := newOrderTestEnv(t)
+	overdue := env.createOrder(t)
+	current := env.createOrder(t)
+	env.overdue(t, overdue.ID)
+
+	newTestSweeper(env).SweepOnce(context.Background())
+
+	assert.Equal(t, models.OrderStatusExpired, env.order(t, overdue.ID).Status)
+	assert.Equal(t, qpaysim.StatusCancelled, env.invoice(t, overdue.OrderNumber).Status)
+	assert.Equal(t, models.OrderStatusAwaitingPayment, env.order(t, current.ID).Status)
+	assert.Equal(t, qpaysim.StatusPending, env.invoice(t, current.OrderNumber).Status)
+}
+
+func TestExpirySweeperSettlesPaymentRacingExpiry(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	// Paid just before the deadline; the callback has not been processed yet
+	inv := env.pay(t, created.OrderNumber, 0)
+	env.overdue(t, created.ID)
+
+	newTestSweeper(env).SweepOnce(context.Background())
+
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+	assert.Equal(t, qpaysim.StatusPaid, env.invoice(t, created.OrderNumber).Status)
+	assert.Len(t, env.orderJobs(t, created.ID), 1)
+
+	// The callback then arrives as a duplicate of the settlement
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), callback(t, inv, qpaysim.StatusPaid), nil), ErrDuplicateWebhook)
+}
+
+func TestInitiatePaymentAfterExpiry(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	env.overdue(t, created.ID)
+
+	_, err := env.orders.InitiatePayment(context.Background(), created.OrderNumber)
+	assert.ErrorIs(t, err, ErrOrderExpired)
+	assert.Equal(t, models.OrderStatusExpired, env.order(t, created.ID).Status)
+	assert.Equal(t, qpaysim.StatusCancelled, env.invoice(t, created.OrderNumber).Status)
+	assert.Len(t, env.sim.Invoices(), 1, "no new invoice for an expired order")
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation names used to inject FakeESIMProvider failures
+const (
+	FakeOpGetSKUList          = "get_sku_list"
+	FakeOpGetSKUsByContinent  = "get_skus_by_continent"
+	FakeOpGetSKUByID          = "get_sku_by_id"
+	FakeOpGetPackagesBySKU    = "get_packages_by_sku"
+	FakeOpGetPackagesDetailed = "get_packages_detailed"
+	FakeOpGetPackagesRaw      = "get_packages_raw"
+	FakeOpCreateOrder         = "create_order"
+	FakeOpGetOrderInfo        = "get_order_info"
+	FakeOpSendPDFEmail        = "send_pdf_email"
+)
+
+// FakeProviderOptions configures an in-process FakeESIMProvider
+type FakeProviderOptions struct {
+	// Catalog lists the SKUs with their packages; DefaultFakeCatalog is used when empty
+	Catalog []RoamWiFiPackagesResponse
+	// Latency is added to every call
+	Latency time.Duration
+	// FailureRate (0..1) makes any call fail at random, on top of injected failures
+	FailureRate float64
+}
+
+// FakeESIMProvider implements ESIMProvider in memory. Orders and emails are recorded so
+// tests can assert on them, and failures can be injected per operation.
+type FakeESIMProvider struct {
+	mu       sync.Mutex
+	opts     FakeProviderOptions
+	catalog  map[string]RoamWiFiPackagesResponse
+	order    []string // SKU IDs in catalog order
+	failures map[string]*fakeFailure
+	orders   map[string]OrderRequest
+	emails   []FakeSentEmail
+	rng      *rand.Rand
+	nextID   int
+}
+
+type fakeFailure struct {
+	err       error
+	remaining int // -1 means always
+}
+
+// FakeSentEmail records a SendPDFEmail call
+type FakeSentEmail struct {
+	OrderID string
+	Email   string
+}
+
+func NewFakeESIMProvider(opts FakeProviderOptions) *FakeESIMProvider {
+	if len(opts.Catalog) == 0 {
+		opts.Catalog = DefaultFakeCatalog()
+	}
+	f := &FakeESIMProvider{
+		opts:     opts,
+		catalog:  make(map[string]RoamWiFiPackagesResponse, len(opts.Catalog)),
+		failures: make(map[string]*fakeFailure),
+		orders:   make(map[string]OrderRequest),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, sku := range opts.Catalog {
+		id := strconv.Itoa(sku.SKUId)
+		f.catalog[id] = sku
+		f.order = append(f.order, id)
+	}
+	return f
+}
+
+// LoadFakeCatalog reads a catalog (JSON array of RoamWiFiPackagesResponse) from disk
+func LoadFakeCatalog(path string) ([]RoamWiFiPackagesResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake catalog: %v", err)
+	}
+	var catalog []RoamWiFiPackagesResponse
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse fake catalog: %v", err)
+	}
+	return catalog, nil
+}
+
+// DefaultFakeCatalog is a small catalog resembling RoamWiFi data
+func DefaultFakeCatalog() []RoamWiFiPackagesResponse {
+	pkg := func(priceID int, flows float64, unit string, days int, price float64) RoamWiFiPackage {
+		return RoamWiFiPackage{
+			APICode:  fmt.Sprintf("FAKE-%d", priceID),
+			Flows:    flows,
+			Unit:     unit,
+			Days:     days,
+			Price:    price,
+			PriceID:  priceID,
+			ShowName: fmt.Sprintf("%g%s %d Days", flows, unit, days),
+		}
+	}
+	return []RoamWiFiPackagesResponse{
+		{
+			SKUId: 9001, Display: "日本", DisplayEn: "Japan", CountryCode: "392",
+			SupportCountry: []string{"Japan"},
+			Packages:       []RoamWiFiPackage{pkg(900101, 1, "GB", 7, 4.5), pkg(900102, 5, "GB", 15, 12), pkg(900103, 10, "GB", 30, 19.9)},
+		},
+		{
+			SKUId: 9002, Display: "韩国", DisplayEn: "South Korea", CountryCode: "410",
+			SupportCountry: []string{"South Korea"},
+			Packages:       []RoamWiFiPackage{pkg(900201, 3, "GB", 7, 6), pkg(900202, 10, "GB", 30, 18)},
+		},
+		{
+			SKUId: 9003, Display: "欧洲", DisplayEn: "Europe", CountryCode: "0",
+			SupportCountry: []string{"France", "Germany", "Italy", "Spain"},
+			Packages:       []RoamWiFiPackage{pkg(900301, 5, "GB", 15, 14), pkg(900302, 20, "GB", 30, 32)},
+		},
+	}
+}
+
+// FailNext makes the next n calls of op return err
+func (f *FakeESIMProvider) FailNext(op string, n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[op] = &fakeFailure{err: err, remaining: n}
+}
+
+// FailAlways makes every call of op return err until Reset
+func (f *FakeESIMProvider) FailAlways(op string, err error) {
+	f.FailNext(op, -1, err)
+}
+
+// Reset clears injected failures, recorded orders and emails
+func (f *FakeESIMProvider) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = make(map[string]*fakeFailure)
+	f.orders = make(map[string]OrderRequest)
+	f.emails = nil
+}
+
+// Orders returns the orders created so far, keyed by order ID
+func (f *FakeESIMProvider) Orders() map[string]OrderRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]OrderRequest, len(f.orders))
+	for k, v := range f.orders {
+		out[k] = v
+	}
+	return out
+}
+
+// SentEmails returns the PDF emails sent so far
+func (f *FakeESIMProvider) SentEmails() []FakeSentEmail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeSentEmail(nil), f.emails...)
+}
+
+// begin applies latency and failure injection for op. The lock is held on success.
+func (f *FakeESIMProvider) begin(ctx context.Context, op string) error {
+	if f.opts.Latency > 0 {
+		select {
+		case <-time.After(f.opts.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	if fail, ok := f.failures[op]; ok && fail.remaining != 0 {
+		if fail.remaining > 0 {
+			fail.remaining--
+		}
+		f.mu.Unlock()
+		return fail.err
+	}
+	if f.opts.FailureRate > 0 && f.rng.Float64() < f.opts.FailureRate {
+		f.mu.Unlock()
+		return &RoamWiFiError{Endpoint: op, Code: "fake", Message: "fake provider: random failure"}
+	}
+	return nil
+}
+
+func (f *FakeESIMProvider) skuInfo(sku RoamWiFiPackagesResponse) SKUInfo {
+	return SKUInfo{SKUID: sku.SKUId, Display: sku.DisplayEn, CountryCode: sku.CountryCode}
+}
+
+func (f *FakeESIMProvider) GetSKUList(ctx context.Context) ([]SKUInfo, error) {
+	if err := f.begin(ctx, FakeOpGetSKUList); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	skus := make([]SKUInfo, 0, len(f.order))
+	for _, id := range f.order {
+		skus = append(skus, f.skuInfo(f.catalog[id]))
+	}
+	return skus, nil
+}
+
+// GetSKUsByContinent returns the catalog in order; the fake has no continent grouping
+func (f *FakeESIMProvider) GetSKUsByContinent(ctx context.Context) ([]SKUInfo, error) {
+	if err := f.begin(ctx, FakeOpGetSKUsByContinent); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	skus := make([]SKUInfo, 0, len(f.order))
+	for _, id := range f.order {
+		skus = append(skus, f.skuInfo(f.catalog[id]))
+	}
+	return skus, nil
+}
+
+func (f *FakeESIMProvider) GetSKUByID(ctx context.Context, skuID string) (*SKUInfo, error) {
+	if err := f.begin(ctx, FakeOpGetSKUByID); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	sku, ok := f.catalog[skuID]
+	if !ok {
+		return nil, fmt.Errorf("sku %s not found", skuID)
+	}
+	info := f.skuInfo(sku)
+	return &info, nil
+}
+
+func (f *FakeESIMProvider) GetPackagesBySKU(ctx context.Context, skuID string) ([]PackageInfo, error) {
+	if err := f.begin(ctx, FakeOpGetPackagesBySKU); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	sku, ok := f.catalog[skuID]
+	if !ok {
+		return nil, fmt.Errorf("sku %s not found", skuID)
+	}
+	packages := make([]PackageInfo, 0, len(sku.Packages))
+	for _, p := range sku.Packages {
+		packages = append(packages, PackageInfo{
+			PackageID:   strconv.Itoa(p.PriceID),
+			PackageName: p.ShowName,
+			DataLimit:   fmt.Sprintf("%g%s", p.Flows, p.Unit),
+			Validity:    p.Days,
+			Price:       p.Price,
+			Countries:   strings.Join(sku.SupportCountry, ","),
+		})
+	}
+	return packages, nil
+}
+
+func (f *FakeESIMProvider) GetPackagesDetailed(ctx context.Context, skuID string) (*RoamWiFiPackagesResponse, error) {
+	if err := f.begin(ctx, FakeOpGetPackagesDetailed); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	sku, ok := f.catalog[skuID]
+	if !ok {
+		return nil, fmt.Errorf("sku %s not found", skuID)
+	}
+	sku.Packages = append([]RoamWiFiPackage(nil), sku.Packages...)
+	return &sku, nil
+}
+
+func (f *FakeESIMProvider) GetPackagesRaw(ctx context.Context, skuID string) (map[string]interface{}, error) {
+	if err := f.begin(ctx, FakeOpGetPackagesRaw); err != nil {
+		return nil, err
+	}
+	sku, ok := f.catalog[skuID]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("sku %s not found", skuID)
+	}
+	data, _ := json.Marshal(sku)
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	return map[string]interface{}{"code": 0, "message": "success", "data": raw}, nil
+}
+
+func (f *FakeESIMProvider) CreateOrder(ctx context.Context, req OrderRequest) (*RoamWiFiOrderResponse, error) {
+	if err := f.begin(ctx, FakeOpCreateOrder); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	if _, ok := f.catalog[req.SKUID]; !ok {
+		return nil, &RoamWiFiError{Endpoint: FakeOpCreateOrder, Code: "404", Message: fmt.Sprintf("sku %s not found", req.SKUID), Kind: ErrProviderInvalidPackage}
+	}
+	f.nextID++
+	orderID := fmt.Sprintf("FAKE%08d", f.nextID)
+	f.orders[orderID] = req
+	return &RoamWiFiOrderResponse{
+		OrderID:        orderID,
+		Status:         "success",
+		QRCode:         "LPA:1$fake.esim.local$" + orderID,
+		ActivationCode: orderID,
+		ESIMData:       map[string]interface{}{"iccid": fmt.Sprintf("8999000000%010d", f.nextID)},
+	}, nil
+}
+
+func (f *FakeESIMProvider) GetOrderInfo(ctx context.Context, orderID string) (*OrderInfo, error) {
+	if err := f.begin(ctx, FakeOpGetOrderInfo); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	if _, ok := f.orders[orderID]; !ok {
+		return nil, fmt.Errorf("API error: order %s not found", orderID)
+	}
+	return &OrderInfo{OrderID: orderID, Status: "success"}, nil
+}
+
+func (f *FakeESIMProvider) SendPDFEmail(ctx context.Context, orderID, email string) error {
+	if err := f.begin(ctx, FakeOpSendPDFEmail); err != nil {
+		return err
+	}
+	defer f.mu.Unlock()
+	if _, ok := f.orders[orderID]; !ok {
+		return fmt.Errorf("API error: order %s not found", orderID)
+	}
+	f.emails = append(f.emails, FakeSentEmail{OrderID: orderID, Email: email})
+	return nil
+}
+
+// Status reports the fake provider as healthy unless every call is configured to fail
+func (f *FakeESIMProvider) Status() ProviderStatus {
+	return ProviderStatus{Provider: ProviderFake, Healthy: f.opts.FailureRate < 1, Available: true}
+}
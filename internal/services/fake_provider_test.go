@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderCatalog(t *testing.T) {
+	fake := NewFakeESIMProvider(FakeProviderOptions{})
+
+	skus, err := fake.GetSKUList(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, skus)
+
+	detailed, err := fake.GetPackagesDetailed(context.Background(), "9001")
+	require.NoError(t, err)
+	assert.Equal(t, 9001, detailed.SKUId)
+	assert.NotEmpty(t, detailed.Packages)
+
+	packages, err := fake.GetPackagesBySKU(context.Background(), "9001")
+	require.NoError(t, err)
+	assert.Len(t, packages, len(detailed.Packages))
+
+	_, err = fake.GetPackagesDetailed(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestFakeProviderOrdersAndEmails(t *testing.T) {
+	fake := NewFakeESIMProvider(FakeProviderOptions{})
+
+	resp, err := fake.CreateOrder(context.Background(), OrderRequest{SKUID: "9002", PackageID: "900201", CustomerEmail: "a@example.com", Quantity: 1})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.OrderID)
+
+	info, err := fake.GetOrderInfo(context.Background(), resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, resp.OrderID, info.OrderID)
+
+	require.NoError(t, fake.SendPDFEmail(context.Background(), resp.OrderID, "a@example.com"))
+	assert.Equal(t, []FakeSentEmail{{OrderID: resp.OrderID, Email: "a@example.com"}}, fake.SentEmails())
+	assert.Len(t, fake.Orders(), 1)
+}
+
+func TestFakeProviderFailureInjection(t *testing.T) {
+	fake := NewFakeESIMProvider(FakeProviderOptions{})
+	boom := errors.New("boom")
+
+	fake.FailNext(FakeOpCreateOrder, 2, boom)
+	for i := 0; i < 2; i++ {
+		_, err := fake.CreateOrder(context.Background(), OrderRequest{SKUID: "9001"})
+		assert.ErrorIs(t, err, boom)
+	}
+	_, err := fake.CreateOrder(context.Background(), OrderRequest{SKUID: "9001"})
+	assert.NoError(t, err)
+
+	fake.FailAlways(FakeOpGetSKUList, boom)
+	_, err = fake.GetSKUList(context.Background())
+	assert.ErrorIs(t, err, boom)
+
+	fake.Reset()
+	_, err = fake.GetSKUList(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, fake.Orders())
+}
+
+func TestNewESIMProvider(t *testing.T) {
+	provider, err := NewESIMProvider(&config.Config{Provider: config.ProviderConfig{Name: ProviderFake, FakeFailOps: "create_order"}})
+	require.NoError(t, err)
+	_, err = provider.CreateOrder(context.Background(), OrderRequest{SKUID: "9001"})
+	assert.Error(t, err)
+
+	provider, err = NewESIMProvider(&config.Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &RoamWiFiService{}, provider)
+
+	_, err = NewESIMProvider(&config.Config{Provider: config.ProviderConfig{Name: "other"}})
+	assert.Error(t, err)
+}
+
+func TestFakeProviderHonoursContext(t *testing.T) {
+	fake := NewFakeESIMProvider(FakeProviderOptions{Latency: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := fake.CreateOrder(ctx, OrderRequest{SKUID: "9001"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, fake.Orders())
+}
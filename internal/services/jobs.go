@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrJobNotFound is returned when a job lookup by ID fails
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobNotRetryable is returned when retrying a job that is not dead
+	ErrJobNotRetryable = errors.New("only dead jobs can be retried")
+)
+
+// permanentJobError marks a failure that retrying cannot fix; the job is dead-lettered at once
+type permanentJobError struct{ err error }
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+func permanentJobErr(err error) error { return &permanentJobError{err: err} }
+
+const (
+	// backgroundOpTimeout bounds one unit of reconciler or expiry work, or recording a job outcome
+	backgroundOpTimeout = time.Minute
+	// defaultJobTimeout applies when JOBS_TIMEOUT is not set
+	defaultJobTimeout = 2 * time.Minute
+)
+
+// workContext bounds one unit of background work. It is detached from ctx so that stopping
+// a worker lets the unit in progress finish instead of aborting it halfway.
+func workContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+// JobService stores background jobs in Postgres and exposes them to admins
+type JobService struct {
+	db     *gorm.DB
+	config config.JobsConfig
+}
+
+func NewJobService(db *gorm.DB, cfg config.JobsConfig) *JobService {
+	return &JobService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// Enqueue adds a job inside tx so it is only visible if the surrounding change commits
+func (j *JobService) Enqueue(tx *gorm.DB, jobType string, orderID *uuid.UUID, payload interface{}) (*models.Job, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %v", err)
+	}
+	job := models.Job{
+		Type:        jobType,
+		OrderID:     orderID,
+		Payload:     string(payloadBytes),
+		Status:      models.JobStatusQueued,
+		RunAt:       time.Now(),
+		MaxAttempts: j.config.MaxAttempts,
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return &job, nil
+}
+
+// ListJobs returns jobs newest first, optionally filtered by status and type
+func (j *JobService) ListJobs(ctx context.Context, page, limit int, status, jobType string) ([]models.Job, int64, error) {
+	query := j.db.WithContext(ctx).Model(&models.Job{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var jobs []models.Job
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get jobs: %v", err)
+	}
+	return jobs, total, nil
+}
+
+// GetJob returns a single job
+func (j *JobService) GetJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	if err := j.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RetryJob puts a dead job back on the queue with a fresh set of attempts
+func (j *JobService) RetryJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	job, err := j.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusDead {
+		return nil, fmt.Errorf("%w: job is %s", ErrJobNotRetryable, job.Status)
+	}
+	result := j.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, models.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusQueued,
+			"run_at":      time.Now(),
+			"attempts":    0,
+			"locked_at":   nil,
+			"finished_at": nil,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retry job: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: job changed concurrently", ErrJobNotRetryable)
+	}
+	return j.GetJob(ctx, id)
+}
+
+// jobHandler runs one job; returning an error schedules a retry
+type jobHandler func(ctx context.Context, job *models.Job) error
+
+// JobWorker claims due jobs and runs them, retrying failures with exponential backoff
+type JobWorker struct {
+	jobs     *JobService
+	handlers map[string]jobHandler
+	// onDead is called once a job of the given type has exhausted its retries
+	onDead map[string]func(ctx context.Context, job *models.Job, err error)
+}
+
+func NewJobWorker(jobs *JobService, orderService *OrderService) *JobWorker {
+	return &JobWorker{
+		jobs: jobs,
+		handlers: map[string]jobHandler{
+			models.JobTypeProvisionESIM: orderService.runProvisionJob,
+			models.JobTypeSendESIMEmail: orderService.runEmailJob,
+		},
+		onDead: map[string]func(ctx context.Context, job *models.Job, err error){
+			models.JobTypeProvisionESIM: orderService.provisionJobDead,
+		},
+	}
+}
+
+// Run polls for due jobs until ctx is cancelled
+func (w *JobWorker) Run(ctx context.Context) {
+	if w.jobs.config.PollInterval <= 0 {
+		logrus.Error("Job worker disabled: JOBS_POLL_INTERVAL must be positive; queued jobs will not run")
+		return
+	}
+	interval := time.Duration(w.jobs.config.PollInterval) * time.Second
+	logrus.Infof("Job worker started (poll interval %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Job worker stopped")
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce claims and runs one batch of due jobs
+func (w *JobWorker) RunOnce(ctx context.Context) {
+	jobs, err := w.claim(ctx)
+	if err != nil {
+		logrus.Errorf("Job worker: failed to claim jobs: %v", err)
+		return
+	}
+	for i := range jobs {
+		if ctx.Err() != nil {
+			// Unstarted jobs are picked up again once their lock times out
+			return
+		}
+		w.execute(ctx, &jobs[i])
+	}
+}
+
+// claim marks due jobs as running. SKIP LOCKED lets several workers share the table, and
+// running jobs whose lock timed out (worker crashed) are reclaimed.
+func (w *JobWorker) claim(ctx context.Context) ([]models.Job, error) {
+	cfg := w.jobs.config
+	now := time.Now()
+	staleBefore := now.Add(-time.Duration(cfg.LockTimeout) * time.Second)
+
+	var jobs []models.Job
+	err := w.jobs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.JobStatusQueued, now, models.JobStatusRunning, staleBefore).
+			Order("run_at ASC").
+			Limit(cfg.BatchSize).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = models.JobStatusRunning
+			jobs[i].LockedAt = &now
+		}
+		return tx.Model(&models.Job{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": models.JobStatusRunning, "locked_at": now}).Error
+	})
+	return jobs, err
+}
+
+// execute runs a claimed job and records the outcome. The job runs to completion (or its
+// timeout) even when the worker is being stopped, so shutdown drains it rather than
+// abandoning it halfway through a provider call.
+func (w *JobWorker) execute(ctx context.Context, job *models.Job) {
+	timeout := time.Duration(w.jobs.config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+	runCtx, cancel := workContext(ctx, timeout)
+	defer cancel()
+
+	handler, ok := w.handlers[job.Type]
+	var runErr error
+	if !ok {
+		runErr = permanentJobErr(fmt.Errorf("no handler for job type %q", job.Type))
+	} else {
+		runErr = handler(runCtx, job)
+	}
+
+	// A handler that used up its deadline must still get its outcome recorded
+	ctx, cancelSave := workContext(ctx, backgroundOpTimeout)
+	defer cancelSave()
+
+	now := time.Now()
+	job.Attempts++
+	job.LockedAt = nil
+
+	var permanent *permanentJobError
+	switch {
+	case runErr == nil:
+		job.Status = models.JobStatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+	case errors.As(runErr, &permanent) || job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobStatusDead
+		job.LastError = runErr.Error()
+		job.FinishedAt = &now
+	default:
+		job.Status = models.JobStatusQueued
+		job.LastError = runErr.Error()
+		job.RunAt = now.Add(backoffDelay(job.Attempts, w.jobs.config.BaseBackoff, w.jobs.config.MaxBackoff))
+	}
+
+	if err := w.jobs.db.WithContext(ctx).Save(job).Error; err != nil {
+		logrus.Errorf("Job worker: failed to save %s job %s: %v", job.Type, job.ID, err)
+		return
+	}
+
+	switch job.Status {
+	case models.JobStatusQueued:
+		logrus.Warnf("Job %s (%s) failed, attempt %d/%d, retrying at %s: %v",
+			job.ID, job.Type, job.Attempts, job.MaxAttempts, job.RunAt.Format(time.RFC3339), runErr)
+	case models.JobStatusDead:
+		logrus.Errorf("Job %s (%s) is dead after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+		if onDead, ok := w.onDead[job.Type]; ok {
+			onDead(ctx, job, runErr)
+		}
+	}
+}
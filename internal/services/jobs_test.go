@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// provisionJob returns the order's provisioning job
+func (e *orderTestEnv) provisionJob(t *testing.T, orderID interface{}) models.Job {
+	t.Helper()
+	var job models.Job
+	require.NoError(t, e.db.Where("order_id = ? AND type = ?", orderID, models.JobTypeProvisionESIM).First(&job).Error)
+	return job
+}
+
+// makeDue moves a queued job's next run into the past
+func (e *orderTestEnv) makeDue(t *testing.T, jobID interface{}) {
+	t.Helper()
+	require.NoError(t, e.db.Model(&models.Job{}).Where("id = ?", jobID).Update("run_at", time.Now().Add(-time.Second)).Error)
+}
+
+// declined is an error result from RoamWiFi: the order was certainly not created
+var declined = &RoamWiFiError{Endpoint: FakeOpCreateOrder, Code: "1001", Message: "system busy"}
+
+func TestProvisionJobCompletesOrder(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+
+	env.worker.RunOnce(context.Background())
+
+	order := env.order(t, created.ID)
+	assert.Equal(t, models.OrderStatusCompleted, order.Status)
+	assert.NotEmpty(t, order.RoamWiFiOrderID)
+	assert.Nil(t, order.ProvisionAttempt)
+	require.NotNil(t, order.ESIMData)
+	assert.Contains(t, *order.ESIMData, order.RoamWiFiOrderID)
+	assert.Len(t, env.provider.Orders(), 1)
+	assert.Equal(t, models.JobStatusSucceeded, env.provisionJob(t, created.ID).Status)
+
+	// The email job queued on completion runs in the next batch
+	env.worker.RunOnce(context.Background())
+	assert.Equal(t, []FakeSentEmail{{OrderID: order.RoamWiFiOrderID, Email: "customer@example.com"}}, env.provider.SentEmails())
+}
+
+func TestProvisionJobRetriesWithBackoff(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+	env.provider.FailNext(FakeOpCreateOrder, 1, declined)
+
+	env.worker.RunOnce(context.Background())
+
+	job := env.provisionJob(t, created.ID)
+	assert.Equal(t, models.JobStatusQueued, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Contains(t, job.LastError, "system busy")
+	assert.Nil(t, job.LockedAt)
+	assert.WithinDuration(t, time.Now().Add(60*time.Second), job.RunAt, 5*time.Second)
+	order := env.order(t, created.ID)
+	assert.Equal(t, models.OrderStatusProvisioning, order.Status)
+	assert.Nil(t, order.ProvisionAttempt, "a declined order may be placed again")
+
+	// Not due yet
+	env.worker.RunOnce(context.Background())
+	assert.Equal(t, 1, env.provisionJob(t, created.ID).Attempts)
+
+	env.makeDue(t, job.ID)
+	env.worker.RunOnce(context.Background())
+	assert.Equal(t, models.JobStatusSucceeded, env.provisionJob(t, created.ID).Status)
+	assert.Equal(t, models.OrderStatusCompleted, env.order(t, created.ID).Status)
+	assert.Len(t, env.provider.Orders(), 1)
+}
+
+func TestProvisionJobDeadLetterRefunds(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+	env.provider.FailAlways(FakeOpCreateOrder, declined)
+
+	for i := 0; i < 3; i++ {
+		env.makeDue(t, env.provisionJob(t, created.ID).ID)
+		env.worker.RunOnce(context.Background())
+	}
+
+	job := env.provisionJob(t, created.ID)
+	assert.Equal(t, models.JobStatusDead, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, models.OrderStatusRefunded, env.order(t, created.ID).Status)
+	assert.Equal(t, qpaysim.StatusRefunded, env.invoice(t, created.OrderNumber).Status)
+
+	// Dead jobs stay put until an admin retries them
+	_, err := env.jobs.RetryJob(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	_, err = env.jobs.RetryJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	_, err = env.jobs.RetryJob(context.Background(), job.ID)
+	assert.ErrorIs(t, err, ErrJobNotRetryable)
+}
+
+func TestProvisionJobPermanentFailureDeadLettersAtOnce(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+	env.provider.FailNext(FakeOpCreateOrder, 1, &RoamWiFiError{Endpoint: FakeOpCreateOrder, Code: "404", Message: "package not found", Kind: ErrProviderInvalidPackage})
+
+	env.worker.RunOnce(context.Background())
+
+	job := env.provisionJob(t, created.ID)
+	assert.Equal(t, models.JobStatusDead, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, models.OrderStatusRefunded, env.order(t, created.ID).Status)
+}
+
+func TestProvisionJobUnknownOutcomeNeedsAttention(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+	// The request timed out; RoamWiFi may or may not have issued the eSIM
+	env.provider.FailNext(FakeOpCreateOrder, 1, context.DeadlineExceeded)
+
+	env.worker.RunOnce(context.Background())
+
+	job := env.provisionJob(t, created.ID)
+	assert.Equal(t, models.JobStatusDead, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	order := env.order(t, created.ID)
+	assert.Equal(t, models.OrderStatusNeedsAttention, order.Status)
+	assert.NotNil(t, order.ProvisionAttempt)
+	assert.Equal(t, qpaysim.StatusPaid, env.invoice(t, created.OrderNumber).Status, "not refunded automatically")
+
+	// An admin who found no eSIM at RoamWiFi retries the job
+	_, err := env.jobs.RetryJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	env.worker.RunOnce(context.Background())
+	order = env.order(t, created.ID)
+	assert.Equal(t, models.OrderStatusCompleted, order.Status)
+	assert.Nil(t, order.ProvisionAttempt)
+	assert.Len(t, env.provider.Orders(), 1)
+}
+
+func TestProvisionJobReclaimedAfterWorkerCrash(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+	job := env.provisionJob(t, created.ID)
+
+	// A worker claimed the job and sent the RoamWiFi order, then died
+	attempted := time.Now().Add(-10 * time.Minute)
+	require.NoError(t, env.db.Model(&models.Job{}).Where("id = ?", job.ID).
+		Updates(map[string]interface{}{"status": models.JobStatusRunning, "locked_at": time.Now()}).Error)
+	require.NoError(t, env.db.Model(&models.Order{}).Where("id = ?", created.ID).
+		Updates(map[string]interface{}{"status": models.OrderStatusProvisioning, "provision_attempt": attempted}).Error)
+
+	// A fresh lock is left alone
+	env.worker.RunOnce(context.Background())
+	assert.Equal(t, models.JobStatusRunning, env.provisionJob(t, created.ID).Status)
+
+	// Once the lock times out the job is reclaimed, but never orders a second eSIM
+	require.NoError(t, env.db.Model(&models.Job{}).Where("id = ?", job.ID).
+		Update("locked_at", time.Now().Add(-time.Hour)).Error)
+	env.worker.RunOnce(context.Background())
+
+	reclaimed := env.provisionJob(t, created.ID)
+	assert.Equal(t, models.JobStatusDead, reclaimed.Status)
+	assert.Contains(t, reclaimed.LastError, "outcome of the RoamWiFi order")
+	assert.Equal(t, models.OrderStatusNeedsAttention, env.order(t, created.ID).Status)
+	assert.Empty(t, env.provider.Orders())
+	assert.Equal(t, qpaysim.StatusPaid, env.invoice(t, created.OrderNumber).Status)
+}
+
+func TestProvisionJobStoreFailureNeedsAttention(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, _ := paidOrder(t, env)
+
+	// Storing the eSIM fails after RoamWiFi issued it
+	require.NoError(t, env.db.Callback().Update().Before("gorm:update").Register("test:fail_esim_store", func(tx *gorm.DB) {
+		if values, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+			if _, ok := values["esim_data"]; ok {
+				tx.AddError(errors.New("disk full"))
+			}
+		}
+	}))
+
+	env.worker.RunOnce(context.Background())
+
+	require.Len(t, env.provider.Orders(), 1)
+	order := env.order(t, created.ID)
+	assert.Equal(t, models.OrderStatusNeedsAttention, order.Status)
+	for id := range env.provider.Orders() {
+		assert.Equal(t, id, order.RoamWiFiOrderID, "the issued order is kept for the review")
+	}
+	assert.Equal(t, models.JobStatusDead, env.provisionJob(t, created.ID).Status)
+	assert.Equal(t, qpaysim.StatusPaid, env.invoice(t, created.OrderNumber).Status, "not refunded automatically")
+}
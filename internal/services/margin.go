@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBelowMinimumMargin is wrapped by MarginViolationError
+	ErrBelowMinimumMargin = errors.New("price is below the minimum margin")
+	// ErrInvalidMarginPolicy is returned for a negative margin or an unknown action
+	ErrInvalidMarginPolicy = errors.New("invalid margin policy")
+	// ErrMarginPolicyNotFound is returned when deleting a SKU policy that does not exist
+	ErrMarginPolicyNotFound = errors.New("margin policy not found")
+	// ErrPricingAlertNotFound is returned when a pricing alert does not exist
+	ErrPricingAlertNotFound = errors.New("pricing alert not found")
+)
+
+// Used when no global margin policy has been saved: never sell below the provider price
+const (
+	defaultMinMarginPercent = 0.0
+	defaultMarginAction     = models.MarginActionReprice
+)
+
+// Price sources set by pricing rules and the margin policy, next to base, markup and override
+const (
+	priceSourceRule        = "rule"
+	priceSourceMarginFloor = "margin_floor"
+)
+
+// Actors recorded when a pricing alert is resolved automatically
+const (
+	alertResolvedBySync         = "sync"
+	alertResolvedByAdminEdit    = "admin_edit"
+	alertResolvedByPolicyChange = "policy_change"
+)
+
+// MarginViolationError is returned when an admin edit would price a package below its
+// minimum margin
+type MarginViolationError struct {
+	PriceUSD         money.Decimal
+	MinPriceUSD      money.Decimal
+	MinMarginPercent float64
+}
+
+func (e *MarginViolationError) Error() string {
+	return fmt.Sprintf("price %s USD is below the minimum of %s USD (%.1f%% margin over the provider price)", e.PriceUSD.StringFixed(2), e.MinPriceUSD.StringFixed(2), e.MinMarginPercent)
+}
+
+func (e *MarginViolationError) Unwrap() error { return ErrBelowMinimumMargin }
+
+// minPriceUSD is the lowest price meeting the policy, rounded up to the cent
+func minPriceUSD(policy models.MarginPolicy, providerPrice money.Decimal) money.Decimal {
+	return providerPrice.AddPercent(policy.MinMarginPercent).Ceil(money.USD.SettlementPlaces())
+}
+
+// belowMargin reports whether price is under the policy's margin
+func belowMargin(policy models.MarginPolicy, providerPrice, price money.Decimal) bool {
+	return price.LessThan(providerPrice.AddPercent(policy.MinMarginPercent))
+}
+
+// configuredPrice returns the price the admin configured for a package: its override,
+// else the provider price with its markup rounded up to the cent, else the price of the
+// pricing rule that matched it, else the provider price
+func configuredPrice(pp *models.PackagePrice, rule *models.PricingRule) (money.Decimal, string) {
+	switch {
+	case pp.OverridePriceUSD != nil:
+		return *pp.OverridePriceUSD, "override"
+	case pp.MarkupPercent != nil:
+		return pp.RawProviderPrice.AddPercent(*pp.MarkupPercent).Ceil(money.USD.SettlementPlaces()), "markup"
+	case rule != nil:
+		return rulePrice(*rule, pp.RawProviderPrice), priceSourceRule
+	default:
+		return pp.RawProviderPrice, "base"
+	}
+}
+
+// priceWithMargin sets the effective USD price of pp from its configured price and applies
+// the margin policy, repricing or deactivating it when the price is too low. A package the
+// policy deactivated earlier is reactivated first. It returns the violation found, if any.
+func priceWithMargin(pp *models.PackagePrice, policy models.MarginPolicy, rule *models.PricingRule) *models.PricingAlert {
+	if !pp.Active && pp.InactiveReason == models.PackageInactiveMargin {
+		pp.Active = true
+		pp.InactiveReason = ""
+	}
+	price, source := configuredPrice(pp, rule)
+	pp.EffectivePriceUSD = price
+	pp.PriceSource = source
+	pp.PricingRuleID = nil
+	if source == priceSourceRule {
+		pp.PricingRuleID = &rule.ID
+	}
+
+	if !belowMargin(policy, pp.RawProviderPrice, price) {
+		return nil
+	}
+	minPrice := minPriceUSD(policy, pp.RawProviderPrice)
+
+	alert := &models.PricingAlert{
+		SKUID:              pp.SKUID,
+		ProviderPriceID:    pp.ProviderPriceID,
+		APICode:            pp.APICode,
+		ShowName:           pp.ShowName,
+		ProviderPriceUSD:   pp.RawProviderPrice,
+		ConfiguredPriceUSD: price,
+		MinPriceUSD:        minPrice,
+		MinMarginPercent:   policy.MinMarginPercent,
+	}
+	if !pp.RawProviderPrice.IsZero() {
+		alert.MarginPercent = pp.RawProviderPrice.PercentChange(price)
+	}
+	if policy.Action == models.MarginActionDeactivate {
+		pp.Active = false
+		pp.InactiveReason = models.PackageInactiveMargin
+		alert.Action = "deactivated"
+	} else {
+		pp.EffectivePriceUSD = minPrice
+		pp.PriceSource = priceSourceMarginFloor
+		alert.Action = "repriced"
+	}
+	return alert
+}
+
+// checkMargin rejects an admin edit that would price pp below its minimum margin
+func checkMargin(pp *models.PackagePrice, policy models.MarginPolicy, rule *models.PricingRule) error {
+	price, _ := configuredPrice(pp, rule)
+	if belowMargin(policy, pp.RawProviderPrice, price) {
+		return &MarginViolationError{PriceUSD: price, MinPriceUSD: minPriceUSD(policy, pp.RawProviderPrice), MinMarginPercent: policy.MinMarginPercent}
+	}
+	return nil
+}
+
+func validMarginPolicy(minMarginPercent float64, action string) bool {
+	return minMarginPercent >= 0 && minMarginPercent <= 1000 &&
+		(action == models.MarginActionReprice || action == models.MarginActionDeactivate)
+}
+
+// marginPolicyFor returns the SKU's policy, else the global policy, else the default
+func (p *ProductService) marginPolicyFor(ctx context.Context, skuID string) (models.MarginPolicy, error) {
+	var policies []models.MarginPolicy
+	if err := p.db.WithContext(ctx).Where("sku_id IN ?", []string{skuID, ""}).Find(&policies).Error; err != nil {
+		return models.MarginPolicy{}, fmt.Errorf("failed to load margin policy: %v", err)
+	}
+	policy := models.MarginPolicy{MinMarginPercent: defaultMinMarginPercent, Action: defaultMarginAction}
+	for _, mp := range policies {
+		if mp.SKUID == skuID || policy.ID == uuid.Nil {
+			policy = mp
+		}
+	}
+	return policy, nil
+}
+
+// GetMarginPolicies returns the global policy (the default when none is saved) and every
+// per-SKU policy
+func (p *ProductService) GetMarginPolicies(ctx context.Context) (models.MarginPolicy, []models.MarginPolicy, error) {
+	global, err := p.marginPolicyFor(ctx, "")
+	if err != nil {
+		return global, nil, err
+	}
+	var perSKU []models.MarginPolicy
+	if err := p.db.WithContext(ctx).Where("sku_id <> ''").Order("sku_id").Find(&perSKU).Error; err != nil {
+		return global, nil, fmt.Errorf("failed to load margin policies: %v", err)
+	}
+	return global, perSKU, nil
+}
+
+// SetMarginPolicy saves the policy of a SKU, or the global policy when skuID is empty, and
+// re-prices the stored packages it applies to
+func (p *ProductService) SetMarginPolicy(ctx context.Context, skuID string, minMarginPercent float64, action string) (*models.MarginPolicy, error) {
+	if action == "" {
+		action = defaultMarginAction
+	}
+	if !validMarginPolicy(minMarginPercent, action) {
+		return nil, ErrInvalidMarginPolicy
+	}
+
+	var policy models.MarginPolicy
+	err := p.db.WithContext(ctx).Where("sku_id = ?", skuID).First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	policy.SKUID = skuID
+	policy.MinMarginPercent = minMarginPercent
+	policy.Action = action
+	if err := p.db.WithContext(ctx).Save(&policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to save margin policy: %v", err)
+	}
+
+	if err := p.enforceMarginPolicy(ctx, skuID); err != nil {
+		return &policy, err
+	}
+	return &policy, nil
+}
+
+// DeleteMarginPolicy removes a SKU's own policy so the global policy applies again
+func (p *ProductService) DeleteMarginPolicy(ctx context.Context, skuID string) error {
+	result := p.db.WithContext(ctx).Where("sku_id = ?", skuID).Delete(&models.MarginPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMarginPolicyNotFound
+	}
+	return p.enforceMarginPolicy(ctx, skuID)
+}
+
+// enforceMarginPolicy re-prices the stored packages of a SKU, or of every SKU when skuID
+// is empty, against the pricing rules, running sales and their current policy
+func (p *ProductService) enforceMarginPolicy(ctx context.Context, skuID string) error {
+	skuIDs := []string{skuID}
+	if skuID == "" {
+		skuIDs = nil
+		if err := p.db.WithContext(ctx).Model(&models.PackagePrice{}).Distinct("sku_id").Pluck("sku_id", &skuIDs).Error; err != nil {
+			return fmt.Errorf("failed to list package SKUs: %v", err)
+		}
+	}
+
+	pricer, err := p.loadPackagePricer(ctx)
+	if err != nil {
+		return err
+	}
+	pricing := NewPricingService(p.db)
+	rate := pricing.packageRate(ctx)
+	rule := pricing.GetRoundingRule(ctx)
+	for _, id := range skuIDs {
+		policy, err := p.marginPolicyFor(ctx, id)
+		if err != nil {
+			return err
+		}
+		var prices []models.PackagePrice
+		if err := p.db.WithContext(ctx).Where("sku_id = ? AND (active = ? OR inactive_reason = ?)", id, true, models.PackageInactiveMargin).Find(&prices).Error; err != nil {
+			return fmt.Errorf("failed to load package prices: %v", err)
+		}
+		var violating []int
+		for i := range prices {
+			pp := &prices[i]
+			alert := pricer.price(pp, policy)
+			setMNTPrice(pp, rate, rule)
+			if err := p.db.WithContext(ctx).Save(pp).Error; err != nil {
+				return fmt.Errorf("update package price: %w", err)
+			}
+			if alert != nil {
+				violating = append(violating, pp.ProviderPriceID)
+				if err := p.raisePricingAlert(ctx, alert); err != nil {
+					return err
+				}
+			}
+		}
+		if err := p.resolvePricingAlerts(ctx, id, violating, alertResolvedByPolicyChange); err != nil {
+			return err
+		}
+		warnCacheInvalidation(p.cache.InvalidatePackages(ctx, id))
+	}
+	return nil
+}
+
+// setMNTPrice converts the effective USD price, and the regular price during a sale, at
+// the USD to MNT rate and rounds it by rule, recording which stored rate was used; a nil
+// rate leaves the MNT prices
+func setMNTPrice(pp *models.PackagePrice, rate *ExchangeRate, rule RoundingRule) {
+	if rate != nil && rate.Rate.Sign() > 0 {
+		r := rate.Rate
+		mnt := rule.Apply(money.New(pp.EffectivePriceUSD, money.USD).Convert(r, money.MNT).Amount)
+		pp.ExchangeRate = &r
+		pp.ExchangeRateID = rate.ID
+		pp.EffectivePriceMNT = &mnt
+		pp.RoundingRule = rule.Name
+		pp.RegularPriceMNT = nil
+		if pp.RegularPriceUSD != nil {
+			regular := rule.Apply(money.New(*pp.RegularPriceUSD, money.USD).Convert(r, money.MNT).Amount)
+			pp.RegularPriceMNT = &regular
+		}
+	}
+}
+
+// raisePricingAlert opens an alert for the package, or refreshes the open one
+func (p *ProductService) raisePricingAlert(ctx context.Context, alert *models.PricingAlert) error {
+	var open models.PricingAlert
+	err := p.db.WithContext(ctx).Where("provider_price_id = ? AND status = ?", alert.ProviderPriceID, models.PricingAlertOpen).First(&open).Error
+	switch {
+	case err == nil:
+		alert.ID = open.ID
+		alert.CreatedAt = open.CreatedAt
+		alert.Occurrences = open.Occurrences + 1
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		alert.Occurrences = 1
+	default:
+		return fmt.Errorf("failed to load pricing alert: %v", err)
+	}
+	alert.Status = models.PricingAlertOpen
+	if err := p.db.WithContext(ctx).Save(alert).Error; err != nil {
+		return fmt.Errorf("failed to save pricing alert: %v", err)
+	}
+	return nil
+}
+
+// resolvePricingAlerts closes the open alerts of a SKU except those of still violating packages
+func (p *ProductService) resolvePricingAlerts(ctx context.Context, skuID string, violating []int, by string) error {
+	query := p.db.WithContext(ctx).Model(&models.PricingAlert{}).Where("sku_id = ? AND status = ?", skuID, models.PricingAlertOpen)
+	if len(violating) > 0 {
+		query = query.Where("provider_price_id NOT IN ?", violating)
+	}
+	now := time.Now()
+	if err := query.Updates(map[string]interface{}{"status": models.PricingAlertResolved, "resolved_at": now, "resolved_by": by}).Error; err != nil {
+		return fmt.Errorf("failed to resolve pricing alerts: %v", err)
+	}
+	return nil
+}
+
+// resolvePackageAlerts closes the open alert of one package
+func (p *ProductService) resolvePackageAlerts(ctx context.Context, providerPriceID int, by string) error {
+	if err := p.db.WithContext(ctx).Model(&models.PricingAlert{}).
+		Where("provider_price_id = ? AND status = ?", providerPriceID, models.PricingAlertOpen).
+		Updates(map[string]interface{}{"status": models.PricingAlertResolved, "resolved_at": time.Now(), "resolved_by": by}).Error; err != nil {
+		return fmt.Errorf("failed to resolve pricing alerts: %v", err)
+	}
+	return nil
+}
+
+// ListPricingAlerts returns pricing alerts, most recently updated first
+func (p *ProductService) ListPricingAlerts(ctx context.Context, page, limit int, status, skuID string) ([]models.PricingAlert, int64, error) {
+	query := p.db.WithContext(ctx).Model(&models.PricingAlert{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if skuID != "" {
+		query = query.Where("sku_id = ?", skuID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var alerts []models.PricingAlert
+	offset := (page - 1) * limit
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&alerts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get pricing alerts: %v", err)
+	}
+	return alerts, total, nil
+}
+
+// ResolvePricingAlert closes an alert by hand. It is opened again if the next sync or
+// policy change still finds the package below its margin.
+func (p *ProductService) ResolvePricingAlert(ctx context.Context, id uuid.UUID, actor string) (*models.PricingAlert, error) {
+	var alert models.PricingAlert
+	if err := p.db.WithContext(ctx).First(&alert, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPricingAlertNotFound
+		}
+		return nil, err
+	}
+	if alert.Status == models.PricingAlertResolved {
+		return &alert, nil
+	}
+	now := time.Now()
+	alert.Status = models.PricingAlertResolved
+	alert.ResolvedAt = &now
+	alert.ResolvedBy = actor
+	if err := p.db.WithContext(ctx).Save(&alert).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceWithMargin(t *testing.T) {
+	policy := models.MarginPolicy{MinMarginPercent: 10, Action: models.MarginActionReprice}
+	markup := 20.0
+	pp := models.PackagePrice{SKUID: "9001", ProviderPriceID: 11, RawProviderPrice: money.FromInt(5), MarkupPercent: &markup, Active: true}
+
+	assert.Nil(t, priceWithMargin(&pp, policy, nil))
+	assert.Equal(t, money.FromInt(6), pp.EffectivePriceUSD)
+	assert.Equal(t, "markup", pp.PriceSource)
+
+	// An override under provider price + 10% is raised to the floor
+	override := money.MustParse("5.2")
+	pp.OverridePriceUSD = &override
+	alert := priceWithMargin(&pp, policy, nil)
+	require.NotNil(t, alert)
+	assert.Equal(t, "repriced", alert.Action)
+	assert.Equal(t, money.MustParse("5.5"), alert.MinPriceUSD)
+	assert.Equal(t, override, alert.ConfiguredPriceUSD)
+	assert.Equal(t, 4.0, alert.MarginPercent)
+	assert.Equal(t, money.MustParse("5.5"), pp.EffectivePriceUSD)
+	assert.Equal(t, priceSourceMarginFloor, pp.PriceSource)
+	assert.True(t, pp.Active)
+
+	policy.Action = models.MarginActionDeactivate
+	alert = priceWithMargin(&pp, policy, nil)
+	require.NotNil(t, alert)
+	assert.Equal(t, "deactivated", alert.Action)
+	assert.False(t, pp.Active)
+	assert.Equal(t, models.PackageInactiveMargin, pp.InactiveReason)
+
+	// Fixing the price sells the package again
+	override = money.FromInt(7)
+	assert.Nil(t, priceWithMargin(&pp, policy, nil))
+	assert.True(t, pp.Active)
+	assert.Empty(t, pp.InactiveReason)
+	assert.Equal(t, money.FromInt(7), pp.EffectivePriceUSD)
+
+	// Packages the provider removed stay inactive
+	pp.Active = false
+	pp.InactiveReason = models.PackageInactiveRemoved
+	assert.Nil(t, priceWithMargin(&pp, policy, nil))
+	assert.False(t, pp.Active)
+}
+
+func TestCheckMargin(t *testing.T) {
+	policy := models.MarginPolicy{MinMarginPercent: 15, Action: models.MarginActionReprice}
+	markup := 10.0
+	pp := models.PackagePrice{RawProviderPrice: money.MustParse("3.33"), MarkupPercent: &markup}
+
+	err := checkMargin(&pp, policy, nil)
+	var violation *MarginViolationError
+	require.True(t, errors.As(err, &violation))
+	assert.ErrorIs(t, err, ErrBelowMinimumMargin)
+	assert.Equal(t, money.MustParse("3.83"), violation.MinPriceUSD)
+
+	markup = 15
+	assert.NoError(t, checkMargin(&pp, policy, nil))
+
+	// Without a policy packages may not be sold below the provider price
+	override := money.FromInt(3)
+	pp.OverridePriceUSD = &override
+	assert.Error(t, checkMargin(&pp, models.MarginPolicy{Action: defaultMarginAction}, nil))
+}
+
+func TestValidMarginPolicy(t *testing.T) {
+	assert.True(t, validMarginPolicy(0, models.MarginActionReprice))
+	assert.True(t, validMarginPolicy(25, models.MarginActionDeactivate))
+	assert.False(t, validMarginPolicy(-1, models.MarginActionReprice))
+	assert.False(t, validMarginPolicy(10, "ignore"))
+}
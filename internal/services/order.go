@@ -1,20 +1,34 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"esim-platform/internal/models"
+	"esim-platform/internal/money"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+// ErrPackageUnavailable is returned when ordering a package that is not for sale, because
+// the provider dropped it or the margin policy deactivated it
+var ErrPackageUnavailable = errors.New("package is not available for sale")
+
+// ErrProvisionNeedsAttention is wrapped by provisioning failures after which RoamWiFi may have
+// issued an eSIM. The order goes to needs_attention for manual review and is never refunded
+// automatically.
+var ErrProvisionNeedsAttention = errors.New("eSIM provisioning needs manual review")
+
 type OrderService struct {
-	db              *gorm.DB
-	roamWiFiService *RoamWiFiService
-	qpayService     *QPayService
+	db          *gorm.DB
+	provider    ESIMProvider
+	qpayService *QPayService
+	jobs        *JobService
 }
 
 type CreateOrderRequest struct {
@@ -24,15 +38,13 @@ type CreateOrderRequest struct {
 	CustomerEmail   string     `json:"customer_email" binding:"required,email"`
 	CustomerPhone   string     `json:"customer_phone"`
 	UserID          *uuid.UUID `json:"user_id"`
-	// CustomPriceUSD optional manual USD override of package effective price
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
 }
 
 type OrderResponse struct {
 	ID            uuid.UUID            `json:"id"`
 	OrderNumber   string               `json:"order_number"`
-	Status        string               `json:"status"`
-	Amount        float64              `json:"amount"`
+	Status        models.OrderStatus   `json:"status"`
+	Amount        money.Decimal        `json:"amount"`
 	Currency      string               `json:"currency"`
 	CustomerEmail string               `json:"customer_email"`
 	CustomerPhone string               `json:"customer_phone"`
@@ -40,6 +52,7 @@ type OrderResponse struct {
 	PackagePrice  *models.PackagePrice `json:"package_price,omitempty"`
 	PaymentURL    string               `json:"payment_url,omitempty"`
 	QRCode        string               `json:"qr_code,omitempty"`
+	ExpiresAt     *time.Time           `json:"expires_at,omitempty"`
 	CreatedAt     time.Time            `json:"created_at"`
 }
 
@@ -54,19 +67,29 @@ type PaymentInitiationResponse struct {
 	InvoiceID   string `json:"invoice_id"`
 }
 
-func NewOrderService(db *gorm.DB, roamWiFiService *RoamWiFiService, qpayService *QPayService) *OrderService {
+func NewOrderService(db *gorm.DB, provider ESIMProvider, qpayService *QPayService, jobs *JobService) *OrderService {
 	return &OrderService{
-		db:              db,
-		roamWiFiService: roamWiFiService,
-		qpayService:     qpayService,
+		db:          db,
+		provider:    provider,
+		qpayService: qpayService,
+		jobs:        jobs,
 	}
 }
 
 // CreateOrder creates a new order and initiates payment
-func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, error) {
+func (o *OrderService) CreateOrder(ctx context.Context, req CreateOrderRequest) (*OrderResponse, error) {
+	// Don't take payment for an eSIM the provider cannot deliver right now
+	if status := o.provider.Status(); !status.Available {
+		retryAfter := time.Minute
+		if status.Circuit != nil && status.Circuit.RetryAt != nil {
+			retryAfter = time.Until(*status.Circuit.RetryAt)
+		}
+		return nil, &CircuitOpenError{Upstream: status.Provider, RetryAfter: retryAfter}
+	}
+
 	// Get product information
 	var product models.Product
-	if err := o.db.First(&product, req.ProductID).Error; err != nil {
+	if err := o.db.WithContext(ctx).First(&product, req.ProductID).Error; err != nil {
 		return nil, fmt.Errorf("product not found: %v", err)
 	}
 
@@ -74,13 +97,13 @@ func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, erro
 	var selectedPackage *models.PackagePrice
 	if req.PackagePriceID != nil {
 		var pp models.PackagePrice
-		if err := o.db.First(&pp, *req.PackagePriceID).Error; err != nil {
+		if err := o.db.WithContext(ctx).First(&pp, *req.PackagePriceID).Error; err != nil {
 			return nil, fmt.Errorf("package_price not found: %v", err)
 		}
 		selectedPackage = &pp
 	} else if req.ProviderPriceID != nil {
 		var pp models.PackagePrice
-		if err := o.db.Where("provider_price_id = ?", *req.ProviderPriceID).First(&pp).Error; err != nil {
+		if err := o.db.WithContext(ctx).Where("provider_price_id = ?", *req.ProviderPriceID).First(&pp).Error; err != nil {
 			return nil, fmt.Errorf("package_price not found for provider_price_id: %v", err)
 		}
 		selectedPackage = &pp
@@ -92,19 +115,27 @@ func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, erro
 	if selectedPackage != nil && selectedPackage.SKUID != product.SKUID {
 		return nil, fmt.Errorf("selected package does not belong to product sku")
 	}
+	if !selectedPackage.Active {
+		return nil, ErrPackageUnavailable
+	}
 
 	// Calculate final price: start from package effective USD price -> convert to MNT using current rate
 	pricing := NewPricingService(o.db)
-	usdToMnt, _ := pricing.GetUSDToMNTRate()
-	finalPriceUSD := selectedPackage.EffectivePriceUSD
-	if req.CustomPriceUSD != nil {
-		finalPriceUSD = *req.CustomPriceUSD
+	usdToMnt, err := pricing.GetUSDToMNTRate(ctx)
+	if err != nil {
+		// Never invoice at a made-up rate
+		return nil, err
 	}
-	finalPriceMNT := finalPriceUSD * usdToMnt
+	finalPriceUSD := selectedPackage.EffectivePriceUSD
+	// Rounded the same way as the package's listed MNT price, so the invoice matches it
+	finalPriceMNT := pricing.GetRoundingRule(ctx).Apply(money.New(finalPriceUSD, money.USD).Convert(usdToMnt, money.MNT).Amount)
 
 	// Generate order number
 	orderNumber := o.qpayService.GenerateOrderNumber()
 
+	// The price above is only honored until the order expires
+	expiresAt := time.Now().Add(o.GetOrderExpiryWindow(ctx))
+
 	// Create order in database
 	order := models.Order{
 		UserID:          req.UserID,
@@ -112,14 +143,15 @@ func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, erro
 		PackagePriceID:  &selectedPackage.ID,
 		ProviderPriceID: &selectedPackage.ProviderPriceID,
 		OrderNumber:     orderNumber,
-		Status:          "pending",
+		Status:          models.OrderStatusPending,
 		Amount:          finalPriceMNT,
 		Currency:        "MNT",
 		CustomerEmail:   req.CustomerEmail,
 		CustomerPhone:   req.CustomerPhone,
+		ExpiresAt:       &expiresAt,
 	}
 
-	if err := o.db.Create(&order).Error; err != nil {
+	if err := o.db.WithContext(ctx).Create(&order).Error; err != nil {
 		return nil, fmt.Errorf("failed to create order: %v", err)
 	}
 
@@ -128,37 +160,46 @@ func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, erro
 	invoiceDescription := fmt.Sprintf("eSIM %s - %s (%s)", product.Name, product.DataLimit, selectedPackage.ShowName)
 
 	qpayResponse, err := o.qpayService.CreateInvoice(
+		ctx,
 		orderNumber,
 		invoiceDescription,
 		req.CustomerEmail,
 		qpayAmount,
 	)
 	if err != nil {
-		// Update order status to failed
-		o.db.Model(&order).Update("status", "failed")
+		// Invoice never reached the customer, so the order cannot be paid
+		o.TransitionOrder(context.WithoutCancel(ctx), &order, models.OrderStatusCancelled, ActorSystem, fmt.Sprintf("QPay invoice creation failed: %v", err))
 		return nil, fmt.Errorf("failed to create QPay invoice: %v", err)
 	}
 
+	// The invoice exists at QPay now; record it even if the client has gone away
+	ctx = context.WithoutCancel(ctx)
+
 	// Update order with QPay invoice ID
-	o.db.Model(&order).Update("qpay_invoice_id", qpayResponse.Data.InvoiceID)
+	o.db.WithContext(ctx).Model(&order).Update("qpay_invoice_id", qpayResponse.Data.InvoiceID)
+	if err := o.TransitionOrder(ctx, &order, models.OrderStatusAwaitingPayment, ActorSystem, "QPay invoice created"); err != nil {
+		return nil, fmt.Errorf("failed to update order status: %v", err)
+	}
 
-	// Create payment transaction record
+	// Create payment transaction record. The QPay payment ID is only known once the invoice
+	// is paid; refunds are issued against it, so the invoice ID goes in the transaction data.
 	transactionData, _ := json.Marshal(map[string]interface{}{
-		"qr_code": qpayResponse.Data.QRCode,
-		"web_url": qpayResponse.Data.URLs.Web,
-		"app_url": qpayResponse.Data.URLs.App,
+		"invoice_id": qpayResponse.Data.InvoiceID,
+		"qr_code":    qpayResponse.Data.QRCode,
+		"web_url":    qpayResponse.Data.URLs.Web,
+		"app_url":    qpayResponse.Data.URLs.App,
 	})
 
 	paymentTransaction := models.PaymentTransaction{
-		OrderID:           order.ID,
-		QPayTransactionID: qpayResponse.Data.InvoiceID,
-		Amount:            finalPriceMNT,
-		Status:            "pending",
-		PaymentMethod:     "qpay",
-		TransactionData:   string(transactionData),
+		OrderID:         order.ID,
+		Amount:          finalPriceMNT,
+		Status:          "pending",
+		Type:            models.PaymentTransactionTypePayment,
+		PaymentMethod:   "qpay",
+		TransactionData: string(transactionData),
 	}
 
-	o.db.Create(&paymentTransaction)
+	o.db.WithContext(ctx).Create(&paymentTransaction)
 
 	return &OrderResponse{
 		ID:            order.ID,
@@ -172,14 +213,15 @@ func (o *OrderService) CreateOrder(req CreateOrderRequest) (*OrderResponse, erro
 		PackagePrice:  selectedPackage,
 		PaymentURL:    qpayResponse.Data.URLs.Web,
 		QRCode:        qpayResponse.Data.QRCode,
+		ExpiresAt:     order.ExpiresAt,
 		CreatedAt:     order.CreatedAt,
 	}, nil
 }
 
 // GetOrder retrieves order information
-func (o *OrderService) GetOrder(orderNumber string) (*OrderResponse, error) {
+func (o *OrderService) GetOrder(ctx context.Context, orderNumber string) (*OrderResponse, error) {
 	var order models.Order
-	if err := o.db.Preload("Product").Preload("PackagePrice").Preload("PaymentTransactions").Where("order_number = ?", orderNumber).First(&order).Error; err != nil {
+	if err := o.db.WithContext(ctx).Preload("Product").Preload("PackagePrice").Preload("PaymentTransactions").Where("order_number = ?", orderNumber).First(&order).Error; err != nil {
 		return nil, fmt.Errorf("order not found: %v", err)
 	}
 
@@ -193,6 +235,7 @@ func (o *OrderService) GetOrder(orderNumber string) (*OrderResponse, error) {
 		CustomerPhone: order.CustomerPhone,
 		Product:       order.Product,
 		PackagePrice:  order.PackagePrice,
+		ExpiresAt:     order.ExpiresAt,
 		CreatedAt:     order.CreatedAt,
 	}
 
@@ -214,32 +257,49 @@ func (o *OrderService) GetOrder(orderNumber string) (*OrderResponse, error) {
 }
 
 // InitiatePayment initiates payment for an existing order
-func (o *OrderService) InitiatePayment(orderNumber string) (*PaymentInitiationResponse, error) {
+func (o *OrderService) InitiatePayment(ctx context.Context, orderNumber string) (*PaymentInitiationResponse, error) {
 	var order models.Order
-	if err := o.db.Preload("Product").Preload("PackagePrice").Where("order_number = ?", orderNumber).First(&order).Error; err != nil {
+	if err := o.db.WithContext(ctx).Preload("Product").Preload("PackagePrice").Where("order_number = ?", orderNumber).First(&order).Error; err != nil {
 		return nil, fmt.Errorf("order not found: %v", err)
 	}
 
-	if order.Status != "pending" {
-		return nil, fmt.Errorf("order is not in pending status")
+	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusAwaitingPayment {
+		return nil, fmt.Errorf("order is not awaiting payment")
 	}
 
-	// Check if QPay invoice already exists
+	// Check if QPay invoice already exists and has been paid; settle it the way its callback would
 	if order.QPayInvoiceID != "" {
-		// Check payment status
-		paymentStatus, err := o.qpayService.CheckPayment(order.QPayInvoiceID)
-		if err == nil && paymentStatus.Data.PaymentStatus == "PAID" {
-			// Update order status
-			o.db.Model(&order).Update("status", "paid")
+		if check, err := o.verifyPayment(ctx, &order); err == nil {
+			if err := o.settleFromCheck(ctx, &order, check, ActorSystem); err != nil && !errors.Is(err, ErrDuplicateWebhook) {
+				return nil, err
+			}
 			return nil, fmt.Errorf("payment already completed")
 		}
 	}
 
+	// The locked price is no longer honored once the payment window has passed
+	if order.IsExpired(time.Now()) {
+		expired, err := o.ExpireOrder(ctx, &order, ActorSystem)
+		if err != nil {
+			return nil, err
+		}
+		if !expired {
+			return nil, fmt.Errorf("payment already completed")
+		}
+		return nil, ErrOrderExpired
+	}
+
+	// Only one invoice may be payable at a time
+	if err := o.cancelUnpaidInvoice(ctx, &order); err != nil {
+		logrus.Warnf("Order %s: %v", order.OrderNumber, err)
+	}
+
 	// Create new QPay invoice
 	qpayAmount := o.qpayService.FormatAmount(order.Amount)
 	invoiceDescription := fmt.Sprintf("eSIM %s - %s", order.Product.Name, order.Product.DataLimit)
 
 	qpayResponse, err := o.qpayService.CreateInvoice(
+		ctx,
 		orderNumber,
 		invoiceDescription,
 		order.CustomerEmail,
@@ -249,37 +309,41 @@ func (o *OrderService) InitiatePayment(orderNumber string) (*PaymentInitiationRe
 		return nil, fmt.Errorf("failed to create QPay invoice: %v", err)
 	}
 
+	// The invoice exists at QPay now; record it even if the client has gone away
+	ctx = context.WithoutCancel(ctx)
+
 	// Update order with QPay invoice ID
-	o.db.Model(&order).Update("qpay_invoice_id", qpayResponse.Data.InvoiceID)
+	o.db.WithContext(ctx).Model(&order).Update("qpay_invoice_id", qpayResponse.Data.InvoiceID)
+	if order.Status == models.OrderStatusPending {
+		if err := o.TransitionOrder(ctx, &order, models.OrderStatusAwaitingPayment, ActorSystem, "QPay invoice created"); err != nil {
+			return nil, fmt.Errorf("failed to update order status: %v", err)
+		}
+	}
 
-	// Create or update payment transaction
+	// Create or update payment transaction; the QPay payment ID is filled in on settlement
+	transactionData, _ := json.Marshal(map[string]interface{}{
+		"invoice_id": qpayResponse.Data.InvoiceID,
+		"qr_code":    qpayResponse.Data.QRCode,
+		"web_url":    qpayResponse.Data.URLs.Web,
+		"app_url":    qpayResponse.Data.URLs.App,
+	})
 	var paymentTransaction models.PaymentTransaction
-	if err := o.db.Where("order_id = ?", order.ID).First(&paymentTransaction).Error; err != nil {
+	if err := o.db.WithContext(ctx).Where("order_id = ? AND type = ?", order.ID, models.PaymentTransactionTypePayment).First(&paymentTransaction).Error; err != nil {
 		// Create new transaction
-		transactionData, _ := json.Marshal(map[string]interface{}{
-			"qr_code": qpayResponse.Data.QRCode,
-			"web_url": qpayResponse.Data.URLs.Web,
-			"app_url": qpayResponse.Data.URLs.App,
-		})
 		paymentTransaction = models.PaymentTransaction{
-			OrderID:           order.ID,
-			QPayTransactionID: qpayResponse.Data.InvoiceID,
-			Amount:            order.Amount,
-			Status:            "pending",
-			PaymentMethod:     "qpay",
-			TransactionData:   string(transactionData),
+			OrderID:         order.ID,
+			Amount:          order.Amount,
+			Status:          "pending",
+			Type:            models.PaymentTransactionTypePayment,
+			PaymentMethod:   "qpay",
+			TransactionData: string(transactionData),
 		}
-		o.db.Create(&paymentTransaction)
+		o.db.WithContext(ctx).Create(&paymentTransaction)
 	} else {
 		// Update existing transaction
-		transactionData, _ := json.Marshal(map[string]interface{}{
-			"qr_code": qpayResponse.Data.QRCode,
-			"web_url": qpayResponse.Data.URLs.Web,
-			"app_url": qpayResponse.Data.URLs.App,
-		})
-		paymentTransaction.QPayTransactionID = qpayResponse.Data.InvoiceID
+		paymentTransaction.QPayTransactionID = ""
 		paymentTransaction.TransactionData = string(transactionData)
-		o.db.Save(&paymentTransaction)
+		o.db.WithContext(ctx).Save(&paymentTransaction)
 	}
 
 	return &PaymentInitiationResponse{
@@ -290,73 +354,290 @@ func (o *OrderService) InitiatePayment(orderNumber string) (*PaymentInitiationRe
 	}, nil
 }
 
-// ProcessPaymentWebhook processes QPay webhook
-func (o *OrderService) ProcessPaymentWebhook(webhookData *QPayWebhookData) error {
+// ProcessPaymentWebhook processes a QPay webhook delivery exactly once. The delivery is stored
+// in webhook_events and handled under a row lock; redeliveries of an already handled event
+// return ErrDuplicateWebhook.
+func (o *OrderService) ProcessPaymentWebhook(ctx context.Context, webhookData *QPayWebhookData, payload []byte) error {
+	return o.processPaymentEvent(ctx, webhookData, payload, ActorQPayWebhook)
+}
+
+// processPaymentEvent is shared by the webhook and the reconciler. Both record under the same
+// event key, so a late callback for a reconciled payment is treated as a duplicate. Rejected
+// events are re-verified, since QPay may confirm a payment it could not confirm earlier.
+func (o *OrderService) processPaymentEvent(ctx context.Context, webhookData *QPayWebhookData, payload []byte, actor string) error {
+	event, err := o.recordWebhookEvent(ctx, webhookData, payload)
+	if err != nil {
+		return err
+	}
+
+	var procErr error
+	err = o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockWebhookEvent(tx, event)
+		if err != nil {
+			return err
+		}
+		switch locked.Status {
+		case models.WebhookEventReceived, models.WebhookEventFailed, models.WebhookEventRejected:
+		default:
+			return ErrDuplicateWebhook
+		}
+
+		var ignoreReason string
+		ignoreReason, procErr = o.applyPaymentWebhook(ctx, webhookData, actor)
+		return finishWebhookEvent(tx, locked, ignoreReason, procErr)
+	})
+	if err != nil {
+		return err
+	}
+	return procErr
+}
+
+// settleFromCheck applies a payment/check result as if QPay had delivered it as a callback
+func (o *OrderService) settleFromCheck(ctx context.Context, order *models.Order, check *QPayCheckPaymentResponse, actor string) error {
+	data := &QPayWebhookData{
+		InvoiceID:       order.QPayInvoiceID,
+		SenderInvoiceNo: order.OrderNumber,
+		TransactionID:   check.Data.TransactionID,
+		PaymentStatus:   check.Data.PaymentStatus,
+		Amount:          check.Data.Amount,
+		PaidAmount:      check.Data.PaidAmount,
+		PaymentDate:     check.Data.PaymentDate,
+	}
+	payload, _ := json.Marshal(check)
+	return o.processPaymentEvent(ctx, data, payload, actor)
+}
+
+// applyPaymentWebhook applies a QPay payment status to its order. Events that would move the
+// order backwards (PENDING after PAID, repeated PAID) are reported via ignoreReason instead.
+func (o *OrderService) applyPaymentWebhook(ctx context.Context, webhookData *QPayWebhookData, actor string) (ignoreReason string, err error) {
 	// Find order by order number
 	var order models.Order
-	if err := o.db.Where("order_number = ?", webhookData.SenderInvoiceNo).First(&order).Error; err != nil {
-		return fmt.Errorf("order not found: %v", err)
+	if err := o.db.WithContext(ctx).Where("order_number = ?", webhookData.SenderInvoiceNo).First(&order).Error; err != nil {
+		return "", fmt.Errorf("order not found: %v", err)
+	}
+
+	// Only orders still waiting for payment react to payment callbacks
+	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusAwaitingPayment {
+		return fmt.Sprintf("order already %s", order.Status), nil
 	}
 
-	// Update order status
+	// Only PAID and CANCELLED change the order; other statuses are not recorded unverified
 	paymentStatus := o.qpayService.GetPaymentStatus(webhookData.PaymentStatus)
-	o.db.Model(&order).Update("status", paymentStatus)
+	if paymentStatus != "paid" && paymentStatus != "cancelled" {
+		return fmt.Sprintf("QPay status %q leaves the order awaiting payment", webhookData.PaymentStatus), nil
+	}
+
+	// The callback is unsigned and only a hint: confirm the status with QPay before trusting it
+	var check *QPayCheckPaymentResponse
+	var verifyErr error
+	if paymentStatus == "paid" {
+		check, verifyErr = o.verifyPayment(ctx, &order)
+	} else {
+		check, verifyErr = o.verifyCancellation(ctx, &order)
+	}
+	if verifyErr != nil && !errors.Is(verifyErr, ErrPaymentRejected) {
+		// QPay unreachable; fail so the delivery is retried
+		return "", verifyErr
+	}
+	transactionData := map[string]interface{}{
+		"invoice_id":      order.QPayInvoiceID,
+		"callback_status": webhookData.PaymentStatus,
+	}
+	// The QPay payment ID refunds are issued against comes from QPay, never from the callback
+	amount := order.Amount
+	transactionID := ""
+	if verifyErr != nil {
+		paymentStatus = "rejected"
+		transactionData["verification_error"] = verifyErr.Error()
+	} else {
+		amount = check.Data.PaidAmount
+		transactionID = check.Data.TransactionID
+		transactionData["payment_status"] = check.Data.PaymentStatus
+		transactionData["payment_date"] = check.Data.PaymentDate
+		transactionData["paid_amount"] = check.Data.PaidAmount
+	}
+	transactionDataBytes, _ := json.Marshal(transactionData)
 
 	// Update or create payment transaction
 	var paymentTransaction models.PaymentTransaction
-	if err := o.db.Where("order_id = ?", order.ID).First(&paymentTransaction).Error; err != nil {
+	if err := o.db.WithContext(ctx).Where("order_id = ? AND type = ?", order.ID, models.PaymentTransactionTypePayment).First(&paymentTransaction).Error; err != nil {
 		// Create new transaction
-		transactionData, _ := json.Marshal(map[string]interface{}{
-			"invoice_id":     webhookData.InvoiceID,
-			"payment_date":   webhookData.PaymentDate,
-			"paid_amount":    webhookData.PaidAmount,
-			"payment_status": webhookData.PaymentStatus,
-		})
 		paymentTransaction = models.PaymentTransaction{
 			OrderID:           order.ID,
-			QPayTransactionID: webhookData.TransactionID,
-			Amount:            webhookData.Amount,
+			QPayTransactionID: transactionID,
+			Amount:            amount,
 			Status:            paymentStatus,
+			Type:              models.PaymentTransactionTypePayment,
 			PaymentMethod:     "qpay",
-			TransactionData:   string(transactionData),
+			TransactionData:   string(transactionDataBytes),
 		}
-		o.db.Create(&paymentTransaction)
+		o.db.WithContext(ctx).Create(&paymentTransaction)
 	} else {
 		// Update existing transaction
-		transactionData, _ := json.Marshal(map[string]interface{}{
-			"invoice_id":     webhookData.InvoiceID,
-			"payment_date":   webhookData.PaymentDate,
-			"paid_amount":    webhookData.PaidAmount,
-			"payment_status": webhookData.PaymentStatus,
-		})
-		paymentTransaction.QPayTransactionID = webhookData.TransactionID
+		if verifyErr == nil {
+			paymentTransaction.QPayTransactionID = transactionID
+		}
 		paymentTransaction.Status = paymentStatus
-		paymentTransaction.TransactionData = string(transactionData)
-		o.db.Save(&paymentTransaction)
+		paymentTransaction.TransactionData = string(transactionDataBytes)
+		o.db.WithContext(ctx).Save(&paymentTransaction)
+	}
+
+	if verifyErr != nil {
+		return "", verifyErr
 	}
 
-	// If payment is successful, create eSIM order with RoamWiFi
+	// Drive the order lifecycle
 	if paymentStatus == "paid" {
-		return o.createESIMOrder(&order)
+		return "", o.completePayment(ctx, &order, check, actor)
 	}
+	return "", o.TransitionOrder(ctx, &order, models.OrderStatusCancelled, actor, "QPay invoice cancelled")
+}
 
-	return nil
+// verifyPayment confirms with QPay that the order's stored invoice is paid in full.
+// Errors wrapping ErrPaymentRejected are final; any other error means QPay could not be asked.
+func (o *OrderService) verifyPayment(ctx context.Context, order *models.Order) (*QPayCheckPaymentResponse, error) {
+	check, err := o.checkInvoice(ctx, order, "PAID")
+	if err != nil {
+		return nil, err
+	}
+	expected := o.qpayService.FormatAmount(order.Amount)
+	if paid := o.qpayService.FormatAmount(check.Data.PaidAmount); !paid.Equal(expected) {
+		return nil, fmt.Errorf("%w: paid amount %s does not match order amount %s", ErrPaymentRejected, paid, expected)
+	}
+	return check, nil
 }
 
-// createESIMOrder creates eSIM order with RoamWiFi after successful payment
-func (o *OrderService) createESIMOrder(order *models.Order) error {
-	// Get product information
-	var product models.Product
-	if err := o.db.First(&product, order.ProductID).Error; err != nil {
-		return fmt.Errorf("product not found: %v", err)
+// verifyCancellation confirms with QPay that the order's stored invoice is cancelled, so it
+// can no longer be paid. Errors are reported as by verifyPayment.
+func (o *OrderService) verifyCancellation(ctx context.Context, order *models.Order) (*QPayCheckPaymentResponse, error) {
+	return o.checkInvoice(ctx, order, "CANCELLED")
+}
+
+// checkInvoice asks QPay for the order's stored invoice and rejects it unless it is in the
+// wanted status
+func (o *OrderService) checkInvoice(ctx context.Context, order *models.Order, want string) (*QPayCheckPaymentResponse, error) {
+	if order.QPayInvoiceID == "" {
+		return nil, fmt.Errorf("%w: order has no QPay invoice", ErrPaymentRejected)
+	}
+	check, err := o.qpayService.CheckPayment(ctx, order.QPayInvoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check payment with QPay: %v", err)
+	}
+	if check.Data.PaymentStatus != want {
+		return nil, fmt.Errorf("%w: QPay reports invoice %s as %q", ErrPaymentRejected, order.QPayInvoiceID, check.Data.PaymentStatus)
 	}
+	return check, nil
+}
 
-	// Load package price if present
-	var packagePrice models.PackagePrice
-	if order.PackagePriceID != nil {
-		if err := o.db.First(&packagePrice, *order.PackagePriceID).Error; err != nil {
-			return fmt.Errorf("package_price not found: %v", err)
+// completePayment marks a verified order paid and queues eSIM provisioning. The job is
+// enqueued in the same transaction, so a paid order always has a provisioning job.
+func (o *OrderService) completePayment(ctx context.Context, order *models.Order, check *QPayCheckPaymentResponse, actor string) error {
+	reason := fmt.Sprintf("QPay payment %s verified (%s)", check.Data.TransactionID, money.New(check.Data.PaidAmount, money.Currency(order.Currency)))
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := o.transitionTx(tx, order, models.OrderStatusPaid, actor, reason); err != nil {
+			return err
 		}
+		_, err := o.jobs.Enqueue(tx, models.JobTypeProvisionESIM, &order.ID, map[string]string{"order_number": order.OrderNumber})
+		return err
+	})
+}
+
+// runProvisionJob creates the eSIM with RoamWiFi for a paid order. It is safe to run again:
+// completed orders are skipped and failed attempts leave the order in provisioning.
+func (o *OrderService) runProvisionJob(ctx context.Context, job *models.Job) error {
+	if job.OrderID == nil {
+		return permanentJobErr(fmt.Errorf("provision job has no order"))
+	}
+	var order models.Order
+	if err := o.db.WithContext(ctx).First(&order, "id = ?", *job.OrderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return permanentJobErr(ErrOrderNotFound)
+		}
+		return err
+	}
+
+	switch order.Status {
+	case models.OrderStatusCompleted:
+		return nil
+	case models.OrderStatusPaid, models.OrderStatusProvisioningFailed:
+		if err := o.TransitionOrder(ctx, &order, models.OrderStatusProvisioning, ActorSystem, "provisioning eSIM with RoamWiFi"); err != nil {
+			return err
+		}
+	case models.OrderStatusNeedsAttention:
+		// Only an admin retrying the dead job gets here, after confirming with RoamWiFi that
+		// the earlier attempt issued no eSIM, so that attempt is forgotten
+		err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := o.transitionTx(tx, &order, models.OrderStatusProvisioning, ActorSystem, "provisioning retried after manual review"); err != nil {
+				return err
+			}
+			return tx.Model(&order).Update("provision_attempt", nil).Error
+		})
+		if err != nil {
+			return err
+		}
+		order.ProvisionAttempt = nil
+	case models.OrderStatusProvisioning:
+		// retry of an earlier attempt
+	default:
+		return permanentJobErr(fmt.Errorf("order %s is %s, not provisionable", order.OrderNumber, order.Status))
+	}
+
+	return o.createESIMOrder(ctx, &order)
+}
+
+// provisionJobDead gives up on provisioning: the order is marked failed and refunded, or
+// held in needs_attention when RoamWiFi may have issued an eSIM
+func (o *OrderService) provisionJobDead(ctx context.Context, job *models.Job, jobErr error) {
+	if job.OrderID == nil {
+		return
+	}
+	var order models.Order
+	if err := o.db.WithContext(ctx).First(&order, "id = ?", *job.OrderID).Error; err != nil {
+		logrus.Errorf("Provision job %s dead but order could not be loaded: %v", job.ID, err)
+		return
+	}
+	if order.Status != models.OrderStatusProvisioning {
+		return
+	}
+	if errors.Is(jobErr, ErrProvisionNeedsAttention) {
+		if err := o.TransitionOrder(ctx, &order, models.OrderStatusNeedsAttention, ActorSystem, jobErr.Error()); err != nil {
+			logrus.Errorf("Order %s: failed to mark for manual review: %v", order.OrderNumber, err)
+			return
+		}
+		logrus.Errorf("Order %s needs manual review, check RoamWiFi before retrying or refunding: %v", order.OrderNumber, jobErr)
+		return
+	}
+	if err := o.TransitionOrder(ctx, &order, models.OrderStatusProvisioningFailed, ActorSystem, jobErr.Error()); err != nil {
+		logrus.Errorf("Order %s: failed to mark provisioning failed: %v", order.OrderNumber, err)
+		return
+	}
+	o.refundFailedProvisioning(ctx, &order, jobErr)
+}
+
+// runEmailJob asks RoamWiFi to email the eSIM PDF to the customer
+func (o *OrderService) runEmailJob(ctx context.Context, job *models.Job) error {
+	if job.OrderID == nil {
+		return permanentJobErr(fmt.Errorf("email job has no order"))
+	}
+	var order models.Order
+	if err := o.db.WithContext(ctx).First(&order, "id = ?", *job.OrderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return permanentJobErr(ErrOrderNotFound)
+		}
+		return err
+	}
+	if order.CustomerEmail == "" || order.RoamWiFiOrderID == "" {
+		return permanentJobErr(fmt.Errorf("order %s has no email or RoamWiFi order", order.OrderNumber))
+	}
+	return o.provider.SendPDFEmail(ctx, order.RoamWiFiOrderID, order.CustomerEmail)
+}
+
+// createESIMOrder creates eSIM order with RoamWiFi for an order in provisioning
+func (o *OrderService) createESIMOrder(ctx context.Context, order *models.Order) error {
+	// Get product information
+	var product models.Product
+	if err := o.db.WithContext(ctx).First(&product, order.ProductID).Error; err != nil {
+		return fmt.Errorf("product not found: %v", err)
 	}
 
 	// Create order request for RoamWiFi
@@ -366,14 +647,40 @@ func (o *OrderService) createESIMOrder(order *models.Order) error {
 	}
 	orderReq := OrderRequest{SKUID: product.SKUID, PackageID: packageID, CustomerEmail: order.CustomerEmail, CustomerPhone: order.CustomerPhone, Quantity: 1}
 
+	// RoamWiFi takes no idempotency key and cannot be searched by our order, so an attempt
+	// is recorded first; a retry that finds it was never resolved must not order again
+	if order.ProvisionAttempt != nil {
+		return permanentJobErr(fmt.Errorf("%w: outcome of the RoamWiFi order attempted at %s is unknown",
+			ErrProvisionNeedsAttention, order.ProvisionAttempt.Format(time.RFC3339)))
+	}
+	attemptedAt := time.Now()
+	if err := o.db.WithContext(ctx).Model(order).Update("provision_attempt", attemptedAt).Error; err != nil {
+		return fmt.Errorf("failed to record provisioning attempt: %v", err)
+	}
+	order.ProvisionAttempt = &attemptedAt
+
 	// Create order with RoamWiFi
-	roamWiFiResponse, err := o.roamWiFiService.CreateOrder(orderReq)
+	roamWiFiResponse, err := o.provider.CreateOrder(ctx, orderReq)
 	if err != nil {
-		// Update order status to failed
-		o.db.Model(order).Update("status", "failed")
-		return fmt.Errorf("failed to create RoamWiFi order: %v", err)
+		err = fmt.Errorf("failed to create RoamWiFi order: %w", err)
+		if !providerDeclined(err) {
+			// Timed out or lost the response: an eSIM may have been issued
+			return permanentJobErr(fmt.Errorf("%w: %v", ErrProvisionNeedsAttention, err))
+		}
+		// RoamWiFi refused the order, so trying again cannot issue a second eSIM
+		if clearErr := o.db.WithContext(context.WithoutCancel(ctx)).Model(order).Update("provision_attempt", nil).Error; clearErr != nil {
+			logrus.Warnf("Order %s: failed to clear provisioning attempt: %v", order.OrderNumber, clearErr)
+		}
+		// Retrying cannot bring back a package that is gone; give up and refund now
+		if errors.Is(err, ErrProviderOutOfStock) || errors.Is(err, ErrProviderInvalidPackage) {
+			return permanentJobErr(err)
+		}
+		return err
 	}
 
+	// RoamWiFi has issued the eSIM; storing it must not be cut short by cancellation
+	ctx = context.WithoutCancel(ctx)
+
 	// Update order with RoamWiFi order ID and eSIM data
 	esimData, _ := json.Marshal(map[string]interface{}{
 		"roamwifi_order_id": roamWiFiResponse.OrderID,
@@ -382,41 +689,59 @@ func (o *OrderService) createESIMOrder(order *models.Order) error {
 		"esim_data":         roamWiFiResponse.ESIMData,
 	})
 
-	o.db.Model(order).Updates(map[string]interface{}{
-		"roamwifi_order_id": roamWiFiResponse.OrderID,
-		"esim_data":         string(esimData),
-		"status":            "completed",
-	})
+	err = o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(order).Updates(map[string]interface{}{
+			"roamwifi_order_id": roamWiFiResponse.OrderID,
+			"esim_data":         string(esimData),
+			"provision_attempt": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := o.transitionTx(tx, order, models.OrderStatusCompleted, ActorSystem, fmt.Sprintf("RoamWiFi order %s created", roamWiFiResponse.OrderID)); err != nil {
+			return err
+		}
 
-	// Send PDF email if email is provided
-	if order.CustomerEmail != "" {
-		go func() {
-			o.roamWiFiService.SendPDFEmail(roamWiFiResponse.OrderID, order.CustomerEmail)
-		}()
+		// Send PDF email if email is provided
+		if order.CustomerEmail != "" {
+			if _, err := o.jobs.Enqueue(tx, models.JobTypeSendESIMEmail, &order.ID, map[string]string{"email": order.CustomerEmail}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// RoamWiFi already issued the eSIM; keep at least its order ID for the manual review
+		// rather than refunding or ordering a second one
+		logrus.Errorf("Order %s: RoamWiFi order %s created but not stored: %v", order.OrderNumber, roamWiFiResponse.OrderID, err)
+		if saveErr := o.db.WithContext(ctx).Model(order).Update("roamwifi_order_id", roamWiFiResponse.OrderID).Error; saveErr != nil {
+			logrus.Errorf("Order %s: failed to save RoamWiFi order ID %s: %v", order.OrderNumber, roamWiFiResponse.OrderID, saveErr)
+		}
+		return permanentJobErr(fmt.Errorf("%w: RoamWiFi order %s created but eSIM data not stored: %v",
+			ErrProvisionNeedsAttention, roamWiFiResponse.OrderID, err))
 	}
 
 	return nil
 }
 
 // GetUserOrders retrieves orders for a specific user
-func (o *OrderService) GetUserOrders(userID uuid.UUID, page, limit int) ([]OrderResponse, int64, error) {
+func (o *OrderService) GetUserOrders(ctx context.Context, userID uuid.UUID, page, limit int) ([]OrderResponse, int64, error) {
 	var orders []models.Order
 	var total int64
 
 	offset := (page - 1) * limit
 
 	// Get total count
-	o.db.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total)
+	o.db.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID).Count(&total)
 
 	// Get orders with pagination
-	if err := o.db.Preload("Product").Preload("PackagePrice").Where("user_id = ?", userID).
+	if err := o.db.WithContext(ctx).Preload("Product").Preload("PackagePrice").Where("user_id = ?", userID).
 		Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to get user orders: %v", err)
 	}
 
 	var responses []OrderResponse
 	for _, order := range orders {
-		response := OrderResponse{ID: order.ID, OrderNumber: order.OrderNumber, Status: order.Status, Amount: order.Amount, Currency: order.Currency, CustomerEmail: order.CustomerEmail, CustomerPhone: order.CustomerPhone, Product: order.Product, PackagePrice: order.PackagePrice, CreatedAt: order.CreatedAt}
+		response := OrderResponse{ID: order.ID, OrderNumber: order.OrderNumber, Status: order.Status, Amount: order.Amount, Currency: order.Currency, CustomerEmail: order.CustomerEmail, CustomerPhone: order.CustomerPhone, Product: order.Product, PackagePrice: order.PackagePrice, ExpiresAt: order.ExpiresAt, CreatedAt: order.CreatedAt}
 		responses = append(responses, response)
 	}
 
@@ -424,12 +749,12 @@ func (o *OrderService) GetUserOrders(userID uuid.UUID, page, limit int) ([]Order
 }
 
 // GetAllOrders retrieves all orders with pagination (for admin)
-func (o *OrderService) GetAllOrders(page, limit int, status string) ([]OrderResponse, int64, error) {
+func (o *OrderService) GetAllOrders(ctx context.Context, page, limit int, status string) ([]OrderResponse, int64, error) {
 	var orders []models.Order
 	var total int64
 
 	offset := (page - 1) * limit
-	query := o.db.Model(&models.Order{})
+	query := o.db.WithContext(ctx).Model(&models.Order{})
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -446,7 +771,7 @@ func (o *OrderService) GetAllOrders(page, limit int, status string) ([]OrderResp
 
 	var responses []OrderResponse
 	for _, order := range orders {
-		response := OrderResponse{ID: order.ID, OrderNumber: order.OrderNumber, Status: order.Status, Amount: order.Amount, Currency: order.Currency, CustomerEmail: order.CustomerEmail, CustomerPhone: order.CustomerPhone, Product: order.Product, PackagePrice: order.PackagePrice, CreatedAt: order.CreatedAt}
+		response := OrderResponse{ID: order.ID, OrderNumber: order.OrderNumber, Status: order.Status, Amount: order.Amount, Currency: order.Currency, CustomerEmail: order.CustomerEmail, CustomerPhone: order.CustomerPhone, Product: order.Product, PackagePrice: order.PackagePrice, ExpiresAt: order.ExpiresAt, CreatedAt: order.CreatedAt}
 		responses = append(responses, response)
 	}
 
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// orderTestEnv wires an OrderService to an in-memory database, the QPay simulator and the
+// fake eSIM provider, with one product and package for sale
+type orderTestEnv struct {
+	db       *gorm.DB
+	sim      *qpaysim.Server
+	provider *FakeESIMProvider
+	jobs     *JobService
+	orders   *OrderService
+	worker   *JobWorker
+	product  models.Product
+	pkg      models.PackagePrice
+}
+
+// orderTestModels are the tables the order flow touches
+var orderTestModels = []interface{}{
+	&models.User{},
+	&models.Product{},
+	&models.PackagePrice{},
+	&models.CurrencyRate{},
+	&models.AdminSetting{},
+	&models.Order{},
+	&models.PaymentTransaction{},
+	&models.OrderStatusHistory{},
+	&models.WebhookEvent{},
+	&models.Job{},
+}
+
+func newOrderTestEnv(t *testing.T) *orderTestEnv {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, model := range orderTestModels {
+		// SQLite has no gen_random_uuid(); the BeforeCreate hooks assign IDs instead
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		stmt.Schema.FieldsWithDefaultDBValue = nil
+		for _, field := range stmt.Schema.Fields {
+			if field.DefaultValue == "gen_random_uuid()" {
+				field.DefaultValue, field.HasDefaultValue, field.DefaultValueInterface = "", false, nil
+			}
+		}
+		require.NoError(t, db.AutoMigrate(model))
+	}
+
+	sim := qpaysim.NewServer(qpaysim.Options{Username: "merchant", Password: "secret"})
+	simServer := httptest.NewServer(sim)
+	t.Cleanup(simServer.Close)
+	qpay := NewQPayService(config.QPayConfig{Endpoint: simServer.URL + "/v2", Username: "merchant", Password: "secret", InvoiceCode: "TEST"})
+
+	env := &orderTestEnv{db: db, sim: sim, provider: NewFakeESIMProvider(FakeProviderOptions{})}
+	env.jobs = NewJobService(db, config.JobsConfig{
+		PollInterval: 1, BatchSize: 10, MaxAttempts: 3, BaseBackoff: 60, MaxBackoff: 600, LockTimeout: 300, Timeout: 30,
+	})
+	env.orders = NewOrderService(db, env.provider, qpay, env.jobs)
+	env.worker = NewJobWorker(env.jobs, env.orders)
+
+	require.NoError(t, db.Create(&models.CurrencyRate{
+		FromCurrency: string(money.USD), ToCurrency: string(money.MNT), Rate: money.FromInt(3400), Source: "manual", LastUpdated: time.Now(),
+	}).Error)
+	env.product = models.Product{SKUID: "9001", Name: "Japan", DataLimit: "1GB", BasePrice: money.MustParse("4.5"), IsActive: true}
+	require.NoError(t, db.Create(&env.product).Error)
+	env.pkg = models.PackagePrice{
+		SKUID: "9001", ProviderPriceID: 900101, ShowName: "1GB 7 Days", Flows: 1, Unit: "GB", Days: 7,
+		RawProviderPrice: money.MustParse("4.5"), EffectivePriceUSD: money.MustParse("5.5"), PriceSource: "base", Active: true,
+	}
+	require.NoError(t, db.Create(&env.pkg).Error)
+	return env
+}
+
+// createOrder places an order for the environment's package
+func (e *orderTestEnv) createOrder(t *testing.T) *OrderResponse {
+	t.Helper()
+	resp, err := e.orders.CreateOrder(context.Background(), CreateOrderRequest{
+		ProductID:      e.product.ID,
+		PackagePriceID: &e.pkg.ID,
+		CustomerEmail:  "customer@example.com",
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// order reloads an order from the database
+func (e *orderTestEnv) order(t *testing.T, id interface{}) models.Order {
+	t.Helper()
+	var order models.Order
+	require.NoError(t, e.db.First(&order, "id = ?", id).Error)
+	return order
+}
+
+// invoice returns the simulator's invoice for an order
+func (e *orderTestEnv) invoice(t *testing.T, orderNumber string) *qpaysim.Invoice {
+	t.Helper()
+	inv, ok := e.sim.FindInvoice(orderNumber)
+	require.True(t, ok, "no QPay invoice for %s", orderNumber)
+	return inv
+}
+
+// pay pays an order's invoice at the simulator; amount <= 0 pays it in full
+func (e *orderTestEnv) pay(t *testing.T, orderNumber string, amount float64) *qpaysim.Invoice {
+	t.Helper()
+	inv, err := e.sim.MarkPaid(e.invoice(t, orderNumber).InvoiceID, amount)
+	require.NoError(t, err)
+	return inv
+}
+
+// callback builds the webhook QPay delivers for an invoice in the given status
+func callback(t *testing.T, inv *qpaysim.Invoice, status string) *QPayWebhookData {
+	t.Helper()
+	amount, err := money.FromFloat(inv.Amount)
+	require.NoError(t, err)
+	paid, err := money.FromFloat(inv.PaidAmount)
+	require.NoError(t, err)
+	return &QPayWebhookData{
+		InvoiceID:       inv.InvoiceID,
+		SenderInvoiceNo: inv.SenderInvoiceNo,
+		TransactionID:   inv.TransactionID,
+		PaymentStatus:   status,
+		Amount:          amount,
+		PaidAmount:      paid,
+		PaymentDate:     inv.PaymentDate,
+	}
+}
+
+// orderJobs returns the order's jobs, oldest first
+func (e *orderTestEnv) orderJobs(t *testing.T, orderID interface{}) []models.Job {
+	t.Helper()
+	var jobs []models.Job
+	require.NoError(t, e.db.Where("order_id = ?", orderID).Order("created_at ASC").Find(&jobs).Error)
+	return jobs
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"esim-platform/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Actors recorded in order_status_history for non-admin transitions
+const (
+	ActorSystem      = "system"
+	ActorQPayWebhook = "qpay_webhook"
+	ActorReconciler  = "payment_reconciler"
+	adminActorPrefix = "admin:"
+)
+
+var (
+	// ErrOrderNotFound is returned when an order lookup by ID fails
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidTransition is returned when the lifecycle does not allow a status change
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// orderTransitions lists the statuses each status may move to; terminal statuses have no entry
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending: {
+		models.OrderStatusAwaitingPayment,
+		models.OrderStatusPaid,
+		models.OrderStatusCancelled,
+		models.OrderStatusExpired,
+	},
+	models.OrderStatusAwaitingPayment: {
+		models.OrderStatusPaid,
+		models.OrderStatusCancelled,
+		models.OrderStatusExpired,
+	},
+	models.OrderStatusPaid: {
+		models.OrderStatusProvisioning,
+		models.OrderStatusRefunded,
+	},
+	models.OrderStatusProvisioning: {
+		models.OrderStatusCompleted,
+		models.OrderStatusProvisioningFailed,
+		models.OrderStatusNeedsAttention,
+	},
+	models.OrderStatusProvisioningFailed: {
+		models.OrderStatusProvisioning,
+		models.OrderStatusRefunded,
+	},
+	models.OrderStatusNeedsAttention: {
+		models.OrderStatusProvisioning,
+		models.OrderStatusCompleted,
+		models.OrderStatusRefunded,
+	},
+	models.OrderStatusCompleted: {
+		models.OrderStatusRefunded,
+	},
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to models.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminActor builds the history actor for an admin user
+func AdminActor(userID string) string {
+	return adminActorPrefix + userID
+}
+
+// TransitionOrder moves an order to a new status and records the change in order_status_history
+func (o *OrderService) TransitionOrder(ctx context.Context, order *models.Order, to models.OrderStatus, actor, reason string) error {
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return o.transitionTx(tx, order, to, actor, reason)
+	})
+}
+
+// transitionTx performs a transition inside an existing transaction. The order row is locked
+// so concurrent transitions (webhook vs admin) are serialized and validated against the
+// latest persisted status rather than the caller's copy.
+func (o *OrderService) transitionTx(tx *gorm.DB, order *models.Order, to models.OrderStatus, actor, reason string) error {
+	var current models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&current, "id = ?", order.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrderNotFound
+		}
+		return fmt.Errorf("lock order: %w", err)
+	}
+	if !CanTransition(current.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current.Status, to)
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ?", current.ID).Update("status", to).Error; err != nil {
+		return fmt.Errorf("update order status: %w", err)
+	}
+	history := models.OrderStatusHistory{
+		OrderID:    current.ID,
+		FromStatus: current.Status,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("record status history: %w", err)
+	}
+	order.Status = to
+	return nil
+}
+
+// UpdateOrderStatus applies an admin-requested status change through the order lifecycle
+func (o *OrderService) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus, actor, reason string) (*models.Order, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, status)
+	}
+	if status == models.OrderStatusRefunded {
+		// Refunds must go through QPay, not just a status change
+		return nil, fmt.Errorf("%w: use the refund endpoint to refund an order", ErrInvalidTransition)
+	}
+	if status == models.OrderStatusProvisioning {
+		// Only the provisioning job moves orders into provisioning
+		return nil, fmt.Errorf("%w: retry the order's provisioning job instead", ErrInvalidTransition)
+	}
+	var order models.Order
+	if err := o.db.WithContext(ctx).First(&order, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if status == models.OrderStatusCancelled && CanTransition(order.Status, status) {
+		if err := o.cancelUnpaidInvoice(ctx, &order); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.TransitionOrder(ctx, &order, status, actor, reason); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderStatusHistory returns the recorded transitions for an order, oldest first
+func (o *OrderService) GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	if err := o.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %v", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"esim-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to models.OrderStatus
+		allowed  bool
+	}{
+		{models.OrderStatusPending, models.OrderStatusAwaitingPayment, true},
+		{models.OrderStatusAwaitingPayment, models.OrderStatusPaid, true},
+		{models.OrderStatusPaid, models.OrderStatusProvisioning, true},
+		{models.OrderStatusProvisioning, models.OrderStatusCompleted, true},
+		{models.OrderStatusProvisioning, models.OrderStatusProvisioningFailed, true},
+		{models.OrderStatusProvisioningFailed, models.OrderStatusProvisioning, true},
+		{models.OrderStatusCompleted, models.OrderStatusRefunded, true},
+		{models.OrderStatusPaid, models.OrderStatusPaid, false},
+		{models.OrderStatusPaid, models.OrderStatusAwaitingPayment, false},
+		{models.OrderStatusCompleted, models.OrderStatusPending, false},
+		{models.OrderStatusAwaitingPayment, models.OrderStatusCompleted, false},
+		{models.OrderStatusRefunded, models.OrderStatusPaid, false},
+		{models.OrderStatusCancelled, models.OrderStatusPaid, false},
+		{models.OrderStatusExpired, models.OrderStatusAwaitingPayment, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.allowed, CanTransition(tc.from, tc.to), "%s -> %s", tc.from, tc.to)
+	}
+}
+
+func TestOrderStatusIsValid(t *testing.T) {
+	assert.True(t, models.OrderStatusProvisioningFailed.IsValid())
+	assert.False(t, models.OrderStatus("failed").IsValid())
+	assert.False(t, models.OrderStatus("unknown").IsValid())
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderChargesPackagePrice(t *testing.T) {
+	env := newOrderTestEnv(t)
+
+	// A client-supplied price is not part of the request and cannot lower the charge
+	var req CreateOrderRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"product_id":"`+env.product.ID.String()+`","package_price_id":"`+env.pkg.ID.String()+
+		`","customer_email":"customer@example.com","custom_price_usd":0.01}`), &req))
+	created, err := env.orders.CreateOrder(context.Background(), req)
+	require.NoError(t, err)
+
+	// 5.50 USD at 3400 MNT/USD
+	expected := money.FromInt(18700)
+	assert.True(t, created.Amount.Equal(expected), "amount %s", created.Amount)
+	order := env.order(t, created.ID)
+	assert.True(t, order.Amount.Equal(expected))
+	assert.Equal(t, models.OrderStatusAwaitingPayment, order.Status)
+	assert.Equal(t, env.invoice(t, created.OrderNumber).InvoiceID, order.QPayInvoiceID)
+	assert.Equal(t, float64(18700), env.invoice(t, created.OrderNumber).Amount)
+}
+
+func TestPaidCallbackIsVerifiedAgainstOrderInvoice(t *testing.T) {
+	env := newOrderTestEnv(t)
+	victim := env.createOrder(t)
+	other := env.createOrder(t)
+
+	// A genuine PAID callback of another order, replayed with this order's number
+	data := callback(t, env.pay(t, other.OrderNumber, 0), qpaysim.StatusPaid)
+	data.SenderInvoiceNo = victim.OrderNumber
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil), ErrPaymentRejected)
+
+	assert.Equal(t, models.OrderStatusAwaitingPayment, env.order(t, victim.ID).Status)
+	assert.Empty(t, env.orderJobs(t, victim.ID))
+}
@@ -1,13 +1,13 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"strconv"
 	"time"
 
 	"esim-platform/internal/models"
+	"esim-platform/internal/money"
 
 	"gorm.io/gorm"
 )
@@ -16,80 +16,71 @@ type PricingService struct {
 	db *gorm.DB
 }
 
-type ExchangeRateAPIResponse struct {
-	Result          string             `json:"result"`
-	BaseCode        string             `json:"base_code"`
-	ConversionRates map[string]float64 `json:"conversion_rates"`
-}
-
 func NewPricingService(db *gorm.DB) *PricingService {
 	return &PricingService{db: db}
 }
 
 // GetUSDToMNTRate gets the current USD to MNT exchange rate
-func (p *PricingService) GetUSDToMNTRate() (float64, error) {
-	// First try to get from database (cache)
-	var rate models.CurrencyRate
-	if err := p.db.Where("from_currency = ? AND to_currency = ?", "USD", "MNT").
-		Order("last_updated DESC").First(&rate).Error; err == nil {
-		// Check if the rate is not older than 24 hours
-		if time.Since(rate.LastUpdated) < 24*time.Hour {
-			return rate.Rate, nil
-		}
-	}
-
-	// If no recent rate found, fetch from external API or use default
-	newRate, err := p.fetchExchangeRateFromAPI()
+func (p *PricingService) GetUSDToMNTRate(ctx context.Context) (money.Decimal, error) {
+	rate, err := p.GetRate(ctx, money.USD, money.MNT)
 	if err != nil {
-		// If API fails, use a default rate or the last known rate
-		if rate.Rate > 0 {
-			return rate.Rate, nil
-		}
-		// Default fallback rate (approximate USD to MNT)
-		return 2850.0, nil
-	}
-
-	// Save the new rate to database
-	currencyRate := models.CurrencyRate{
-		FromCurrency: "USD",
-		ToCurrency:   "MNT",
-		Rate:         newRate,
-		Source:       "api",
-		LastUpdated:  time.Now(),
+		return money.Zero, err
 	}
-	p.db.Create(&currencyRate)
-
-	return newRate, nil
+	return rate.Rate, nil
 }
 
-// fetchExchangeRateFromAPI fetches exchange rate from external API
-func (p *PricingService) fetchExchangeRateFromAPI() (float64, error) {
-	// Using a free exchange rate API (you can replace with your preferred provider)
-	url := "https://api.exchangerate-api.com/v4/latest/USD"
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+// packageRate returns the USD to MNT rate package prices are converted at, or nil when
+// there is none
+func (p *PricingService) packageRate(ctx context.Context) *ExchangeRate {
+	rate, err := p.GetRate(ctx, money.USD, money.MNT)
 	if err != nil {
-		return 0, err
+		return nil
 	}
-	defer resp.Body.Close()
+	return &rate
+}
 
-	var apiResp ExchangeRateAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return 0, err
+// GetRate returns the latest stored rate from one currency to another. Without a stored
+// rate for the pair it is the inverse of the opposite pair, or derived from the MNT rates
+// of both currencies. Rates are stored by the ExchangeRateUpdater and by admins; nothing
+// is fetched here.
+func (p *PricingService) GetRate(ctx context.Context, from, to money.Currency) (ExchangeRate, error) {
+	if from == to {
+		return ExchangeRate{From: from, To: to, Rate: money.FromInt(1), Source: "identity", AsOf: time.Now()}, nil
 	}
-
-	if mntRate, exists := apiResp.ConversionRates["MNT"]; exists {
-		return mntRate, nil
+	if stored, ok := p.latestRate(ctx, from, to); ok {
+		return exchangeRateFromModel(stored), nil
 	}
+	if stored, ok := p.latestRate(ctx, to, from); ok {
+		inverse := exchangeRateFromModel(stored)
+		inverse.From, inverse.To, inverse.Rate = from, to, money.FromInt(1).Div(stored.Rate)
+		return inverse, nil
+	}
+	if from == money.MNT || to == money.MNT {
+		return ExchangeRate{}, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+	fromMNT, err := p.GetRate(ctx, from, money.MNT)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	toMNT, err := p.GetRate(ctx, to, money.MNT)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	return crossRate(fromMNT, toMNT), nil
+}
 
-	return 0, fmt.Errorf("MNT rate not found in API response")
+// latestRate returns the most recently stored rate for a currency pair
+func (p *PricingService) latestRate(ctx context.Context, from, to money.Currency) (models.CurrencyRate, bool) {
+	var rate models.CurrencyRate
+	err := p.db.WithContext(ctx).Where("from_currency = ? AND to_currency = ?", string(from), string(to)).
+		Order("last_updated DESC").First(&rate).Error
+	return rate, err == nil && rate.Rate.Sign() > 0
 }
 
 // GetDefaultProfitMargin gets the default profit margin from settings
-func (p *PricingService) GetDefaultProfitMargin() float64 {
+func (p *PricingService) GetDefaultProfitMargin(ctx context.Context) float64 {
 	var setting models.AdminSetting
-	if err := p.db.Where("setting_key = ?", "default_profit_margin").First(&setting).Error; err == nil {
+	if err := p.db.WithContext(ctx).Where("setting_key = ?", "default_profit_margin").First(&setting).Error; err == nil {
 		if margin, err := strconv.ParseFloat(setting.SettingValue, 64); err == nil {
 			return margin
 		}
@@ -99,91 +90,97 @@ func (p *PricingService) GetDefaultProfitMargin() float64 {
 }
 
 // UpdateProductPricing updates the MNT pricing for a product
-func (p *PricingService) UpdateProductPricing(productID string) error {
+func (p *PricingService) UpdateProductPricing(ctx context.Context, productID string) error {
 	var product models.Product
-	if err := p.db.First(&product, "id = ?", productID).Error; err != nil {
+	if err := p.db.WithContext(ctx).First(&product, "id = ?", productID).Error; err != nil {
 		return err
 	}
 
-	usdToMntRate, err := p.GetUSDToMNTRate()
+	usdToMntRate, err := p.GetUSDToMNTRate(ctx)
 	if err != nil {
 		return err
 	}
 
-	profitMargin := p.GetDefaultProfitMargin()
+	profitMargin := p.GetDefaultProfitMargin(ctx)
 	product.CalculateMNTPrice(usdToMntRate, profitMargin)
+	product.PriceMNT = roundMNT(product.PriceMNT, p.GetRoundingRule(ctx))
 
-	return p.db.Save(&product).Error
+	return p.db.WithContext(ctx).Save(&product).Error
 }
 
 // UpdatePackagePricing updates the MNT pricing for a package
-func (p *PricingService) UpdatePackagePricing(packageID string) error {
+func (p *PricingService) UpdatePackagePricing(ctx context.Context, packageID string) error {
 	var pkg models.Package
-	if err := p.db.First(&pkg, "id = ?", packageID).Error; err != nil {
+	if err := p.db.WithContext(ctx).First(&pkg, "id = ?", packageID).Error; err != nil {
 		return err
 	}
 
-	usdToMntRate, err := p.GetUSDToMNTRate()
+	usdToMntRate, err := p.GetUSDToMNTRate(ctx)
 	if err != nil {
 		return err
 	}
 
-	profitMargin := p.GetDefaultProfitMargin()
+	profitMargin := p.GetDefaultProfitMargin(ctx)
 	pkg.CalculateMNTPrice(usdToMntRate, profitMargin)
+	pkg.PriceMNT = roundMNT(pkg.PriceMNT, p.GetRoundingRule(ctx))
 
-	return p.db.Save(&pkg).Error
+	return p.db.WithContext(ctx).Save(&pkg).Error
 }
 
 // UpdateAllProductPricing updates pricing for all active products
-func (p *PricingService) UpdateAllProductPricing() error {
+func (p *PricingService) UpdateAllProductPricing(ctx context.Context) error {
 	var products []models.Product
-	if err := p.db.Where("is_active = ?", true).Find(&products).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("is_active = ?", true).Find(&products).Error; err != nil {
 		return err
 	}
 
-	usdToMntRate, err := p.GetUSDToMNTRate()
+	usdToMntRate, err := p.GetUSDToMNTRate(ctx)
 	if err != nil {
 		return err
 	}
 
-	profitMargin := p.GetDefaultProfitMargin()
+	profitMargin := p.GetDefaultProfitMargin(ctx)
+	rule := p.GetRoundingRule(ctx)
 
 	for i := range products {
 		products[i].CalculateMNTPrice(usdToMntRate, profitMargin)
+		products[i].PriceMNT = roundMNT(products[i].PriceMNT, rule)
 	}
 
-	return p.db.Save(&products).Error
+	return p.db.WithContext(ctx).Save(&products).Error
 }
 
 // UpdateAllPackagePricing updates pricing for all active packages
-func (p *PricingService) UpdateAllPackagePricing() error {
+func (p *PricingService) UpdateAllPackagePricing(ctx context.Context) error {
 	var packages []models.Package
-	if err := p.db.Where("is_active = ?", true).Find(&packages).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("is_active = ?", true).Find(&packages).Error; err != nil {
 		return err
 	}
 
-	usdToMntRate, err := p.GetUSDToMNTRate()
+	usdToMntRate, err := p.GetUSDToMNTRate(ctx)
 	if err != nil {
 		return err
 	}
 
-	profitMargin := p.GetDefaultProfitMargin()
+	profitMargin := p.GetDefaultProfitMargin(ctx)
+	rule := p.GetRoundingRule(ctx)
 
 	for i := range packages {
 		packages[i].CalculateMNTPrice(usdToMntRate, profitMargin)
+		packages[i].PriceMNT = roundMNT(packages[i].PriceMNT, rule)
 	}
 
-	return p.db.Save(&packages).Error
+	return p.db.WithContext(ctx).Save(&packages).Error
 }
 
-// SetManualExchangeRate allows admin to set a manual exchange rate
-func (p *PricingService) SetManualExchangeRate(rate float64) error {
+// SetManualExchangeRate allows admin to set a manual exchange rate for a currency pair
+func (p *PricingService) SetManualExchangeRate(ctx context.Context, from, to money.Currency, rate money.Decimal) error {
 	currencyRate := models.CurrencyRate{
-		FromCurrency: "USD",
-		ToCurrency:   "MNT",
+		FromCurrency: string(from),
+		ToCurrency:   string(to),
 		Rate:         rate,
 		Source:       "manual",
 		LastUpdated:  time.Now(),
 	}
-	return p.db.Create(&currencyRate).Error
+	return p.db.WithContext(ctx).Create(&currencyRate).Error
 }
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidPricingRule is returned for a rule with an unknown add-on type, a negative
+	// add-on or an empty range
+	ErrInvalidPricingRule = errors.New("invalid pricing rule")
+	// ErrPricingRuleNotFound is returned when a pricing rule does not exist
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+)
+
+// rulePrice is the provider price with the rule's add-on, rounded up to the cent
+func rulePrice(rule models.PricingRule, providerPrice money.Decimal) money.Decimal {
+	price := providerPrice.Add(rule.AddOn)
+	if rule.AddOnType == models.PricingAddOnPercent {
+		price = providerPrice.AddPercent(rule.AddOn.Float64())
+	}
+	return price.Ceil(money.USD.SettlementPlaces())
+}
+
+// packageDataMB returns the data volume of a package in MB; false when its unit is not a
+// data volume
+func packageDataMB(pp *models.PackagePrice) (float64, bool) {
+	switch strings.ToUpper(strings.TrimSpace(pp.Unit)) {
+	case "KB":
+		return pp.Flows / 1024, true
+	case "MB":
+		return pp.Flows, true
+	case "GB":
+		return pp.Flows * 1024, true
+	case "TB":
+		return pp.Flows * 1024 * 1024, true
+	}
+	return 0, false
+}
+
+// ruleMatches reports whether every condition of rule holds for pp, a package of a SKU on
+// continent
+func ruleMatches(rule models.PricingRule, pp *models.PackagePrice, continent string) bool {
+	if rule.SKUID != "" && rule.SKUID != pp.SKUID {
+		return false
+	}
+	if rule.Continent != "" && !strings.EqualFold(rule.Continent, continent) {
+		return false
+	}
+	if rule.MinDataMB != nil || rule.MaxDataMB != nil {
+		mb, ok := packageDataMB(pp)
+		if !ok || (rule.MinDataMB != nil && mb < *rule.MinDataMB) || (rule.MaxDataMB != nil && mb > *rule.MaxDataMB) {
+			return false
+		}
+	}
+	if (rule.MinDays != nil && pp.Days < *rule.MinDays) || (rule.MaxDays != nil && pp.Days > *rule.MaxDays) {
+		return false
+	}
+	if rule.MinProviderPriceUSD != nil && pp.RawProviderPrice.LessThan(*rule.MinProviderPriceUSD) {
+		return false
+	}
+	if rule.MaxProviderPriceUSD != nil && rule.MaxProviderPriceUSD.LessThan(pp.RawProviderPrice) {
+		return false
+	}
+	return true
+}
+
+func validPricingRule(rule *models.PricingRule) error {
+	switch {
+	case strings.TrimSpace(rule.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidPricingRule)
+	case rule.AddOnType != models.PricingAddOnPercent && rule.AddOnType != models.PricingAddOnFixed:
+		return fmt.Errorf("%w: add_on_type must be %s or %s", ErrInvalidPricingRule, models.PricingAddOnPercent, models.PricingAddOnFixed)
+	case rule.AddOn.Sign() < 0:
+		return fmt.Errorf("%w: add_on must not be negative", ErrInvalidPricingRule)
+	case rule.AddOnType == models.PricingAddOnPercent && rule.AddOn.Float64() > 1000:
+		return fmt.Errorf("%w: add_on is over 1000%%", ErrInvalidPricingRule)
+	case rule.MinDataMB != nil && rule.MaxDataMB != nil && *rule.MinDataMB > *rule.MaxDataMB,
+		rule.MinDays != nil && rule.MaxDays != nil && *rule.MinDays > *rule.MaxDays,
+		rule.MinProviderPriceUSD != nil && rule.MaxProviderPriceUSD != nil && rule.MaxProviderPriceUSD.LessThan(*rule.MinProviderPriceUSD):
+		return fmt.Errorf("%w: a range minimum is above its maximum", ErrInvalidPricingRule)
+	}
+	return nil
+}
+
+// pricingRules holds the active rules in priority order and the continent of each SKU
+type pricingRules struct {
+	rules      []models.PricingRule
+	continents map[string]string
+}
+
+// forPackage returns the winning rule for pp, or nil when none matches
+func (r *pricingRules) forPackage(pp *models.PackagePrice) *models.PricingRule {
+	for i := range r.rules {
+		if ruleMatches(r.rules[i], pp, r.continents[pp.SKUID]) {
+			return &r.rules[i]
+		}
+	}
+	return nil
+}
+
+// sortRules orders rules by priority; equal priorities keep their order, oldest first
+func sortRules(rules []models.PricingRule) {
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+}
+
+func (p *ProductService) loadPricingRules(ctx context.Context) (*pricingRules, error) {
+	set := &pricingRules{}
+	if err := p.db.WithContext(ctx).Where("active = ?", true).Order("priority, created_at").Find(&set.rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load pricing rules: %v", err)
+	}
+	return set, p.loadContinents(ctx, set)
+}
+
+// loadContinents looks up the continent of every SKU once a rule matches on continent
+func (p *ProductService) loadContinents(ctx context.Context, set *pricingRules) error {
+	if set.continents != nil {
+		return nil
+	}
+	for _, rule := range set.rules {
+		if rule.Continent == "" {
+			continue
+		}
+		var products []models.Product
+		if err := p.db.WithContext(ctx).Select("sku_id", "continent").Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to load SKU continents: %v", err)
+		}
+		set.continents = make(map[string]string, len(products))
+		for _, product := range products {
+			set.continents[product.SKUID] = product.Continent
+		}
+		return nil
+	}
+	return nil
+}
+
+// ListPricingRules returns every rule, active or not, in priority order
+func (p *ProductService) ListPricingRules(ctx context.Context) ([]models.PricingRule, error) {
+	var rules []models.PricingRule
+	if err := p.db.WithContext(ctx).Order("priority, created_at").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load pricing rules: %v", err)
+	}
+	return rules, nil
+}
+
+// CreatePricingRule saves a new rule and re-prices the stored packages
+func (p *ProductService) CreatePricingRule(ctx context.Context, rule *models.PricingRule) error {
+	if err := validPricingRule(rule); err != nil {
+		return err
+	}
+	rule.ID = uuid.New()
+	if err := p.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to save pricing rule: %v", err)
+	}
+	return p.enforceMarginPolicy(ctx, "")
+}
+
+// UpdatePricingRule replaces a rule's conditions and add-on and re-prices the stored
+// packages
+func (p *ProductService) UpdatePricingRule(ctx context.Context, id uuid.UUID, update models.PricingRule) (*models.PricingRule, error) {
+	if err := validPricingRule(&update); err != nil {
+		return nil, err
+	}
+	var rule models.PricingRule
+	if err := p.db.WithContext(ctx).First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPricingRuleNotFound
+		}
+		return nil, err
+	}
+	update.ID, update.CreatedAt = rule.ID, rule.CreatedAt
+	if err := p.db.WithContext(ctx).Save(&update).Error; err != nil {
+		return nil, fmt.Errorf("failed to save pricing rule: %v", err)
+	}
+	return &update, p.enforceMarginPolicy(ctx, "")
+}
+
+// DeletePricingRule removes a rule and re-prices the packages it priced
+func (p *ProductService) DeletePricingRule(ctx context.Context, id uuid.UUID) error {
+	result := p.db.WithContext(ctx).Delete(&models.PricingRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPricingRuleNotFound
+	}
+	return p.enforceMarginPolicy(ctx, "")
+}
+
+// PricingRuleRef identifies the rule that won for a package
+type PricingRuleRef struct {
+	ID       uuid.UUID `json:"id"` // zero for a draft rule
+	Name     string    `json:"name"`
+	Priority int       `json:"priority"`
+}
+
+// PricingRulePreview shows how a package would be priced under the rules, next to its
+// current price
+type PricingRulePreview struct {
+	PackagePriceID   uuid.UUID       `json:"package_price_id"`
+	ProviderPriceID  int             `json:"provider_price_id"`
+	SKUID            string          `json:"sku_id"`
+	ShowName         string          `json:"show_name"`
+	Flows            float64         `json:"flows"`
+	Unit             string          `json:"unit"`
+	Days             int             `json:"days"`
+	ProviderPriceUSD money.Decimal   `json:"provider_price_usd"`
+	Rule             *PricingRuleRef `json:"rule,omitempty"`           // winning rule, if any matches
+	RulePriceUSD     *money.Decimal  `json:"rule_price_usd,omitempty"` // its price, even when an override or markup takes precedence
+	CurrentPriceUSD  money.Decimal   `json:"current_price_usd"`
+	CurrentSource    string          `json:"current_source"`
+	NewPriceUSD      money.Decimal   `json:"new_price_usd"`
+	NewSource        string          `json:"new_source"` // after overrides, markups, the margin policy and running sales
+	NewActive        bool            `json:"new_active"`
+	Changed          bool            `json:"changed"`
+}
+
+// PreviewPricingRules shows which rule wins for each active package, or each package of
+// skuID, and the price it would get, without saving anything. A draft rule is previewed
+// as if it were saved: it replaces the stored rule with its ID, or is added when it has
+// none.
+func (p *ProductService) PreviewPricingRules(ctx context.Context, draft *models.PricingRule, skuID string) ([]PricingRulePreview, error) {
+	pricer, err := p.loadPackagePricer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := pricer.rules
+	if draft != nil {
+		if err := validPricingRule(draft); err != nil {
+			return nil, err
+		}
+		if draft.ID != uuid.Nil {
+			var count int64
+			if err := p.db.WithContext(ctx).Model(&models.PricingRule{}).Where("id = ?", draft.ID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, ErrPricingRuleNotFound
+			}
+		}
+		kept := rules.rules[:0]
+		for _, rule := range rules.rules {
+			if draft.ID == uuid.Nil || rule.ID != draft.ID {
+				kept = append(kept, rule)
+			}
+		}
+		rules.rules = kept
+		if draft.Active {
+			rules.rules = append(rules.rules, *draft)
+			sortRules(rules.rules)
+		}
+		if err := p.loadContinents(ctx, rules); err != nil {
+			return nil, err
+		}
+	}
+
+	query := p.db.WithContext(ctx).Where("active = ? OR inactive_reason = ?", true, models.PackageInactiveMargin)
+	if skuID != "" {
+		query = query.Where("sku_id = ?", skuID)
+	}
+	var prices []models.PackagePrice
+	if err := query.Order("sku_id, provider_price_id").Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to load package prices: %v", err)
+	}
+
+	policies := make(map[string]models.MarginPolicy)
+	previews := make([]PricingRulePreview, 0, len(prices))
+	for _, current := range prices {
+		policy, ok := policies[current.SKUID]
+		if !ok {
+			if policy, err = p.marginPolicyFor(ctx, current.SKUID); err != nil {
+				return nil, err
+			}
+			policies[current.SKUID] = policy
+		}
+		pp := current
+		rule := rules.forPackage(&pp)
+		pricer.price(&pp, policy)
+
+		preview := PricingRulePreview{
+			PackagePriceID:   current.ID,
+			ProviderPriceID:  current.ProviderPriceID,
+			SKUID:            current.SKUID,
+			ShowName:         current.ShowName,
+			Flows:            current.Flows,
+			Unit:             current.Unit,
+			Days:             current.Days,
+			ProviderPriceUSD: current.RawProviderPrice,
+			CurrentPriceUSD:  current.EffectivePriceUSD,
+			CurrentSource:    current.PriceSource,
+			NewPriceUSD:      pp.EffectivePriceUSD,
+			NewSource:        pp.PriceSource,
+			NewActive:        pp.Active,
+		}
+		if rule != nil {
+			price := rulePrice(*rule, current.RawProviderPrice)
+			preview.Rule = &PricingRuleRef{ID: rule.ID, Name: rule.Name, Priority: rule.Priority}
+			preview.RulePriceUSD = &price
+		}
+		preview.Changed = !pp.EffectivePriceUSD.Equal(current.EffectivePriceUSD) || pp.PriceSource != current.PriceSource || pp.Active != current.Active
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
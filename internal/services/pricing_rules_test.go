@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+func intPtr(v int) *int           { return &v }
+func usdPtr(v string) *money.Decimal {
+	d := money.MustParse(v)
+	return &d
+}
+
+func TestRulePrice(t *testing.T) {
+	percent := models.PricingRule{AddOnType: models.PricingAddOnPercent, AddOn: money.FromInt(15)}
+	assert.Equal(t, "4.61", rulePrice(percent, money.MustParse("4.005")).String())
+	fixed := models.PricingRule{AddOnType: models.PricingAddOnFixed, AddOn: money.MustParse("1.5")}
+	assert.Equal(t, "5.51", rulePrice(fixed, money.MustParse("4.005")).String())
+}
+
+func TestRuleMatches(t *testing.T) {
+	pp := &models.PackagePrice{SKUID: "9001", Flows: 3, Unit: "GB", Days: 15, RawProviderPrice: money.MustParse("6.5")}
+	cases := []struct {
+		name  string
+		rule  models.PricingRule
+		match bool
+	}{
+		{"empty", models.PricingRule{}, true},
+		{"sku", models.PricingRule{SKUID: "9001"}, true},
+		{"other sku", models.PricingRule{SKUID: "9002"}, false},
+		{"continent", models.PricingRule{Continent: "asia"}, true},
+		{"other continent", models.PricingRule{Continent: "Europe"}, false},
+		{"data bucket", models.PricingRule{MinDataMB: floatPtr(1024), MaxDataMB: floatPtr(5 * 1024)}, true},
+		{"data below", models.PricingRule{MinDataMB: floatPtr(5 * 1024)}, false},
+		{"days", models.PricingRule{MinDays: intPtr(15), MaxDays: intPtr(30)}, true},
+		{"days above", models.PricingRule{MaxDays: intPtr(7)}, false},
+		{"price range", models.PricingRule{MinProviderPriceUSD: usdPtr("5"), MaxProviderPriceUSD: usdPtr("6.5")}, true},
+		{"price above", models.PricingRule{MaxProviderPriceUSD: usdPtr("6")}, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.match, ruleMatches(tc.rule, pp, "Asia"), tc.name)
+	}
+
+	daily := &models.PackagePrice{Flows: 1, Unit: "Day", Days: 1}
+	assert.False(t, ruleMatches(models.PricingRule{MaxDataMB: floatPtr(1024)}, daily, ""))
+	assert.True(t, ruleMatches(models.PricingRule{MaxDays: intPtr(1)}, daily, ""))
+}
+
+func TestPricingRulesPriority(t *testing.T) {
+	rules := []models.PricingRule{
+		{ID: uuid.New(), Name: "catch-all", Priority: 100},
+		{ID: uuid.New(), Name: "asia", Priority: 10, Continent: "Asia"},
+		{ID: uuid.New(), Name: "japan", Priority: 10, SKUID: "9001"},
+	}
+	sortRules(rules)
+	set := &pricingRules{rules: rules, continents: map[string]string{"9001": "Asia", "9003": "Europe"}}
+
+	assert.Equal(t, "asia", set.forPackage(&models.PackagePrice{SKUID: "9001"}).Name)
+	assert.Equal(t, "catch-all", set.forPackage(&models.PackagePrice{SKUID: "9003"}).Name)
+	assert.Nil(t, (&pricingRules{}).forPackage(&models.PackagePrice{SKUID: "9001"}))
+}
+
+func TestValidPricingRule(t *testing.T) {
+	valid := models.PricingRule{Name: "asia", AddOnType: models.PricingAddOnPercent, AddOn: money.FromInt(20)}
+	assert.NoError(t, validPricingRule(&valid))
+
+	for name, rule := range map[string]models.PricingRule{
+		"no name":      {AddOnType: models.PricingAddOnFixed},
+		"unknown type": {Name: "x", AddOnType: "tiered"},
+		"negative":     {Name: "x", AddOnType: models.PricingAddOnFixed, AddOn: money.FromInt(-1)},
+		"huge percent": {Name: "x", AddOnType: models.PricingAddOnPercent, AddOn: money.FromInt(2000)},
+		"empty range":  {Name: "x", AddOnType: models.PricingAddOnFixed, MinDays: intPtr(30), MaxDays: intPtr(7)},
+	} {
+		assert.ErrorIs(t, validPricingRule(&rule), ErrInvalidPricingRule, name)
+	}
+}
+
+func TestPriceWithMarginUsesRule(t *testing.T) {
+	rule := &models.PricingRule{ID: uuid.New(), AddOnType: models.PricingAddOnPercent, AddOn: money.FromInt(20)}
+	policy := models.MarginPolicy{MinMarginPercent: 10, Action: models.MarginActionReprice}
+
+	pp := models.PackagePrice{RawProviderPrice: money.FromInt(10), Active: true}
+	require.Nil(t, priceWithMargin(&pp, policy, rule))
+	assert.Equal(t, "12", pp.EffectivePriceUSD.String())
+	assert.Equal(t, priceSourceRule, pp.PriceSource)
+	assert.Equal(t, &rule.ID, pp.PricingRuleID)
+
+	// A package's own markup takes precedence over the rule
+	pp.MarkupPercent = floatPtr(30)
+	require.Nil(t, priceWithMargin(&pp, policy, rule))
+	assert.Equal(t, "13", pp.EffectivePriceUSD.String())
+	assert.Equal(t, "markup", pp.PriceSource)
+	assert.Nil(t, pp.PricingRuleID)
+
+	// A rule below the minimum margin is repriced to the floor like any other price
+	pp.MarkupPercent = nil
+	low := &models.PricingRule{ID: uuid.New(), AddOnType: models.PricingAddOnFixed, AddOn: money.MustParse("0.5")}
+	alert := priceWithMargin(&pp, policy, low)
+	require.NotNil(t, alert)
+	assert.Equal(t, "11", pp.EffectivePriceUSD.String())
+	assert.Equal(t, priceSourceMarginFloor, pp.PriceSource)
+}
@@ -1,20 +1,24 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"esim-platform/internal/models"
+	"esim-platform/internal/money"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type ProductService struct {
-	db              *gorm.DB
-	roamWiFiService *RoamWiFiService
+	db       *gorm.DB
+	provider ESIMProvider
+	cache    *CatalogCache // nil disables catalog caching
 }
 
 // EnrichedRoamWiFiPackage extends provider package data with pricing fields
@@ -42,11 +46,19 @@ type EnrichedRoamWiFiPackage struct {
 	MustDate          int                      `json:"must_date"`
 	HadDaypassDetail  int                      `json:"had_daypass_detail"`
 	// Pricing enrichment
-	EffectivePriceUSD float64  `json:"effective_price_usd"`
-	EffectivePriceMNT *float64 `json:"effective_price_mnt,omitempty"`
-	PriceSource       string   `json:"price_source"`
-	MarkupPercent     *float64 `json:"markup_percent,omitempty"`
-	OverridePriceUSD  *float64 `json:"override_price_usd,omitempty"`
+	EffectivePriceUSD money.Decimal  `json:"effective_price_usd"`
+	EffectivePriceMNT *money.Decimal `json:"effective_price_mnt,omitempty"`
+	PriceSource       string         `json:"price_source"`
+	MarkupPercent     *float64       `json:"markup_percent,omitempty"`
+	OverridePriceUSD  *money.Decimal `json:"override_price_usd,omitempty"`
+	// During a sale, the regular "was" prices and when the sale ends
+	OriginalPriceUSD *money.Decimal `json:"original_price_usd,omitempty"`
+	OriginalPriceMNT *money.Decimal `json:"original_price_mnt,omitempty"`
+	SaleEndsAt       *time.Time     `json:"sale_ends_at,omitempty"`
+	// DisplayPrice and DisplayOriginalPrice are the MNT prices converted to the response's
+	// display currency
+	DisplayPrice         *money.Decimal `json:"display_price,omitempty"`
+	DisplayOriginalPrice *money.Decimal `json:"display_original_price,omitempty"`
 }
 
 // EnrichedRoamWiFiPackagesResponse top-level enriched response
@@ -59,78 +71,130 @@ type EnrichedRoamWiFiPackagesResponse struct {
 	ImageURL       string                    `json:"image_url"`
 	CountryImages  []RoamWiFiCountryImage    `json:"country_images"`
 	Packages       []EnrichedRoamWiFiPackage `json:"packages"`
+	// Prices are charged in ChargeCurrency; DisplayPrice is shown in Currency at DisplayRate
+	Currency       money.Currency `json:"currency,omitempty"`
+	ChargeCurrency money.Currency `json:"charge_currency,omitempty"`
+	DisplayRate    *ExchangeRate  `json:"display_rate,omitempty"`
+}
+
+// InCurrency returns a copy of r with every package's MNT price converted at rate
+// (display currency to MNT). A nil rate shows prices in MNT.
+func (r *EnrichedRoamWiFiPackagesResponse) InCurrency(rate *ExchangeRate) *EnrichedRoamWiFiPackagesResponse {
+	out := *r
+	out.Currency, out.ChargeCurrency, out.DisplayRate = money.MNT, money.MNT, rate
+	if rate != nil {
+		out.Currency = rate.From
+	}
+	out.Packages = make([]EnrichedRoamWiFiPackage, len(r.Packages))
+	for i, pkg := range r.Packages {
+		pkg.DisplayPrice = displayPrice(pkg.EffectivePriceMNT, rate)
+		pkg.DisplayOriginalPrice = displayPrice(pkg.OriginalPriceMNT, rate)
+		out.Packages[i] = pkg
+	}
+	return &out
+}
+
+// displayPrice converts an MNT price, if there is one, at rate; a nil rate keeps it in MNT
+func displayPrice(mnt *money.Decimal, rate *ExchangeRate) *money.Decimal {
+	if mnt == nil {
+		return nil
+	}
+	display := *mnt
+	if rate != nil {
+		display = rate.Convert(money.New(display, money.MNT)).Amount
+	}
+	return &display
 }
 
 type CreateProductRequest struct {
-	SKUID          string   `json:"sku_id" binding:"required"`
-	Name           string   `json:"name" binding:"required"`
-	Description    string   `json:"description"`
-	DataLimit      string   `json:"data_limit"`
-	ValidityDays   int      `json:"validity_days"`
-	Countries      []string `json:"countries"`
-	Continent      string   `json:"continent"`
-	BasePrice      float64  `json:"base_price" binding:"required"`
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
-}
-
-// SyncPackagePrices fetches provider packages for a SKU and upserts pricing rows
-func (p *ProductService) SyncPackagePrices(skuID string) error {
-	detailed, err := p.roamWiFiService.GetPackagesDetailed(skuID)
+	SKUID          string         `json:"sku_id" binding:"required"`
+	Name           string         `json:"name" binding:"required"`
+	Description    string         `json:"description"`
+	DataLimit      string         `json:"data_limit"`
+	ValidityDays   int            `json:"validity_days"`
+	Countries      []string       `json:"countries"`
+	Continent      string         `json:"continent"`
+	BasePrice      *money.Decimal `json:"base_price" binding:"required"`
+	CustomPriceUSD *money.Decimal `json:"custom_price_usd"`
+}
+
+// PackageSyncResult counts the pricing rows touched by a package sync and lists the
+// catalog changes it found
+type PackageSyncResult struct {
+	Added         int                    `json:"added"`
+	Changed       int                    `json:"changed"`
+	Deactivated   int                    `json:"deactivated"`
+	PricingAlerts int                    `json:"pricing_alerts"` // packages below their minimum margin
+	Changes       []models.CatalogChange `json:"changes"`
+}
+
+// SyncPackagePrices fetches provider packages for a SKU and upserts pricing rows. Every
+// package is re-priced against the SKU's margin policy.
+func (p *ProductService) SyncPackagePrices(ctx context.Context, skuID string) (PackageSyncResult, error) {
+	var result PackageSyncResult
+	detailed, err := p.provider.GetPackagesDetailed(ctx, skuID)
 	if err != nil {
-		return fmt.Errorf("fetch detailed packages: %w", err)
+		return result, fmt.Errorf("fetch detailed packages: %w", err)
 	}
 	if detailed == nil {
-		return fmt.Errorf("no data returned for sku %s", skuID)
+		return result, fmt.Errorf("no data returned for sku %s", skuID)
+	}
+	policy, err := p.marginPolicyFor(ctx, skuID)
+	if err != nil {
+		return result, err
+	}
+	pricer, err := p.loadPackagePricer(ctx)
+	if err != nil {
+		return result, err
 	}
 	pricing := NewPricingService(p.db)
-	rate, _ := pricing.GetUSDToMNTRate()
+	rate := pricing.packageRate(ctx)
+	rule := pricing.GetRoundingRule(ctx)
 	now := time.Now()
+	var alerts []*models.PricingAlert
 	for _, pkg := range detailed.Packages {
-		effective := pkg.Price
-		priceSource := "base"
+		price, err := money.FromFloat(pkg.Price)
+		if err != nil {
+			return result, fmt.Errorf("package %d: invalid provider price: %w", pkg.PriceID, err)
+		}
 		var existing models.PackagePrice
-		tx := p.db.Where("provider_price_id = ?", pkg.PriceID).First(&existing)
+		tx := p.db.WithContext(ctx).Where("provider_price_id = ?", pkg.PriceID).First(&existing)
 		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("read existing price: %w", tx.Error)
+			return result, fmt.Errorf("read existing price: %w", tx.Error)
 		}
 		if existing.ID == uuid.Nil {
-			var effectiveMNT *float64
-			if rate > 0 {
-				mnt := effective * rate
-				effectiveMNT = &mnt
+			rec := models.PackagePrice{SKUID: skuID, ProviderPriceID: pkg.PriceID, APICode: pkg.APICode, ShowName: pkg.ShowName, Flows: pkg.Flows, Unit: pkg.Unit, Days: pkg.Days, RawProviderPrice: price, Active: true, LastSyncedAt: &now}
+			if alert := pricer.price(&rec, policy); alert != nil {
+				alerts = append(alerts, alert)
 			}
-			rec := models.PackagePrice{SKUID: skuID, ProviderPriceID: pkg.PriceID, APICode: pkg.APICode, ShowName: pkg.ShowName, Flows: pkg.Flows, Unit: pkg.Unit, Days: pkg.Days, RawProviderPrice: pkg.Price, EffectivePriceUSD: effective, EffectivePriceMNT: effectiveMNT, ExchangeRate: &rate, PriceSource: priceSource, Active: true, LastSyncedAt: &now}
-			if err := p.db.Create(&rec).Error; err != nil {
-				return fmt.Errorf("create package price: %w", err)
+			setMNTPrice(&rec, rate, rule)
+			if err := p.db.WithContext(ctx).Create(&rec).Error; err != nil {
+				return result, fmt.Errorf("create package price: %w", err)
 			}
+			result.Added++
+			result.Changes = append(result.Changes, newCatalogChange(&rec, models.CatalogChangeAdded, nil, &pkg.Price, ""))
 		} else {
+			if packagePriceChanged(&existing, skuID, pkg, price) {
+				result.Changed++
+			}
+			result.Changes = append(result.Changes, diffPackagePrice(&existing, skuID, pkg, price)...)
 			existing.SKUID = skuID
 			existing.APICode = pkg.APICode
 			existing.ShowName = pkg.ShowName
 			existing.Flows = pkg.Flows
 			existing.Unit = pkg.Unit
 			existing.Days = pkg.Days
-			existing.RawProviderPrice = pkg.Price
-			if existing.OverridePriceUSD != nil {
-				existing.EffectivePriceUSD = *existing.OverridePriceUSD
-				priceSource = "override"
-			} else if existing.MarkupPercent != nil {
-				existing.EffectivePriceUSD = pkg.Price * (1 + *existing.MarkupPercent/100)
-				priceSource = "markup"
-			} else {
-				existing.EffectivePriceUSD = pkg.Price
-				priceSource = "base"
-			}
-			existing.PriceSource = priceSource
-			existing.ExchangeRate = &rate
-			if rate > 0 {
-				mnt := existing.EffectivePriceUSD * rate
-				existing.EffectivePriceMNT = &mnt
-			}
+			existing.RawProviderPrice = price
 			existing.LastSyncedAt = &now
+			// The provider offers it, so only the margin policy can keep it inactive
 			existing.Active = true
-			if err := p.db.Save(&existing).Error; err != nil {
-				return fmt.Errorf("update package price: %w", err)
+			existing.InactiveReason = ""
+			if alert := pricer.price(&existing, policy); alert != nil {
+				alerts = append(alerts, alert)
+			}
+			setMNTPrice(&existing, rate, rule)
+			if err := p.db.WithContext(ctx).Save(&existing).Error; err != nil {
+				return result, fmt.Errorf("update package price: %w", err)
 			}
 		}
 	}
@@ -139,96 +203,150 @@ func (p *ProductService) SyncPackagePrices(skuID string) error {
 		providerIDs = append(providerIDs, pkg.PriceID)
 	}
 	// Deactivate any packages no longer returned by provider
-	if err := p.db.Model(&models.PackagePrice{}).Where("sku_id = ? AND provider_price_id NOT IN ?", skuID, providerIDs).Updates(map[string]interface{}{"active": false}).Error; err != nil {
-		return fmt.Errorf("deactivate missing packages: %w", err)
+	var missing []models.PackagePrice
+	if err := p.db.WithContext(ctx).Where("sku_id = ? AND active = ? AND provider_price_id NOT IN ?", skuID, true, providerIDs).Find(&missing).Error; err != nil {
+		return result, fmt.Errorf("read missing packages: %w", err)
+	}
+	if len(missing) > 0 {
+		ids := make([]uuid.UUID, len(missing))
+		for i := range missing {
+			ids[i] = missing[i].ID
+			price := missing[i].RawProviderPrice.Float64()
+			result.Changes = append(result.Changes, newCatalogChange(&missing[i], models.CatalogChangeRemoved, &price, nil, ""))
+		}
+		if err := p.db.WithContext(ctx).Model(&models.PackagePrice{}).Where("id IN ?", ids).Updates(map[string]interface{}{"active": false, "inactive_reason": models.PackageInactiveRemoved}).Error; err != nil {
+			return result, fmt.Errorf("deactivate missing packages: %w", err)
+		}
+		result.Deactivated = len(missing)
 	}
-	return nil
+
+	violating := make([]int, 0, len(alerts))
+	for _, alert := range alerts {
+		if err := p.raisePricingAlert(ctx, alert); err != nil {
+			return result, err
+		}
+		violating = append(violating, alert.ProviderPriceID)
+	}
+	if err := p.resolvePricingAlerts(ctx, skuID, violating, alertResolvedBySync); err != nil {
+		return result, err
+	}
+	result.PricingAlerts = len(alerts)
+	warnCacheInvalidation(p.cache.InvalidatePackages(ctx, skuID))
+	return result, nil
+}
+
+// packagePriceChanged reports whether a sync changes what the provider says about an
+// existing package, or brings back one the provider had removed. price is pkg.Price as a
+// Decimal.
+func packagePriceChanged(existing *models.PackagePrice, skuID string, pkg RoamWiFiPackage, price money.Decimal) bool {
+	return (!existing.Active && existing.InactiveReason != models.PackageInactiveMargin) ||
+		existing.SKUID != skuID ||
+		existing.APICode != pkg.APICode ||
+		existing.ShowName != pkg.ShowName ||
+		existing.Flows != pkg.Flows ||
+		existing.Unit != pkg.Unit ||
+		existing.Days != pkg.Days ||
+		!existing.RawProviderPrice.Equal(price)
 }
 
-// SetPackageMarkup sets markup percent and recomputes effective price (clears override)
-func (p *ProductService) SetPackageMarkup(providerPriceID int, markup float64) error {
+// SetPackageMarkup sets markup percent and recomputes effective price (clears override).
+// A markup below the SKU's minimum margin is rejected with a *MarginViolationError.
+func (p *ProductService) SetPackageMarkup(ctx context.Context, providerPriceID int, markup float64) error {
 	var pp models.PackagePrice
-	if err := p.db.Where("provider_price_id = ?", providerPriceID).First(&pp).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("provider_price_id = ?", providerPriceID).First(&pp).Error; err != nil {
 		return err
 	}
 	pp.MarkupPercent = &markup
 	pp.OverridePriceUSD = nil
-	// recompute
-	base := pp.RawProviderPrice
-	pp.EffectivePriceUSD = base * (1 + markup/100)
-	pp.PriceSource = "markup"
-	rateSvc := NewPricingService(p.db)
-	if rate, err := rateSvc.GetUSDToMNTRate(); err == nil {
-		pp.ExchangeRate = &rate
-		mnt := pp.EffectivePriceUSD * rate
-		pp.EffectivePriceMNT = &mnt
-	} else {
-		pp.ExchangeRate = nil
-	}
-	return p.db.Save(&pp).Error
+	return p.savePackagePricing(ctx, &pp)
 }
 
-// SetPackageOverride sets or clears override price (if nil passed clears override and falls back to markup/base)
-func (p *ProductService) SetPackageOverride(providerPriceID int, override *float64) error {
+// SetPackageOverride sets or clears override price (if nil passed clears override and falls back to markup/base).
+// A resulting price below the SKU's minimum margin is rejected with a *MarginViolationError.
+func (p *ProductService) SetPackageOverride(ctx context.Context, providerPriceID int, override *money.Decimal) error {
 	var pp models.PackagePrice
-	if err := p.db.Where("provider_price_id = ?", providerPriceID).First(&pp).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("provider_price_id = ?", providerPriceID).First(&pp).Error; err != nil {
 		return err
 	}
-	if override == nil {
-		pp.OverridePriceUSD = nil
-		// fallback recompute
-		if pp.MarkupPercent != nil {
-			pp.EffectivePriceUSD = pp.RawProviderPrice * (1 + *pp.MarkupPercent/100)
-			pp.PriceSource = "markup"
-		} else {
-			pp.EffectivePriceUSD = pp.RawProviderPrice
-			pp.PriceSource = "base"
-		}
-	} else {
-		if *override <= 0 {
-			return fmt.Errorf("override must be > 0")
-		}
-		pp.OverridePriceUSD = override
-		pp.EffectivePriceUSD = *override
-		pp.PriceSource = "override"
+	if override != nil && override.Sign() <= 0 {
+		return fmt.Errorf("override must be > 0")
 	}
+	pp.OverridePriceUSD = override
+	return p.savePackagePricing(ctx, &pp)
+}
+
+// savePackagePricing checks an admin's markup or override against the margin policy, then
+// recomputes and stores the effective prices. A package the policy had deactivated is sold
+// again, and its open pricing alert is resolved.
+func (p *ProductService) savePackagePricing(ctx context.Context, pp *models.PackagePrice) error {
+	policy, err := p.marginPolicyFor(ctx, pp.SKUID)
+	if err != nil {
+		return err
+	}
+	pricer, err := p.loadPackagePricer(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkMargin(pp, policy, pricer.rules.forPackage(pp)); err != nil {
+		return err
+	}
+	pricer.price(pp, policy)
 	rateSvc := NewPricingService(p.db)
-	if rate, err := rateSvc.GetUSDToMNTRate(); err == nil {
-		pp.ExchangeRate = &rate
-		mnt := pp.EffectivePriceUSD * rate
-		pp.EffectivePriceMNT = &mnt
+	if rate := rateSvc.packageRate(ctx); rate != nil {
+		setMNTPrice(pp, rate, rateSvc.GetRoundingRule(ctx))
 	} else {
-		pp.ExchangeRate = nil
+		pp.ExchangeRate, pp.ExchangeRateID = nil, nil
+	}
+	if err := p.db.WithContext(ctx).Save(pp).Error; err != nil {
+		return err
 	}
-	return p.db.Save(&pp).Error
+	if err := p.resolvePackageAlerts(ctx, pp.ProviderPriceID, alertResolvedByAdminEdit); err != nil {
+		return err
+	}
+	warnCacheInvalidation(p.cache.InvalidatePackages(ctx, pp.SKUID))
+	return nil
 }
 
 type UpdateProductRequest struct {
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DataLimit      string   `json:"data_limit"`
-	ValidityDays   int      `json:"validity_days"`
-	Countries      []string `json:"countries"`
-	Continent      string   `json:"continent"`
-	BasePrice      float64  `json:"base_price"`
-	CustomPriceUSD *float64 `json:"custom_price_usd"`
-	IsActive       *bool    `json:"is_active"`
-}
-
-func NewProductService(db *gorm.DB, roamWiFiService *RoamWiFiService) *ProductService {
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	DataLimit      string         `json:"data_limit"`
+	ValidityDays   int            `json:"validity_days"`
+	Countries      []string       `json:"countries"`
+	Continent      string         `json:"continent"`
+	BasePrice      *money.Decimal `json:"base_price"`
+	CustomPriceUSD *money.Decimal `json:"custom_price_usd"`
+	IsActive       *bool          `json:"is_active"`
+}
+
+func NewProductService(db *gorm.DB, provider ESIMProvider, cache *CatalogCache) *ProductService {
 	return &ProductService{
-		db:              db,
-		roamWiFiService: roamWiFiService,
+		db:       db,
+		provider: provider,
+		cache:    cache,
+	}
+}
+
+// warnCacheInvalidation logs a failed invalidation; the change itself is saved and the
+// stale entry expires with its TTL
+func warnCacheInvalidation(err error) {
+	if err != nil {
+		logrus.WithError(err).Warn("Catalog cache invalidation failed")
 	}
 }
 
+// ProviderStatus reports the health of the configured eSIM provider
+func (p *ProductService) ProviderStatus() ProviderStatus {
+	return p.provider.Status()
+}
+
 // GetProducts retrieves products with filtering and pagination
-func (p *ProductService) GetProducts(page, limit int, continent, active string) ([]models.Product, int64, error) {
+func (p *ProductService) GetProducts(ctx context.Context, page, limit int, continent, active string) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
 	offset := (page - 1) * limit
-	query := p.db.Model(&models.Product{})
+	query := p.db.WithContext(ctx).Model(&models.Product{})
 
 	// Apply filters
 	if continent != "" {
@@ -254,9 +372,9 @@ func (p *ProductService) GetProducts(page, limit int, continent, active string)
 }
 
 // GetProductsByContinent retrieves products grouped by continent
-func (p *ProductService) GetProductsByContinent() (map[string][]models.Product, error) {
+func (p *ProductService) GetProductsByContinent(ctx context.Context) (map[string][]models.Product, error) {
 	var products []models.Product
-	if err := p.db.Where("is_active = ?", true).Find(&products).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("is_active = ?", true).Find(&products).Error; err != nil {
 		return nil, fmt.Errorf("failed to get products: %v", err)
 	}
 
@@ -270,21 +388,26 @@ func (p *ProductService) GetProductsByContinent() (map[string][]models.Product,
 }
 
 // GetProduct retrieves a specific product by ID
-func (p *ProductService) GetProduct(productID uuid.UUID) (*models.Product, error) {
+func (p *ProductService) GetProduct(ctx context.Context, productID uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	if err := p.db.Where("id = ?", productID).First(&product).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("id = ?", productID).First(&product).Error; err != nil {
 		return nil, fmt.Errorf("product not found: %v", err)
 	}
 	return &product, nil
 }
 
 // GetPackagesBySKU retrieves packages for a specific SKU from RoamWiFi
-func (p *ProductService) GetPackagesBySKU(skuID string) ([]PackageInfo, error) {
-	return p.roamWiFiService.GetPackagesBySKU(skuID)
+func (p *ProductService) GetPackagesBySKU(ctx context.Context, skuID string) ([]PackageInfo, error) {
+	return cachedLoad(ctx, p.cache, CatalogCachePackages, catalogPackagesKey(skuID, "basic"), func(ctx context.Context) ([]PackageInfo, error) {
+		return p.provider.GetPackagesBySKU(ctx, skuID)
+	})
 }
 
 // CreateProduct creates a new product
-func (p *ProductService) CreateProduct(req CreateProductRequest) (*models.Product, error) {
+func (p *ProductService) CreateProduct(ctx context.Context, req CreateProductRequest) (*models.Product, error) {
+	if req.BasePrice == nil {
+		return nil, fmt.Errorf("base price is required")
+	}
 	product := models.Product{
 		SKUID:          req.SKUID,
 		Name:           req.Name,
@@ -293,12 +416,12 @@ func (p *ProductService) CreateProduct(req CreateProductRequest) (*models.Produc
 		ValidityDays:   req.ValidityDays,
 		Countries:      req.Countries,
 		Continent:      req.Continent,
-		BasePrice:      req.BasePrice,
+		BasePrice:      *req.BasePrice,
 		CustomPriceUSD: req.CustomPriceUSD,
 		IsActive:       true,
 	}
 
-	if err := p.db.Create(&product).Error; err != nil {
+	if err := p.db.WithContext(ctx).Create(&product).Error; err != nil {
 		return nil, fmt.Errorf("failed to create product: %v", err)
 	}
 
@@ -306,9 +429,9 @@ func (p *ProductService) CreateProduct(req CreateProductRequest) (*models.Produc
 }
 
 // UpdateProduct updates an existing product
-func (p *ProductService) UpdateProduct(productID uuid.UUID, req UpdateProductRequest) (*models.Product, error) {
+func (p *ProductService) UpdateProduct(ctx context.Context, productID uuid.UUID, req UpdateProductRequest) (*models.Product, error) {
 	var product models.Product
-	if err := p.db.Where("id = ?", productID).First(&product).Error; err != nil {
+	if err := p.db.WithContext(ctx).Where("id = ?", productID).First(&product).Error; err != nil {
 		return nil, fmt.Errorf("product not found: %v", err)
 	}
 
@@ -331,8 +454,8 @@ func (p *ProductService) UpdateProduct(productID uuid.UUID, req UpdateProductReq
 	if req.Continent != "" {
 		product.Continent = req.Continent
 	}
-	if req.BasePrice > 0 {
-		product.BasePrice = req.BasePrice
+	if req.BasePrice != nil && req.BasePrice.Sign() > 0 {
+		product.BasePrice = *req.BasePrice
 	}
 	if req.CustomPriceUSD != nil {
 		product.CustomPriceUSD = req.CustomPriceUSD
@@ -341,7 +464,7 @@ func (p *ProductService) UpdateProduct(productID uuid.UUID, req UpdateProductReq
 		product.IsActive = *req.IsActive
 	}
 
-	if err := p.db.Save(&product).Error; err != nil {
+	if err := p.db.WithContext(ctx).Save(&product).Error; err != nil {
 		return nil, fmt.Errorf("failed to update product: %v", err)
 	}
 
@@ -349,16 +472,25 @@ func (p *ProductService) UpdateProduct(productID uuid.UUID, req UpdateProductReq
 }
 
 // DeleteProduct deletes a product
-func (p *ProductService) DeleteProduct(productID uuid.UUID) error {
-	return p.db.Where("id = ?", productID).Delete(&models.Product{}).Error
+func (p *ProductService) DeleteProduct(ctx context.Context, productID uuid.UUID) error {
+	return p.db.WithContext(ctx).Where("id = ?", productID).Delete(&models.Product{}).Error
 }
 
+// defaultProductBasePrice is set on synced products since the SKU list has no prices
+var defaultProductBasePrice = money.FromInt(25)
+
 // SyncProductsFromRoamWiFi syncs products from RoamWiFi API
-func (p *ProductService) SyncProductsFromRoamWiFi() (int, error) {
+func (p *ProductService) SyncProductsFromRoamWiFi(ctx context.Context) (int, error) {
+	_, count, err := p.syncProducts(ctx)
+	return count, err
+}
+
+// syncProducts upserts a product per provider SKU and returns the SKU list it synced from
+func (p *ProductService) syncProducts(ctx context.Context) ([]SKUInfo, int, error) {
 	// Get SKU list from RoamWiFi
-	skuList, err := p.roamWiFiService.GetSKUList()
+	skuList, err := p.provider.GetSKUList(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get SKU list from RoamWiFi: %v", err)
+		return nil, 0, fmt.Errorf("failed to get SKU list from RoamWiFi: %w", err)
 	}
 
 	count := 0
@@ -368,19 +500,19 @@ func (p *ProductService) SyncProductsFromRoamWiFi() (int, error) {
 
 		// Check if product already exists
 		var existingProduct models.Product
-		if err := p.db.Where("sku_id = ?", skuIDStr).First(&existingProduct).Error; err == nil {
+		if err := p.db.WithContext(ctx).Where("sku_id = ?", skuIDStr).First(&existingProduct).Error; err == nil {
 			// Product exists, update it
 			existingProduct.Name = sku.Display
 			existingProduct.Continent = p.inferContinentFromDisplay(sku.Display)
 			// Set default values since API doesn't provide these
 			existingProduct.DataLimit = "Varies"
-			existingProduct.ValidityDays = 30 // Default validity
-			existingProduct.BasePrice = 25.0  // Default price, admin can update later
+			existingProduct.ValidityDays = 30                   // Default validity
+			existingProduct.BasePrice = defaultProductBasePrice // admin can update later
 
 			// Parse country code - this might be a region code, we'll store it
 			existingProduct.Countries = []string{sku.CountryCode}
 
-			if err := p.db.Save(&existingProduct).Error; err != nil {
+			if err := p.db.WithContext(ctx).Save(&existingProduct).Error; err != nil {
 				continue // Skip this product if update fails
 			}
 		} else {
@@ -390,20 +522,21 @@ func (p *ProductService) SyncProductsFromRoamWiFi() (int, error) {
 				Name:         sku.Display,
 				Continent:    p.inferContinentFromDisplay(sku.Display),
 				DataLimit:    "Varies",
-				ValidityDays: 30,   // Default validity
-				BasePrice:    25.0, // Default price, admin can update later
+				ValidityDays: 30,                      // Default validity
+				BasePrice:    defaultProductBasePrice, // admin can update later
 				Countries:    []string{sku.CountryCode},
 				IsActive:     true,
 			}
 
-			if err := p.db.Create(&product).Error; err != nil {
+			if err := p.db.WithContext(ctx).Create(&product).Error; err != nil {
 				continue // Skip this product if creation fails
 			}
 		}
 		count++
 	}
 
-	return count, nil
+	warnCacheInvalidation(p.cache.InvalidateSKULists(ctx))
+	return skuList, count, nil
 }
 
 // inferContinentFromDisplay tries to infer continent from the display name
@@ -431,14 +564,14 @@ func (p *ProductService) inferContinentFromDisplay(display string) string {
 }
 
 // SearchProducts searches products by name or description
-func (p *ProductService) SearchProducts(query string, page, limit int) ([]models.Product, int64, error) {
+func (p *ProductService) SearchProducts(ctx context.Context, query string, page, limit int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
 	offset := (page - 1) * limit
 
 	// Build search query
-	searchQuery := p.db.Where("name ILIKE ? OR description ILIKE ?",
+	searchQuery := p.db.WithContext(ctx).Where("name ILIKE ? OR description ILIKE ?",
 		"%"+query+"%", "%"+query+"%")
 
 	// Get total count
@@ -453,14 +586,14 @@ func (p *ProductService) SearchProducts(query string, page, limit int) ([]models
 }
 
 // GetProductsByPriceRange retrieves products within a price range
-func (p *ProductService) GetProductsByPriceRange(minPrice, maxPrice float64, page, limit int) ([]models.Product, int64, error) {
+func (p *ProductService) GetProductsByPriceRange(ctx context.Context, minPrice, maxPrice money.Decimal, page, limit int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
 	offset := (page - 1) * limit
 
 	// Build query
-	query := p.db.Where("base_price BETWEEN ? AND ?", minPrice, maxPrice)
+	query := p.db.WithContext(ctx).Where("base_price BETWEEN ? AND ?", minPrice, maxPrice)
 
 	// Get total count
 	query.Model(&models.Product{}).Count(&total)
@@ -473,24 +606,63 @@ func (p *ProductService) GetProductsByPriceRange(minPrice, maxPrice float64, pag
 	return products, total, nil
 }
 
-// GetSKUList proxies to RoamWiFiService to fetch live SKU list
-func (p *ProductService) GetSKUList() ([]SKUInfo, error) {
-	return p.roamWiFiService.GetSKUList()
+// GetSKUList returns the provider SKU list through the catalog cache
+func (p *ProductService) GetSKUList(ctx context.Context) ([]SKUInfo, error) {
+	return cachedLoad(ctx, p.cache, CatalogCacheSKUs, catalogSKUsKey, p.provider.GetSKUList)
 }
 
-// GetSKUByID proxies to RoamWiFiService to fetch a single SKU
-func (p *ProductService) GetSKUByID(skuID string) (*SKUInfo, error) {
-	return p.roamWiFiService.GetSKUByID(skuID)
+// GetSKUsByContinent returns the provider SKUs grouped by continent through the catalog cache
+func (p *ProductService) GetSKUsByContinent(ctx context.Context) ([]SKUInfo, error) {
+	return cachedLoad(ctx, p.cache, CatalogCacheContinents, catalogContinentsKey, p.provider.GetSKUsByContinent)
 }
 
-// GetPackagesRaw proxies to RoamWiFiService to fetch raw packages data
-func (p *ProductService) GetPackagesRaw(skuID string) (map[string]interface{}, error) {
-	return p.roamWiFiService.GetPackagesRaw(skuID)
+// CatalogCacheStats returns the catalog cache counters of this instance, nil when caching is off
+func (p *ProductService) CatalogCacheStats() map[string]CatalogCacheStats {
+	return p.cache.Stats()
 }
 
-// GetPackagesDetailed proxies to RoamWiFiService detailed response
-func (p *ProductService) GetPackagesDetailed(skuID string) (*EnrichedRoamWiFiPackagesResponse, error) {
-	base, err := p.roamWiFiService.GetPackagesDetailed(skuID)
+// InvalidateCatalogCache drops cached catalog data: the packages of the given SKUs, or
+// everything when no SKU is given
+func (p *ProductService) InvalidateCatalogCache(ctx context.Context, skuIDs ...string) error {
+	if len(skuIDs) == 0 {
+		return p.cache.InvalidateAll(ctx)
+	}
+	return p.cache.InvalidatePackages(ctx, skuIDs...)
+}
+
+// GetSKUByID proxies to the eSIM provider to fetch a single SKU
+func (p *ProductService) GetSKUByID(ctx context.Context, skuID string) (*SKUInfo, error) {
+	return p.provider.GetSKUByID(ctx, skuID)
+}
+
+// GetPackagesRaw proxies to the eSIM provider to fetch raw packages data
+func (p *ProductService) GetPackagesRaw(ctx context.Context, skuID string) (map[string]interface{}, error) {
+	return p.provider.GetPackagesRaw(ctx, skuID)
+}
+
+// GetPackagesDetailed returns the provider detailed response merged with our package
+// prices, through the catalog cache. Price changes invalidate the SKU's entry.
+func (p *ProductService) GetPackagesDetailed(ctx context.Context, skuID string) (*EnrichedRoamWiFiPackagesResponse, error) {
+	return cachedLoad(ctx, p.cache, CatalogCachePackages, catalogPackagesKey(skuID, "detailed"), func(ctx context.Context) (*EnrichedRoamWiFiPackagesResponse, error) {
+		return p.loadPackagesDetailed(ctx, skuID)
+	})
+}
+
+// DisplayRate returns the rate for showing MNT prices in currency, as units of MNT per
+// unit of currency. MNT prices need no rate, so it is nil for MNT.
+func (p *ProductService) DisplayRate(ctx context.Context, currency money.Currency) (*ExchangeRate, error) {
+	if currency == money.MNT {
+		return nil, nil
+	}
+	rate, err := NewPricingService(p.db).GetRate(ctx, currency, money.MNT)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (p *ProductService) loadPackagesDetailed(ctx context.Context, skuID string) (*EnrichedRoamWiFiPackagesResponse, error) {
+	base, err := p.provider.GetPackagesDetailed(ctx, skuID)
 	if err != nil {
 		return nil, err
 	}
@@ -507,25 +679,65 @@ func (p *ProductService) GetPackagesDetailed(skuID string) (*EnrichedRoamWiFiPac
 	// Load pricing map
 	var prices []models.PackagePrice
 	priceMap := map[int]models.PackagePrice{}
-	if err := p.db.Where("sku_id = ? AND active = ?", skuID, true).Find(&prices).Error; err == nil {
+	if err := p.db.WithContext(ctx).Where("sku_id = ?", skuID).Find(&prices).Error; err == nil {
 		for _, pr := range prices {
 			priceMap[pr.ProviderPriceID] = pr
 		}
 	}
+	saleEnds := p.saleEnds(ctx, prices)
 	// Merge
 	for _, pkg := range base.Packages {
+		if pr, ok := priceMap[pkg.PriceID]; ok && !pr.Active && pr.InactiveReason == models.PackageInactiveMargin {
+			// Not for sale until its price meets the margin policy
+			continue
+		}
+		basePrice, err := money.FromFloat(pkg.Price)
+		if err != nil {
+			logrus.Warnf("SKU %s package %d: skipping invalid provider price: %v", skuID, pkg.PriceID, err)
+			continue
+		}
 		merged := EnrichedRoamWiFiPackage{
 			APICode: pkg.APICode, Flows: pkg.Flows, Unit: pkg.Unit, Days: pkg.Days, Price: pkg.Price, PriceID: pkg.PriceID, FlowType: pkg.FlowType, ShowName: pkg.ShowName, PID: pkg.PID, Premark: pkg.Premark, Overlay: pkg.Overlay, ExpireDays: pkg.ExpireDays, Network: pkg.Network, SupportDaypass: pkg.SupportDaypass, OpenCardFee: pkg.OpenCardFee, MinDay: pkg.MinDay, SingleDiscountDay: pkg.SingleDiscountDay, SingleDiscount: pkg.SingleDiscount, MaxDiscount: pkg.MaxDiscount, MaxDay: pkg.MaxDay, MustDate: pkg.MustDate, HadDaypassDetail: pkg.HadDaypassDetail,
-			EffectivePriceUSD: pkg.Price, PriceSource: "base",
+			EffectivePriceUSD: basePrice, PriceSource: "base",
 		}
-		if pr, ok := priceMap[pkg.PriceID]; ok {
+		if pr, ok := priceMap[pkg.PriceID]; ok && pr.Active {
 			merged.EffectivePriceUSD = pr.EffectivePriceUSD
 			merged.EffectivePriceMNT = pr.EffectivePriceMNT
 			merged.PriceSource = pr.PriceSource
 			merged.MarkupPercent = pr.MarkupPercent
 			merged.OverridePriceUSD = pr.OverridePriceUSD
+			if pr.ScheduledChangeID != nil {
+				merged.OriginalPriceUSD = pr.RegularPriceUSD
+				merged.OriginalPriceMNT = pr.RegularPriceMNT
+				if ends, ok := saleEnds[*pr.ScheduledChangeID]; ok {
+					merged.SaleEndsAt = &ends
+				}
+			}
 		}
 		enriched.Packages = append(enriched.Packages, merged)
 	}
 	return enriched, nil
 }
+
+// saleEnds returns when the sales running on the given packages end
+func (p *ProductService) saleEnds(ctx context.Context, prices []models.PackagePrice) map[uuid.UUID]time.Time {
+	ends := make(map[uuid.UUID]time.Time)
+	var ids []uuid.UUID
+	for _, pp := range prices {
+		if pp.ScheduledChangeID != nil {
+			ids = append(ids, *pp.ScheduledChangeID)
+		}
+	}
+	if len(ids) == 0 {
+		return ends
+	}
+	var changes []models.ScheduledPriceChange
+	if err := p.db.WithContext(ctx).Select("id", "ends_at").Where("id IN ?", ids).Find(&changes).Error; err != nil {
+		logrus.WithError(err).Warn("Failed to load sale end times")
+		return ends
+	}
+	for _, change := range changes {
+		ends[change.ID] = change.EndsAt
+	}
+	return ends
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"esim-platform/internal/config"
+)
+
+// ESIMProvider is the upstream eSIM catalog and ordering API. RoamWiFiService is the
+// production implementation; FakeESIMProvider serves tests and local development.
+type ESIMProvider interface {
+	GetSKUList(ctx context.Context) ([]SKUInfo, error)
+	GetSKUsByContinent(ctx context.Context) ([]SKUInfo, error)
+	GetSKUByID(ctx context.Context, skuID string) (*SKUInfo, error)
+	GetPackagesBySKU(ctx context.Context, skuID string) ([]PackageInfo, error)
+	GetPackagesDetailed(ctx context.Context, skuID string) (*RoamWiFiPackagesResponse, error)
+	GetPackagesRaw(ctx context.Context, skuID string) (map[string]interface{}, error)
+	CreateOrder(ctx context.Context, req OrderRequest) (*RoamWiFiOrderResponse, error)
+	GetOrderInfo(ctx context.Context, orderID string) (*OrderInfo, error)
+	SendPDFEmail(ctx context.Context, orderID, email string) error
+	Status() ProviderStatus
+}
+
+// ProviderStatus reports which provider is active and the health of its upstream session.
+// Available is false while the circuit breaker rejects calls; new purchases are refused then.
+type ProviderStatus struct {
+	Provider  string                 `json:"provider"`
+	Healthy   bool                   `json:"healthy"`
+	Available bool                   `json:"available"`
+	Session   *RoamWiFiSessionStatus `json:"session,omitempty"`
+	Circuit   *CircuitStatus         `json:"circuit,omitempty"`
+}
+
+var (
+	_ ESIMProvider = (*RoamWiFiService)(nil)
+	_ ESIMProvider = (*FakeESIMProvider)(nil)
+)
+
+// providerDeclined reports whether a failed provider call certainly did nothing: the provider
+// answered with an error result, or the call was never sent because its circuit was open.
+// Timeouts, network errors and 5xx responses leave the outcome unknown.
+func providerDeclined(err error) bool {
+	var open *CircuitOpenError
+	if errors.As(err, &open) {
+		return true
+	}
+	var rwErr *RoamWiFiError
+	return errors.As(err, &rwErr) && !errors.Is(rwErr.Kind, ErrUpstreamUnavailable)
+}
+
+// Provider names accepted by ESIM_PROVIDER
+const (
+	ProviderRoamWiFi = "roamwifi"
+	ProviderFake     = "fake"
+)
+
+// NewESIMProvider builds the provider selected in configuration
+func NewESIMProvider(cfg *config.Config) (ESIMProvider, error) {
+	switch strings.ToLower(cfg.Provider.Name) {
+	case "", ProviderRoamWiFi:
+		return NewRoamWiFiService(cfg.RoamWiFi), nil
+	case ProviderFake:
+		opts := FakeProviderOptions{
+			Latency:     time.Duration(cfg.Provider.FakeLatencyMS) * time.Millisecond,
+			FailureRate: cfg.Provider.FakeFailureRate,
+		}
+		if cfg.Provider.FakeCatalogFile != "" {
+			catalog, err := LoadFakeCatalog(cfg.Provider.FakeCatalogFile)
+			if err != nil {
+				return nil, err
+			}
+			opts.Catalog = catalog
+		}
+		fake := NewFakeESIMProvider(opts)
+		for _, op := range strings.Split(cfg.Provider.FakeFailOps, ",") {
+			if op = strings.TrimSpace(op); op != "" {
+				fake.FailAlways(op, fmt.Errorf("fake provider: %s disabled by configuration", op))
+			}
+		}
+		return fake, nil
+	default:
+		return nil, fmt.Errorf("unknown eSIM provider %q", cfg.Provider.Name)
+	}
+}
@@ -1,29 +1,34 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"esim-platform/internal/config"
+	"esim-platform/internal/money"
 )
 
 type QPayService struct {
-	config config.QPayConfig
-	client *http.Client
+	config  config.QPayConfig
+	client  *http.Client
+	tokens  *qpayTokenManager
+	breaker *circuitBreaker
+	retry   retryPolicy
 }
 
 type QPayInvoiceRequest struct {
-	MerchantID         string  `json:"merchant_id"`
-	InvoiceCode        string  `json:"invoice_code"`
-	SenderInvoiceNo    string  `json:"sender_invoice_no"`
-	InvoiceReceiver    string  `json:"invoice_receiver"`
-	InvoiceDescription string  `json:"invoice_description"`
-	Amount             float64 `json:"amount"`
-	CallbackURL        string  `json:"callback_url"`
+	MerchantID         string        `json:"merchant_id"`
+	InvoiceCode        string        `json:"invoice_code"`
+	SenderInvoiceNo    string        `json:"sender_invoice_no"`
+	InvoiceReceiver    string        `json:"invoice_receiver"`
+	InvoiceDescription string        `json:"invoice_description"`
+	Amount             money.Decimal `json:"amount"` // MNT
+	CallbackURL        string        `json:"callback_url"`
 }
 
 type QPayInvoiceResponse struct {
@@ -49,24 +54,24 @@ type QPayCheckPaymentResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Data    struct {
-		InvoiceID       string  `json:"invoice_id"`
-		SenderInvoiceNo string  `json:"sender_invoice_no"`
-		TransactionID   string  `json:"transaction_id"`
-		PaymentStatus   string  `json:"payment_status"`
-		Amount          float64 `json:"amount"`
-		PaidAmount      float64 `json:"paid_amount"`
-		PaymentDate     string  `json:"payment_date"`
+		InvoiceID       string        `json:"invoice_id"`
+		SenderInvoiceNo string        `json:"sender_invoice_no"`
+		TransactionID   string        `json:"transaction_id"`
+		PaymentStatus   string        `json:"payment_status"`
+		Amount          money.Decimal `json:"amount"`
+		PaidAmount      money.Decimal `json:"paid_amount"`
+		PaymentDate     string        `json:"payment_date"`
 	} `json:"data"`
 }
 
 type QPayWebhookData struct {
-	InvoiceID       string  `json:"invoice_id"`
-	SenderInvoiceNo string  `json:"sender_invoice_no"`
-	TransactionID   string  `json:"transaction_id"`
-	PaymentStatus   string  `json:"payment_status"`
-	Amount          float64 `json:"amount"`
-	PaidAmount      float64 `json:"paid_amount"`
-	PaymentDate     string  `json:"payment_date"`
+	InvoiceID       string        `json:"invoice_id"`
+	SenderInvoiceNo string        `json:"sender_invoice_no"`
+	TransactionID   string        `json:"transaction_id"`
+	PaymentStatus   string        `json:"payment_status"`
+	Amount          money.Decimal `json:"amount"`
+	PaidAmount      money.Decimal `json:"paid_amount"`
+	PaymentDate     string        `json:"payment_date"`
 }
 
 func NewQPayService(cfg config.QPayConfig) *QPayService {
@@ -75,11 +80,15 @@ func NewQPayService(cfg config.QPayConfig) *QPayService {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		tokens:  &qpayTokenManager{now: time.Now},
+		breaker: newCircuitBreaker("QPay", cfg.Resilience),
+		retry:   newRetryPolicy(cfg.Resilience),
 	}
 }
 
-// CreateInvoice creates a new QPay invoice
-func (q *QPayService) CreateInvoice(orderNumber, description, customerEmail string, amount float64) (*QPayInvoiceResponse, error) {
+// CreateInvoice creates a new QPay invoice. It is not retried: a repeated request would
+// create a second invoice for the same order.
+func (q *QPayService) CreateInvoice(ctx context.Context, orderNumber, description, customerEmail string, amount money.Decimal) (*QPayInvoiceResponse, error) {
 	url := fmt.Sprintf("%s/invoice", q.config.Endpoint)
 
 	// Generate invoice code with prefix and timestamp
@@ -100,16 +109,9 @@ func (q *QPayService) CreateInvoice(orderNumber, description, customerEmail stri
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBodyBytes))
+	resp, err := q.doAuthorized(ctx, "POST", url, reqBodyBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := q.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -126,7 +128,7 @@ func (q *QPayService) CreateInvoice(orderNumber, description, customerEmail stri
 }
 
 // CheckPayment checks the payment status of an invoice
-func (q *QPayService) CheckPayment(invoiceID string) (*QPayCheckPaymentResponse, error) {
+func (q *QPayService) CheckPayment(ctx context.Context, invoiceID string) (*QPayCheckPaymentResponse, error) {
 	url := fmt.Sprintf("%s/payment/check", q.config.Endpoint)
 
 	// Generate check password (MD5 hash of QPay password)
@@ -143,16 +145,13 @@ func (q *QPayService) CheckPayment(invoiceID string) (*QPayCheckPaymentResponse,
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBodyBytes))
+	var resp *http.Response
+	err = q.retry.do(ctx, func() (err error) {
+		resp, err = q.doAuthorized(ctx, "POST", url, reqBodyBytes)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := q.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -168,6 +167,60 @@ func (q *QPayService) CheckPayment(invoiceID string) (*QPayCheckPaymentResponse,
 	return &response, nil
 }
 
+// QPayRefundRequest is the body of a QPay v2 payment refund
+type QPayRefundRequest struct {
+	CallbackURL string         `json:"callback_url,omitempty"`
+	Note        string         `json:"note,omitempty"`
+	Amount      *money.Decimal `json:"amount,omitempty"` // MNT; omitted for a full refund
+}
+
+// QPayActionResponse is returned by QPay cancel/refund calls; an empty body means success
+type QPayActionResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// CancelInvoice cancels an unpaid QPay invoice so it can no longer be paid
+func (q *QPayService) CancelInvoice(ctx context.Context, invoiceID string) error {
+	url := fmt.Sprintf("%s/invoice/%s", q.config.Endpoint, invoiceID)
+	return q.retry.do(ctx, func() error { return q.doAction(ctx, "DELETE", url, nil) })
+}
+
+// RefundPayment refunds a QPay payment. An amount of zero refunds the full payment.
+// Like CreateInvoice it is not retried, so a refund is never issued twice.
+func (q *QPayService) RefundPayment(ctx context.Context, paymentID string, amount money.Decimal, note string) error {
+	url := fmt.Sprintf("%s/payment/refund/%s", q.config.Endpoint, paymentID)
+
+	req := QPayRefundRequest{CallbackURL: q.config.CallbackURL, Note: note}
+	if !amount.IsZero() {
+		formatted := q.FormatAmount(amount)
+		req.Amount = &formatted
+	}
+	reqBodyBytes, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	return q.doAction(ctx, "DELETE", url, reqBodyBytes)
+}
+
+// doAction performs a QPay call whose response carries no data beyond an optional error code
+func (q *QPayService) doAction(ctx context.Context, method, url string, body []byte) error {
+	resp, err := q.doAuthorized(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response QPayActionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	if response.Code != 0 {
+		return fmt.Errorf("QPay API error: %s", response.Message)
+	}
+	return nil
+}
+
 // VerifyWebhookSignature verifies the webhook signature from QPay
 func (q *QPayService) VerifyWebhookSignature(data map[string]interface{}, signature string) bool {
 	// QPay webhook verification logic
@@ -198,6 +251,7 @@ func (q *QPayService) VerifyWebhookSignature(data map[string]interface{}, signat
 // ParseWebhookData parses webhook data from QPay
 func (q *QPayService) ParseWebhookData(data map[string]interface{}) (*QPayWebhookData, error) {
 	webhookData := &QPayWebhookData{}
+	var err error
 
 	// Parse invoice_id
 	if invoiceID, ok := data["invoice_id"].(string); ok {
@@ -228,18 +282,22 @@ func (q *QPayService) ParseWebhookData(data map[string]interface{}) (*QPayWebhoo
 	}
 
 	// Parse amount
-	if amount, ok := data["amount"].(float64); ok {
-		webhookData.Amount = amount
-	} else {
+	amount, ok := data["amount"].(float64)
+	if !ok {
 		return nil, fmt.Errorf("invalid amount")
 	}
+	if webhookData.Amount, err = money.FromFloat(amount); err != nil {
+		return nil, fmt.Errorf("invalid amount: %v", err)
+	}
 
 	// Parse paid_amount
-	if paidAmount, ok := data["paid_amount"].(float64); ok {
-		webhookData.PaidAmount = paidAmount
-	} else {
+	paidAmount, ok := data["paid_amount"].(float64)
+	if !ok {
 		return nil, fmt.Errorf("invalid paid_amount")
 	}
+	if webhookData.PaidAmount, err = money.FromFloat(paidAmount); err != nil {
+		return nil, fmt.Errorf("invalid paid_amount: %v", err)
+	}
 
 	// Parse payment_date
 	if paymentDate, ok := data["payment_date"].(string); ok {
@@ -262,15 +320,17 @@ func (q *QPayService) GetPaymentStatus(qpayStatus string) string {
 		return "failed"
 	case "CANCELLED":
 		return "cancelled"
+	case "REFUNDED":
+		return "refunded"
 	default:
 		return "unknown"
 	}
 }
 
-// FormatAmount formats amount for QPay (in MNT, no decimals)
-func (q *QPayService) FormatAmount(amount float64) float64 {
-	// QPay expects amounts in MNT without decimals
-	return float64(int(amount))
+// FormatAmount settles an MNT amount for QPay, which expects whole tugrik. Order amounts
+// are already whole, so this only guards against unrounded input.
+func (q *QPayService) FormatAmount(amount money.Decimal) money.Decimal {
+	return money.New(amount, money.MNT).Settled().Amount
 }
 
 // GenerateOrderNumber generates a unique order number
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// qpayTokenRefreshSkew is how long before expiry a cached token is considered stale
+const qpayTokenRefreshSkew = 60 * time.Second
+
+// QPayTokenResponse is returned by QPay v2 /auth/token and /auth/refresh
+type QPayTokenResponse struct {
+	TokenType        string `json:"token_type"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+	Scope            string `json:"scope"`
+}
+
+// qpayTokenManager caches the QPay access token and renews it before it expires.
+// A single mutex serializes renewals so concurrent callers share one token request.
+type qpayTokenManager struct {
+	mu               sync.Mutex
+	accessToken      string
+	accessExpiresAt  time.Time
+	refreshToken     string
+	refreshExpiresAt time.Time
+	now              func() time.Time
+}
+
+// qpayExpiry converts a QPay expires_in value to an absolute time. QPay returns a unix
+// timestamp, but a relative number of seconds is accepted as well.
+func qpayExpiry(now time.Time, expiresIn int64) time.Time {
+	if expiresIn > 1_000_000_000 {
+		return time.Unix(expiresIn, 0)
+	}
+	return now.Add(time.Duration(expiresIn) * time.Second)
+}
+
+// token returns a valid access token, refreshing or re-authenticating when needed
+func (q *QPayService) token(ctx context.Context) (string, error) {
+	m := q.tokens
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if m.accessToken != "" && now.Add(qpayTokenRefreshSkew).Before(m.accessExpiresAt) {
+		return m.accessToken, nil
+	}
+
+	var resp *QPayTokenResponse
+	var err error
+	if m.refreshToken != "" && now.Add(qpayTokenRefreshSkew).Before(m.refreshExpiresAt) {
+		resp, err = q.refreshToken(ctx, m.refreshToken)
+	}
+	if resp == nil {
+		// No usable refresh token, or the refresh was rejected: log in again
+		resp, err = q.requestToken(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m.accessToken = resp.AccessToken
+	m.accessExpiresAt = qpayExpiry(now, resp.ExpiresIn)
+	if resp.RefreshToken != "" {
+		m.refreshToken = resp.RefreshToken
+		m.refreshExpiresAt = qpayExpiry(now, resp.RefreshExpiresIn)
+	}
+	return m.accessToken, nil
+}
+
+// invalidateToken drops the cached access token if it is still the one that was rejected
+func (q *QPayService) invalidateToken(rejected string) {
+	m := q.tokens
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.accessToken == rejected {
+		m.accessToken = ""
+		m.accessExpiresAt = time.Time{}
+	}
+}
+
+// requestToken authenticates with merchant credentials via basic auth
+func (q *QPayService) requestToken(ctx context.Context) (*QPayTokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/auth/token", q.config.Endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %v", err)
+	}
+	req.SetBasicAuth(q.config.Username, q.config.Password)
+	return q.doTokenRequest(req)
+}
+
+// refreshToken exchanges a refresh token for a new access token
+func (q *QPayService) refreshToken(ctx context.Context, refreshToken string) (*QPayTokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/auth/refresh", q.config.Endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+refreshToken)
+	return q.doTokenRequest(req)
+}
+
+func (q *QPayService) doTokenRequest(req *http.Request) (*QPayTokenResponse, error) {
+	resp, err := q.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("QPay auth failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token QPayTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("QPay auth returned no access token")
+	}
+	return &token, nil
+}
+
+// doAuthorized sends a JSON request with the bearer token, re-authenticating and
+// retrying once if QPay answers 401. Error statuses are returned as errors.
+func (q *QPayService) doAuthorized(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := q.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := q.send(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			q.invalidateToken(token)
+			continue
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			defer resp.Body.Close()
+			errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return nil, fmt.Errorf("QPay API error: status %d: %s", resp.StatusCode, string(errBody))
+		}
+		return resp, nil
+	}
+}
+
+// send performs one HTTP exchange under the circuit breaker. Network errors and 5xx or
+// 429 responses are returned as transient errors with the response body closed.
+func (q *QPayService) send(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := q.breaker.do(req.Context(), func() error {
+		var err error
+		if resp, err = q.client.Do(req); err != nil {
+			return transient(fmt.Errorf("failed to make request: %w", err))
+		}
+		if err := statusError("QPay", resp); err != nil {
+			resp.Body.Close()
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
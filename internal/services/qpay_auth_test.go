@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQPayAuthTestServer(t *testing.T, tokenCalls *int32, rejectFirst bool) *httptest.Server {
+	var rejected int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/token":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "merchant" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(tokenCalls, 1)
+			json.NewEncoder(w).Encode(QPayTokenResponse{
+				AccessToken:  "access-" + string(rune('0'+n)),
+				ExpiresIn:    3600,
+				RefreshToken: "refresh",
+			})
+		case "/payment/check":
+			if rejectFirst && atomic.CompareAndSwapInt32(&rejected, 0, 1) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Contains(t, r.Header.Get("Authorization"), "Bearer access-")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"invoice_id": "inv-1", "payment_status": "PAID"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestQPayService(endpoint string) *QPayService {
+	return NewQPayService(config.QPayConfig{Endpoint: endpoint, Username: "merchant", Password: "secret"})
+}
+
+func TestQPayTokenIsCachedAcrossConcurrentCalls(t *testing.T) {
+	var tokenCalls int32
+	srv := newQPayAuthTestServer(t, &tokenCalls, false)
+	defer srv.Close()
+	q := newTestQPayService(srv.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.CheckPayment(context.Background(), "inv-1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenCalls))
+}
+
+func TestQPayRetriesOnceOnUnauthorized(t *testing.T) {
+	var tokenCalls int32
+	srv := newQPayAuthTestServer(t, &tokenCalls, true)
+	defer srv.Close()
+	q := newTestQPayService(srv.URL)
+
+	resp, err := q.CheckPayment(context.Background(), "inv-1")
+	require.NoError(t, err)
+	assert.Equal(t, "PAID", resp.Data.PaymentStatus)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenCalls))
+}
+
+func TestQPayExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	assert.Equal(t, now.Add(time.Hour), qpayExpiry(now, 3600))
+	assert.Equal(t, time.Unix(1_700_003_600, 0), qpayExpiry(now, 1_700_003_600))
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PaymentReconciler polls QPay for orders whose payment callback never arrived and feeds the
+// result through the same processing path as the webhook
+type PaymentReconciler struct {
+	db           *gorm.DB
+	orderService *OrderService
+	qpayService  *QPayService
+	config       config.ReconcilerConfig
+
+	// per-order backoff, only touched from the Run goroutine
+	backoff map[uuid.UUID]*reconcileBackoff
+	now     func() time.Time
+}
+
+type reconcileBackoff struct {
+	attempts int
+	next     time.Time
+}
+
+func NewPaymentReconciler(db *gorm.DB, orderService *OrderService, qpayService *QPayService, cfg config.ReconcilerConfig) *PaymentReconciler {
+	return &PaymentReconciler{
+		db:           db,
+		orderService: orderService,
+		qpayService:  qpayService,
+		config:       cfg,
+		backoff:      make(map[uuid.UUID]*reconcileBackoff),
+		now:          time.Now,
+	}
+}
+
+// Run scans pending orders every interval until ctx is cancelled
+func (r *PaymentReconciler) Run(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		logrus.Warn("Payment reconciler disabled: PAYMENT_RECONCILE_INTERVAL must be positive")
+		return
+	}
+	interval := time.Duration(r.config.Interval) * time.Second
+	logrus.Infof("Payment reconciler started (interval %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Payment reconciler stopped")
+			return
+		case <-ticker.C:
+			r.ReconcileOnce(ctx)
+		}
+	}
+}
+
+// ReconcileOnce checks one batch of unpaid orders that are due for a retry
+func (r *PaymentReconciler) ReconcileOnce(ctx context.Context) {
+	now := r.now()
+	minCreated := now.Add(-time.Duration(r.config.MaxAge) * time.Second)
+	maxCreated := now.Add(-time.Duration(r.config.MinAge) * time.Second)
+
+	var orders []models.Order
+	if err := r.db.WithContext(ctx).Where("status IN ? AND qpay_invoice_id <> '' AND created_at BETWEEN ? AND ?",
+		[]models.OrderStatus{models.OrderStatusPending, models.OrderStatusAwaitingPayment}, minCreated, maxCreated).
+		Order("created_at ASC").
+		Find(&orders).Error; err != nil {
+		logrus.Errorf("Payment reconciler: failed to load pending orders: %v", err)
+		return
+	}
+
+	// Forget backoff state for orders that left the window or were settled elsewhere
+	seen := make(map[uuid.UUID]bool, len(orders))
+	for _, order := range orders {
+		seen[order.ID] = true
+	}
+	for id := range r.backoff {
+		if !seen[id] {
+			delete(r.backoff, id)
+		}
+	}
+
+	checked := 0
+	for i := range orders {
+		if ctx.Err() != nil || checked >= r.config.BatchSize {
+			return
+		}
+		order := &orders[i]
+		if state, ok := r.backoff[order.ID]; ok && now.Before(state.next) {
+			continue
+		}
+		checked++
+
+		opCtx, cancel := workContext(ctx, backgroundOpTimeout)
+		settled, err := r.reconcileOrder(opCtx, order)
+		cancel()
+		if err != nil {
+			logrus.Warnf("Payment reconciler: order %s: %v", order.OrderNumber, err)
+		}
+		if settled {
+			delete(r.backoff, order.ID)
+			continue
+		}
+		r.scheduleRetry(order.ID, now)
+	}
+}
+
+// reconcileOrder asks QPay about the order's invoice and applies a final status. It reports
+// whether the order no longer needs polling.
+func (r *PaymentReconciler) reconcileOrder(ctx context.Context, order *models.Order) (bool, error) {
+	check, err := r.qpayService.CheckPayment(ctx, order.QPayInvoiceID)
+	if err != nil {
+		return false, err
+	}
+	if check.Data.PaymentStatus != "PAID" && check.Data.PaymentStatus != "CANCELLED" {
+		return false, nil
+	}
+
+	// A rejected or already handled event leaves the order unpaid; keep backing off until it
+	// ages out of the window rather than re-checking it every scan
+	if err := r.orderService.settleFromCheck(ctx, order, check, ActorReconciler); err != nil {
+		return false, err
+	}
+	logrus.Infof("Payment reconciler: order %s settled as %s", order.OrderNumber, check.Data.PaymentStatus)
+	return true, nil
+}
+
+// scheduleRetry doubles the order's delay, starting from the scan interval
+func (r *PaymentReconciler) scheduleRetry(orderID uuid.UUID, now time.Time) {
+	state, ok := r.backoff[orderID]
+	if !ok {
+		state = &reconcileBackoff{}
+		r.backoff[orderID] = state
+	}
+	state.attempts++
+	state.next = now.Add(backoffDelay(state.attempts, r.config.Interval, r.config.MaxBackoff))
+}
+
+// backoffDelay returns interval * 2^(attempts-1), capped at maxBackoff (all in seconds)
+func backoffDelay(attempts, interval, maxBackoff int) time.Duration {
+	delay := time.Duration(interval) * time.Second
+	max := time.Duration(maxBackoff) * time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
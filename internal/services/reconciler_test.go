@@ -0,0 +1,15 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	assert.Equal(t, 60*time.Second, backoffDelay(1, 60, 1800))
+	assert.Equal(t, 120*time.Second, backoffDelay(2, 60, 1800))
+	assert.Equal(t, 480*time.Second, backoffDelay(4, 60, 1800))
+	assert.Equal(t, 1800*time.Second, backoffDelay(10, 60, 1800))
+}
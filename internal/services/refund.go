@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefundNotAllowed is returned when an order has no settled QPay payment to refund
+	ErrRefundNotAllowed = errors.New("order has no refundable payment")
+	// ErrInvalidRefundAmount is returned when a refund exceeds what is left of the payment
+	ErrInvalidRefundAmount = errors.New("invalid refund amount")
+)
+
+// RefundResult describes a refund that was issued for an order
+type RefundResult struct {
+	Order        *models.Order              `json:"order"`
+	Refund       *models.PaymentTransaction `json:"refund"`
+	RefundedMNT  money.Decimal              `json:"refunded_mnt"`  // total refunded so far
+	RemainingMNT money.Decimal              `json:"remaining_mnt"` // still refundable
+}
+
+// RefundOrder refunds a paid order through QPay and records a refund transaction with a
+// negative amount. An amount of zero refunds whatever is left; once nothing is left the
+// order moves to refunded.
+func (o *OrderService) RefundOrder(ctx context.Context, orderID uuid.UUID, amount money.Decimal, reason, actor string) (*RefundResult, error) {
+	if amount.Sign() < 0 {
+		return nil, fmt.Errorf("%w: amount must not be negative", ErrInvalidRefundAmount)
+	}
+	// Once QPay has moved money the refund row must be written, so a disconnecting
+	// client does not cancel the refund; the QPay client timeout still bounds it
+	ctx = context.WithoutCancel(ctx)
+
+	var result *RefundResult
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent refunds cannot both pass the remaining-amount check
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return fmt.Errorf("lock order: %w", err)
+		}
+		if !CanTransition(order.Status, models.OrderStatusRefunded) {
+			return fmt.Errorf("%w: order is %s", ErrInvalidTransition, order.Status)
+		}
+
+		var payment models.PaymentTransaction
+		if err := tx.Where("order_id = ? AND type = ? AND status = ?", order.ID, models.PaymentTransactionTypePayment, "paid").
+			First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefundNotAllowed
+			}
+			return fmt.Errorf("failed to load payment: %v", err)
+		}
+		if payment.QPayTransactionID == "" {
+			// Refunds are issued against the QPay payment ID, never the invoice ID
+			return fmt.Errorf("%w: payment has no QPay payment ID", ErrRefundNotAllowed)
+		}
+
+		var refunded money.Decimal
+		if err := tx.Model(&models.PaymentTransaction{}).
+			Where("order_id = ? AND type = ? AND status = ?", order.ID, models.PaymentTransactionTypeRefund, "refunded").
+			Select("COALESCE(SUM(-amount), 0)").Scan(&refunded).Error; err != nil {
+			return fmt.Errorf("failed to sum refunds: %v", err)
+		}
+
+		remaining := payment.Amount.Sub(refunded)
+		if amount.IsZero() {
+			amount = remaining
+		} else if !amount.Equal(money.New(amount, money.MNT).Settled().Amount) {
+			// QPay refunds whole tugrik; the recorded refund must match what it pays out
+			return fmt.Errorf("%w: %s is not a whole tugrik amount", ErrInvalidRefundAmount, amount)
+		}
+		if amount.Sign() <= 0 || amount.GreaterThan(remaining) {
+			return fmt.Errorf("%w: %s requested, %s refundable", ErrInvalidRefundAmount, amount, remaining)
+		}
+
+		// A full refund is sent without an amount so QPay refunds the whole payment
+		qpayAmount := amount
+		if amount.Equal(payment.Amount) {
+			qpayAmount = money.Zero
+		}
+		if err := o.qpayService.RefundPayment(ctx, payment.QPayTransactionID, qpayAmount, reason); err != nil {
+			return fmt.Errorf("QPay refund failed: %w", err)
+		}
+
+		transactionData, _ := json.Marshal(map[string]interface{}{
+			"payment_id": payment.ID,
+			"reason":     reason,
+			"actor":      actor,
+		})
+		refund := models.PaymentTransaction{
+			OrderID:           order.ID,
+			QPayTransactionID: payment.QPayTransactionID,
+			Amount:            amount.Neg(),
+			Status:            "refunded",
+			Type:              models.PaymentTransactionTypeRefund,
+			PaymentMethod:     payment.PaymentMethod,
+			TransactionData:   string(transactionData),
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			// QPay has already refunded; make sure the discrepancy is visible
+			logrus.Errorf("Refund of %s for order %s succeeded at QPay but was not recorded: %v", amount, order.OrderNumber, err)
+			return fmt.Errorf("failed to record refund: %v", err)
+		}
+
+		remaining = remaining.Sub(amount)
+		if remaining.Sign() <= 0 {
+			historyReason := reason
+			if historyReason == "" {
+				historyReason = "payment fully refunded"
+			}
+			if err := o.transitionTx(tx, &order, models.OrderStatusRefunded, actor, historyReason); err != nil {
+				return err
+			}
+		}
+
+		result = &RefundResult{
+			Order:        &order,
+			Refund:       &refund,
+			RefundedMNT:  refunded.Add(amount),
+			RemainingMNT: remaining,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// refundFailedProvisioning gives the customer their money back when the eSIM cannot be issued
+func (o *OrderService) refundFailedProvisioning(ctx context.Context, order *models.Order, provisionErr error) {
+	reason := fmt.Sprintf("automatic refund: eSIM provisioning failed: %v", provisionErr)
+	if _, err := o.RefundOrder(ctx, order.ID, money.Zero, reason, ActorSystem); err != nil {
+		logrus.Errorf("Automatic refund for order %s failed: %v", order.OrderNumber, err)
+		return
+	}
+	order.Status = models.OrderStatusRefunded
+	logrus.Infof("Order %s refunded after provisioning failure", order.OrderNumber)
+}
+
+// cancelUnpaidInvoice cancels the QPay invoice of an order that is about to be cancelled so
+// the customer can no longer pay it
+func (o *OrderService) cancelUnpaidInvoice(ctx context.Context, order *models.Order) error {
+	if order.QPayInvoiceID == "" {
+		return nil
+	}
+	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusAwaitingPayment {
+		return nil
+	}
+	if err := o.qpayService.CancelInvoice(ctx, order.QPayInvoiceID); err != nil {
+		return fmt.Errorf("failed to cancel QPay invoice: %w", err)
+	}
+	return nil
+}
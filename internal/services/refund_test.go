@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paidOrder creates an order and settles it through the payment callback
+func paidOrder(t *testing.T, env *orderTestEnv) (*OrderResponse, *qpaysim.Invoice) {
+	t.Helper()
+	created := env.createOrder(t)
+	inv := env.pay(t, created.OrderNumber, 0)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), callback(t, inv, qpaysim.StatusPaid), nil))
+	return created, inv
+}
+
+func TestRefundOrderPartialThenFull(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created, inv := paidOrder(t, env)
+	ctx := context.Background()
+
+	result, err := env.orders.RefundOrder(ctx, created.ID, money.FromInt(5000), "partial", AdminActor("admin"))
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPaid, result.Order.Status)
+	assert.True(t, result.RemainingMNT.Equal(money.FromInt(13700)))
+	assert.Equal(t, inv.TransactionID, result.Refund.QPayTransactionID)
+	assert.True(t, result.Refund.Amount.Equal(money.FromInt(-5000)))
+	assert.Equal(t, float64(5000), env.invoice(t, created.OrderNumber).RefundedAmount)
+
+	_, err = env.orders.RefundOrder(ctx, created.ID, money.MustParse("0.5"), "fraction", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrInvalidRefundAmount)
+	_, err = env.orders.RefundOrder(ctx, created.ID, money.FromInt(13701), "too much", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrInvalidRefundAmount)
+
+	// Zero refunds the rest and closes the order
+	result, err = env.orders.RefundOrder(ctx, created.ID, money.Zero, "", AdminActor("admin"))
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusRefunded, env.order(t, created.ID).Status)
+	assert.True(t, result.RefundedMNT.Equal(money.FromInt(18700)))
+	assert.True(t, result.RemainingMNT.IsZero())
+	refunded := env.invoice(t, created.OrderNumber)
+	assert.Equal(t, qpaysim.StatusRefunded, refunded.Status)
+	assert.Equal(t, float64(18700), refunded.RefundedAmount)
+
+	_, err = env.orders.RefundOrder(ctx, created.ID, money.Zero, "again", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestRefundOrderEligibility(t *testing.T) {
+	env := newOrderTestEnv(t)
+	ctx := context.Background()
+
+	unpaid := env.createOrder(t)
+	_, err := env.orders.RefundOrder(ctx, unpaid.ID, money.Zero, "unpaid", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = env.orders.RefundOrder(ctx, unpaid.ID, money.FromInt(-1), "negative", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrInvalidRefundAmount)
+
+	// Without the QPay payment ID there is nothing to refund against
+	paid, _ := paidOrder(t, env)
+	require.NoError(t, env.db.Model(&models.PaymentTransaction{}).Where("order_id = ?", paid.ID).
+		Update("qpay_transaction_id", "").Error)
+	_, err = env.orders.RefundOrder(ctx, paid.ID, money.Zero, "no payment ID", AdminActor("admin"))
+	assert.ErrorIs(t, err, ErrRefundNotAllowed)
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, paid.ID).Status)
+}
+
+func TestCancelOrderCancelsInvoice(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+
+	order, err := env.orders.UpdateOrderStatus(context.Background(), created.ID, models.OrderStatusCancelled, AdminActor("admin"), "customer asked")
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	assert.Equal(t, qpaysim.StatusCancelled, env.invoice(t, created.OrderNumber).Status)
+
+	// Paying the cancelled invoice is no longer possible
+	_, err = env.sim.MarkPaid(env.invoice(t, created.OrderNumber).InvoiceID, 0)
+	assert.Error(t, err)
+}
+
+func TestRefundAfterPaymentSettledByInitiatePayment(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	inv := env.pay(t, created.OrderNumber, 0)
+
+	// The callback never arrived; asking to pay again finds the payment and settles it
+	_, err := env.orders.InitiatePayment(context.Background(), created.OrderNumber)
+	assert.EqualError(t, err, "payment already completed")
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+
+	var payment models.PaymentTransaction
+	require.NoError(t, env.db.Where("order_id = ? AND type = ?", created.ID, models.PaymentTransactionTypePayment).First(&payment).Error)
+	assert.Equal(t, inv.TransactionID, payment.QPayTransactionID)
+
+	_, err = env.orders.RefundOrder(context.Background(), created.ID, money.Zero, "refund", AdminActor("admin"))
+	require.NoError(t, err)
+	assert.Equal(t, qpaysim.StatusRefunded, env.invoice(t, created.OrderNumber).Status)
+}
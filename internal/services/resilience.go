@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"esim-platform/internal/config"
+)
+
+// ErrUpstreamUnavailable is wrapped by errors returned while an upstream API is considered
+// down, either because its circuit breaker is open or because it answered with a 5xx.
+var ErrUpstreamUnavailable = errors.New("upstream service unavailable")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitOpenError is returned without calling the upstream while its circuit is open
+type CircuitOpenError struct {
+	Upstream   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s unavailable: circuit open, retry in %s", e.Upstream, e.RetryAfter.Round(time.Second))
+}
+
+func (e *CircuitOpenError) Unwrap() error { return ErrUpstreamUnavailable }
+
+// CircuitStatus is a snapshot of a circuit breaker for the admin status endpoint
+type CircuitStatus struct {
+	State         string     `json:"state"`
+	Failures      int        `json:"consecutive_failures"`
+	OpenedAt      *time.Time `json:"opened_at,omitempty"`
+	RetryAt       *time.Time `json:"retry_at,omitempty"`
+	LastFailure   string     `json:"last_failure,omitempty"`
+	TimesOpened   int        `json:"times_opened"`
+	RejectedCalls int        `json:"rejected_calls"`
+}
+
+// transientError marks failures worth retrying and counted by the circuit breaker:
+// network errors, timeouts and 5xx or 429 responses
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+func transient(err error) error { return &transientError{err: err} }
+
+func isTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t)
+}
+
+// IsUpstreamUnavailable reports whether err means an upstream API is down or unreachable,
+// as opposed to rejecting the request. Handlers answer these with 503.
+func IsUpstreamUnavailable(err error) bool {
+	return errors.Is(err, ErrUpstreamUnavailable) || isTransient(err)
+}
+
+// statusError turns a 5xx or 429 response into a transient error, or returns nil
+func statusError(upstream string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return transient(fmt.Errorf("%s returned status %d", upstream, resp.StatusCode))
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return transient(fmt.Errorf("%s returned status %d: %w", upstream, resp.StatusCode, ErrUpstreamUnavailable))
+	}
+	return nil
+}
+
+// circuitBreaker opens after threshold consecutive transient failures and rejects calls
+// for cooldown. It then lets a single trial call through: success closes the circuit,
+// failure opens it again. A threshold of zero disables the breaker.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	upstream  string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state       string
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastFailure string
+	opened      int
+	rejected    int
+}
+
+func newCircuitBreaker(upstream string, cfg config.ResilienceConfig) *circuitBreaker {
+	cooldown := time.Duration(cfg.BreakerCooldown) * time.Second
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &circuitBreaker{upstream: upstream, threshold: cfg.BreakerThreshold, cooldown: cooldown, now: time.Now, state: CircuitClosed}
+}
+
+// allow reports whether a call may proceed. Every allowed call must be followed by
+// record or release.
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if wait := b.openedAt.Add(b.cooldown).Sub(b.now()); wait > 0 {
+			b.rejected++
+			return &CircuitOpenError{Upstream: b.upstream, RetryAfter: wait}
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			b.rejected++
+			return &CircuitOpenError{Upstream: b.upstream, RetryAfter: time.Second}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record reports the outcome of an allowed call. Only transient errors count as
+// failures; business errors show that the upstream is reachable.
+func (b *circuitBreaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil || !isTransient(err) {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	b.lastFailure = err.Error()
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		if b.state != CircuitOpen {
+			b.opened++
+		}
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release ends an allowed call without an outcome, e.g. when the caller gave up
+func (b *circuitBreaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// do runs fn under the breaker. Failures caused by ctx ending are not counted.
+func (b *circuitBreaker) do(ctx context.Context, fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return err
+	}
+	b.record(err)
+	return err
+}
+
+func (b *circuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := CircuitStatus{
+		State:         b.state,
+		Failures:      b.failures,
+		LastFailure:   b.lastFailure,
+		TimesOpened:   b.opened,
+		RejectedCalls: b.rejected,
+	}
+	if b.state != CircuitClosed {
+		openedAt, retryAt := b.openedAt, b.openedAt.Add(b.cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// Open reports whether calls are currently being rejected
+func (b *circuitBreaker) Open() bool {
+	if b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == CircuitOpen && b.now().Before(b.openedAt.Add(b.cooldown))
+}
+
+// retryPolicy retries idempotent calls that failed with a transient error
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func newRetryPolicy(cfg config.ResilienceConfig) retryPolicy {
+	p := retryPolicy{
+		attempts:  cfg.RetryAttempts,
+		baseDelay: time.Duration(cfg.RetryBaseMS) * time.Millisecond,
+		maxDelay:  time.Duration(cfg.RetryMaxMS) * time.Millisecond,
+	}
+	if p.attempts < 1 {
+		p.attempts = 1
+	}
+	if p.maxDelay < p.baseDelay {
+		p.maxDelay = p.baseDelay
+	}
+	return p
+}
+
+// delay returns a jittered backoff for the given retry (1 = first retry), drawn
+// uniformly between half and all of the capped exponential delay
+func (p retryPolicy) delay(retry int) time.Duration {
+	d := p.baseDelay
+	for i := 1; i < retry && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	if d > p.maxDelay {
+		d = p.maxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// do calls fn until it succeeds, fails with a non-transient error, runs out of attempts
+// or ctx ends. An open circuit is not retried.
+func (p retryPolicy) do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.attempts || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
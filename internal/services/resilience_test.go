@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker("test", config.ResilienceConfig{BreakerThreshold: 2, BreakerCooldown: 30})
+	b.now = func() time.Time { return now }
+	fail := func() error { return transient(errors.New("connection refused")) }
+	ctx := context.Background()
+
+	// Business errors do not count against the upstream
+	assert.Error(t, b.do(ctx, func() error { return errors.New("sold out") }))
+	assert.Error(t, b.do(ctx, fail))
+	assert.Equal(t, CircuitClosed, b.Status().State)
+	assert.Error(t, b.do(ctx, fail))
+	assert.True(t, b.Open())
+
+	var open *CircuitOpenError
+	err := b.do(ctx, func() error { t.Fatal("called while open"); return nil })
+	require.True(t, errors.As(err, &open))
+	assert.ErrorIs(t, err, ErrUpstreamUnavailable)
+	assert.Equal(t, 30*time.Second, open.RetryAfter)
+
+	// After the cooldown one trial call goes through; failing it reopens the circuit
+	now = now.Add(31 * time.Second)
+	assert.Error(t, b.do(ctx, fail))
+	assert.True(t, b.Open())
+
+	now = now.Add(31 * time.Second)
+	require.NoError(t, b.do(ctx, func() error { return nil }))
+	status := b.Status()
+	assert.Equal(t, CircuitClosed, status.State)
+	assert.Equal(t, 2, status.TimesOpened)
+	assert.Equal(t, 1, status.RejectedCalls)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := newRetryPolicy(config.ResilienceConfig{RetryAttempts: 3, RetryBaseMS: 1, RetryMaxMS: 2})
+
+	var calls int
+	err := p.do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return transient(errors.New("timeout"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = p.do(context.Background(), func() error {
+		calls++
+		return errors.New("invalid package")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	for retry := 1; retry < 10; retry++ {
+		d := p.delay(retry)
+		assert.True(t, d >= 0 && d <= 2*time.Millisecond, "delay %s out of range", d)
+	}
+}
+
+func TestRoamWiFiServesCachedCatalogWhileDown(t *testing.T) {
+	var down, orders int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 && r.URL.Path != "/api_order/login" {
+			w.WriteHeader(http.StatusBadGateway)
+			if r.URL.Path == "/api_order/createOrder" {
+				atomic.AddInt32(&orders, 1)
+			}
+			return
+		}
+		switch r.URL.Path {
+		case "/api_order/login":
+			w.Write([]byte(`{"code":"0","data":{"token":"t"}}`))
+		case "/api_esim/getSkus":
+			w.Write([]byte(`{"code":"0","data":[{"skuid":1,"display":"Japan"}]}`))
+		case "/api_esim/getPackages":
+			w.Write([]byte(`{"code":"0","data":{"skuid":"1","esimPackageDtoList":[{"apiCode":"A1","days":7,"price":"4.5","priceid":11}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	rw := NewRoamWiFiService(config.RoamWiFiConfig{
+		APIURL: server.URL, PhoneNumber: "99999999", Password: "secret",
+		Resilience: config.ResilienceConfig{RetryAttempts: 2, RetryBaseMS: 1, RetryMaxMS: 1, BreakerThreshold: 3, BreakerCooldown: 60},
+	})
+	ctx := context.Background()
+	_, err := rw.GetPackagesBySKU(ctx, "1")
+	require.NoError(t, err)
+	_, err = rw.GetSKUList(ctx)
+	require.NoError(t, err)
+
+	atomic.StoreInt32(&down, 1)
+
+	packages, err := rw.GetPackagesBySKU(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "A1", packages[0].PackageID)
+	skus, err := rw.GetSKUList(ctx)
+	require.NoError(t, err)
+	assert.Len(t, skus, 1)
+
+	// Two reads with two attempts each have opened the circuit
+	status := rw.Status()
+	assert.False(t, status.Available)
+	assert.Equal(t, CircuitOpen, status.Circuit.State)
+
+	_, err = rw.GetPackagesBySKU(ctx, "2")
+	assert.True(t, IsUpstreamUnavailable(err))
+	_, err = rw.CreateOrder(ctx, OrderRequest{SKUID: "1", PackageID: "A1", Quantity: 1})
+	assert.ErrorIs(t, err, ErrUpstreamUnavailable)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&orders))
+}
+
+func TestQPayRetriesOnlyIdempotentCalls(t *testing.T) {
+	var invoices, checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/token":
+			w.Write([]byte(`{"access_token":"a","expires_in":3600}`))
+		case "/invoice":
+			atomic.AddInt32(&invoices, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/payment/check":
+			if atomic.AddInt32(&checks, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"code":0,"data":{"invoice_id":"inv-1","payment_status":"PAID"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	q := NewQPayService(config.QPayConfig{
+		Endpoint: srv.URL, Username: "merchant", Password: "secret",
+		Resilience: config.ResilienceConfig{RetryAttempts: 3, RetryBaseMS: 1, RetryMaxMS: 1},
+	})
+	ctx := context.Background()
+
+	_, err := q.CreateInvoice(ctx, "ESIM1", "test", "", money.FromInt(1000))
+	assert.True(t, IsUpstreamUnavailable(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoices))
+
+	check, err := q.CheckPayment(ctx, "inv-1")
+	require.NoError(t, err)
+	assert.Equal(t, "PAID", check.Data.PaymentStatus)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&checks))
+}
@@ -2,13 +2,13 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,18 +16,21 @@ import (
 
 	"esim-platform/internal/config"
 	"sync"
+
+	"github.com/sirupsen/logrus"
 )
 
 type RoamWiFiService struct {
-	config      config.RoamWiFiConfig
-	client      *http.Client
-	token       string
-	tokenExpiry time.Time
-
-	// SKU cache
-	skuCache       []SKUInfo
-	skuCacheExpiry time.Time
-	cacheMu        sync.RWMutex
+	config  config.RoamWiFiConfig
+	client  *http.Client
+	session *roamWiFiSession
+	breaker *circuitBreaker
+	retry   retryPolicy
+
+	// Last good SKU and package lists, served while the provider is unavailable
+	cacheMu       sync.RWMutex
+	lastSKUs      []SKUInfo
+	packagesCache map[string]*roamWiFiPackagesData
 }
 
 type PackageInfo struct {
@@ -73,8 +76,20 @@ type RoamWiFiResponse struct {
 }
 
 func NewRoamWiFiService(cfg config.RoamWiFiConfig) *RoamWiFiService {
-	client := &http.Client{Timeout: 30 * time.Second}
-	return &RoamWiFiService{config: cfg, client: client}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	r := &RoamWiFiService{
+		config:        cfg,
+		client:        client,
+		breaker:       newCircuitBreaker(ProviderRoamWiFi, cfg.Resilience),
+		retry:         newRetryPolicy(cfg.Resilience),
+		packagesCache: make(map[string]*roamWiFiPackagesData),
+	}
+	r.session = newRoamWiFiSession(r.login, time.Duration(cfg.TokenTTLMinutes)*time.Minute)
+	return r
 }
 
 // --- Detailed package response modeling (new) ---
@@ -128,177 +143,115 @@ type RoamWiFiPackagesResponse struct {
 	Packages       []RoamWiFiPackage      `json:"packages"`
 }
 
-// GetPackagesDetailed returns rich provider data mapped into internal structs
-func (r *RoamWiFiService) GetPackagesDetailed(skuID string) (*RoamWiFiPackagesResponse, error) {
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
-	apiURL := fmt.Sprintf("%s/api_esim/getPackages", r.config.APIURL)
-	params := map[string]string{"token": r.token, "skuId": skuID}
-	params["sign"] = r.generateSignature(params)
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := apiURL + "?" + values.Encode()
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read failed: %v", err)
-	}
-	fmt.Printf("GetPackagesDetailed URL=%s RAW=%s\n", fullURL, string(body))
-	var raw map[string]any
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return nil, fmt.Errorf("decode failed: %v", err)
-	}
-	code := fmt.Sprint(raw["code"])
-	if code != "0" && code != "200" {
-		return nil, fmt.Errorf("API error code=%s", code)
-	}
-	data, ok := raw["data"].(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("unexpected data structure")
-	}
-	respObj := &RoamWiFiPackagesResponse{}
-	if v, ok := data["skuid"].(float64); ok {
-		respObj.SKUId = int(v)
-	}
-	if v, ok := data["display"].(string); ok {
-		respObj.Display = v
-	}
-	if v, ok := data["displayEn"].(string); ok {
-		respObj.DisplayEn = v
-	}
-	if v, ok := data["countrycode"].(string); ok {
-		respObj.CountryCode = v
-	} else if v2, ok2 := data["countrycode"].(float64); ok2 {
-		respObj.CountryCode = strconv.Itoa(int(v2))
-	}
-	if v, ok := data["imageUrl"].(string); ok {
-		respObj.ImageURL = v
-	}
-	if arr, ok := data["supportCountry"].([]interface{}); ok {
-		for _, c := range arr {
-			if s, ok := c.(string); ok {
-				respObj.SupportCountry = append(respObj.SupportCountry, s)
-			}
+// roamWiFiPackagesData is the data of an api_esim/getPackages response
+type roamWiFiPackagesData struct {
+	SKUID          roamWiFiNumber `json:"skuid"`
+	Display        string         `json:"display"`
+	DisplayEn      string         `json:"displayEn"`
+	CountryCode    roamWiFiString `json:"countrycode"`
+	ImageURL       string         `json:"imageUrl"`
+	SupportCountry []string       `json:"supportCountry"`
+	CountryImages  []struct {
+		CountryCode roamWiFiNumber `json:"countryCode"`
+		ImageURL    string         `json:"imageUrl"`
+		Name        string         `json:"name"`
+		NameEn      string         `json:"nameEn"`
+	} `json:"countryImageUrlDtoList"`
+	Packages []roamWiFiPackageData `json:"esimPackageDtoList"`
+}
+
+type roamWiFiPackageData struct {
+	APICode           string                   `json:"apiCode"`
+	Flows             roamWiFiNumber           `json:"flows"`
+	Unit              string                   `json:"unit"`
+	Days              roamWiFiNumber           `json:"days"`
+	Price             roamWiFiNumber           `json:"price"`
+	PriceID           roamWiFiNumber           `json:"priceid"`
+	FlowType          roamWiFiNumber           `json:"flowType"`
+	ShowName          string                   `json:"showName"`
+	PID               roamWiFiNumber           `json:"pid"`
+	Premark           string                   `json:"premark"`
+	Overlay           roamWiFiNumber           `json:"overlay"`
+	ExpireDays        roamWiFiNumber           `json:"expireDays"`
+	Network           []RoamWiFiPackageNetwork `json:"networkDtoList"`
+	SupportDaypass    roamWiFiNumber           `json:"supportDaypass"`
+	OpenCardFee       roamWiFiNumber           `json:"openCardFee"`
+	MinDay            roamWiFiNumber           `json:"minDay"`
+	SingleDiscountDay roamWiFiNumber           `json:"singleDiscountDay"`
+	SingleDiscount    roamWiFiNumber           `json:"singleDiscount"`
+	MaxDiscount       roamWiFiNumber           `json:"maxDiscount"`
+	MaxDay            roamWiFiNumber           `json:"maxDay"`
+	MustDate          roamWiFiNumber           `json:"mustDate"`
+	HadDaypassDetail  roamWiFiNumber           `json:"hadDaypassDetail"`
+}
+
+// getPackages fetches the package list of a SKU. While the provider is unavailable the
+// last list fetched for the SKU is returned instead.
+func (r *RoamWiFiService) getPackages(ctx context.Context, skuID string) (*roamWiFiPackagesData, error) {
+	var data roamWiFiPackagesData
+	if err := r.read(ctx, "/api_esim/getPackages", map[string]string{"skuId": skuID}, &data); err != nil {
+		r.cacheMu.RLock()
+		cached, ok := r.packagesCache[skuID]
+		r.cacheMu.RUnlock()
+		if ok && IsUpstreamUnavailable(err) {
+			logrus.WithError(err).WithField("sku_id", skuID).Warn("RoamWiFi unavailable, serving cached packages")
+			return cached, nil
 		}
+		return nil, err
 	}
-	if imgs, ok := data["countryImageUrlDtoList"].([]interface{}); ok {
-		for _, im := range imgs {
-			if m, ok := im.(map[string]interface{}); ok {
-				ci := RoamWiFiCountryImage{}
-				if v, ok := m["countryCode"].(float64); ok {
-					ci.CountryCode = int(v)
-				}
-				if v, ok := m["imageUrl"].(string); ok {
-					ci.ImageURL = v
-				}
-				if v, ok := m["name"].(string); ok {
-					ci.Name = v
-				}
-				if v, ok := m["nameEn"].(string); ok {
-					ci.NameEn = v
-				}
-				respObj.CountryImages = append(respObj.CountryImages, ci)
-			}
-		}
+	r.cacheMu.Lock()
+	r.packagesCache[skuID] = &data
+	r.cacheMu.Unlock()
+	return &data, nil
+}
+
+// GetPackagesDetailed returns rich provider data mapped into internal structs
+func (r *RoamWiFiService) GetPackagesDetailed(ctx context.Context, skuID string) (*RoamWiFiPackagesResponse, error) {
+	data, err := r.getPackages(ctx, skuID)
+	if err != nil {
+		return nil, err
 	}
-	// packages
-	if list, ok := data["esimPackageDtoList"].([]interface{}); ok {
-		for _, item := range list {
-			if m, ok := item.(map[string]interface{}); ok {
-				p := RoamWiFiPackage{}
-				if v, ok := m["apiCode"].(string); ok {
-					p.APICode = v
-				}
-				if v, ok := m["flows"].(float64); ok {
-					p.Flows = v
-				}
-				if v, ok := m["unit"].(string); ok {
-					p.Unit = v
-				}
-				if v, ok := m["days"].(float64); ok {
-					p.Days = int(v)
-				}
-				if v, ok := m["price"].(float64); ok {
-					p.Price = v
-				}
-				if v, ok := m["priceid"].(float64); ok {
-					p.PriceID = int(v)
-				}
-				if v, ok := m["flowType"].(float64); ok {
-					p.FlowType = int(v)
-				}
-				if v, ok := m["showName"].(string); ok {
-					p.ShowName = v
-				}
-				if v, ok := m["pid"].(float64); ok {
-					p.PID = int(v)
-				}
-				if v, ok := m["premark"].(string); ok {
-					p.Premark = v
-				}
-				if v, ok := m["overlay"].(float64); ok {
-					p.Overlay = int(v)
-				}
-				if v, ok := m["expireDays"].(float64); ok {
-					p.ExpireDays = int(v)
-				}
-				if v, ok := m["supportDaypass"].(float64); ok {
-					p.SupportDaypass = int(v)
-				}
-				if v, ok := m["openCardFee"].(float64); ok {
-					p.OpenCardFee = v
-				}
-				if v, ok := m["minDay"].(float64); ok {
-					p.MinDay = int(v)
-				}
-				if v, ok := m["singleDiscountDay"].(float64); ok {
-					p.SingleDiscountDay = int(v)
-				}
-				if v, ok := m["singleDiscount"].(float64); ok {
-					p.SingleDiscount = int(v)
-				}
-				if v, ok := m["maxDiscount"].(float64); ok {
-					p.MaxDiscount = int(v)
-				}
-				if v, ok := m["maxDay"].(float64); ok {
-					p.MaxDay = int(v)
-				}
-				if v, ok := m["mustDate"].(float64); ok {
-					p.MustDate = int(v)
-				}
-				if v, ok := m["hadDaypassDetail"].(float64); ok {
-					p.HadDaypassDetail = int(v)
-				}
-				if nets, ok := m["networkDtoList"].([]interface{}); ok {
-					for _, n := range nets {
-						if nm, ok := n.(map[string]interface{}); ok {
-							nw := RoamWiFiPackageNetwork{}
-							if v, ok := nm["type"].(string); ok {
-								nw.Type = v
-							}
-							if v, ok := nm["operator"].(string); ok {
-								nw.Operator = v
-							}
-							if v, ok := nm["namecn"].(string); ok {
-								nw.NameCN = v
-							}
-							if v, ok := nm["nameen"].(string); ok {
-								nw.NameEN = v
-							}
-							p.Network = append(p.Network, nw)
-						}
-					}
-				}
-				respObj.Packages = append(respObj.Packages, p)
-			}
-		}
+	respObj := &RoamWiFiPackagesResponse{
+		SKUId:          data.SKUID.Int(),
+		Display:        data.Display,
+		DisplayEn:      data.DisplayEn,
+		CountryCode:    string(data.CountryCode),
+		SupportCountry: data.SupportCountry,
+		ImageURL:       data.ImageURL,
+	}
+	for _, ci := range data.CountryImages {
+		respObj.CountryImages = append(respObj.CountryImages, RoamWiFiCountryImage{
+			CountryCode: ci.CountryCode.Int(),
+			ImageURL:    ci.ImageURL,
+			Name:        ci.Name,
+			NameEn:      ci.NameEn,
+		})
+	}
+	for _, p := range data.Packages {
+		respObj.Packages = append(respObj.Packages, RoamWiFiPackage{
+			APICode:           p.APICode,
+			Flows:             float64(p.Flows),
+			Unit:              p.Unit,
+			Days:              p.Days.Int(),
+			Price:             float64(p.Price),
+			PriceID:           p.PriceID.Int(),
+			FlowType:          p.FlowType.Int(),
+			ShowName:          p.ShowName,
+			PID:               p.PID.Int(),
+			Premark:           p.Premark,
+			Overlay:           p.Overlay.Int(),
+			ExpireDays:        p.ExpireDays.Int(),
+			Network:           p.Network,
+			SupportDaypass:    p.SupportDaypass.Int(),
+			OpenCardFee:       float64(p.OpenCardFee),
+			MinDay:            p.MinDay.Int(),
+			SingleDiscountDay: p.SingleDiscountDay.Int(),
+			SingleDiscount:    p.SingleDiscount.Int(),
+			MaxDiscount:       p.MaxDiscount.Int(),
+			MaxDay:            p.MaxDay.Int(),
+			MustDate:          p.MustDate.Int(),
+			HadDaypassDetail:  p.HadDaypassDetail.Int(),
+		})
 	}
 	return respObj, nil
 }
@@ -338,163 +291,87 @@ func (r *RoamWiFiService) generateSignature(params map[string]string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// login authenticates with RoamWiFi API and gets token
-func (r *RoamWiFiService) login() error {
-	// Use the exact same URL pattern as working code
-	loginURL := fmt.Sprintf("%s/api_order/login", r.config.APIURL)
-
-	// Create parameters exactly as in the working code
-	params := map[string]string{
-		"phonenumber": r.config.PhoneNumber,
-		"password":    r.config.Password,
-	}
-
+// login authenticates with RoamWiFi API and returns a new token. Callers go through
+// r.session, which caches the token and single-flights concurrent logins.
+func (r *RoamWiFiService) login(ctx context.Context) (string, error) {
 	// If credentials are empty, return an error immediately
 	if r.config.PhoneNumber == "" || r.config.Password == "" {
-		return fmt.Errorf("missing credentials: phonenumber='%s', password='%s'", r.config.PhoneNumber, r.config.Password)
+		return "", fmt.Errorf("missing credentials: phonenumber='%s', password set=%t", r.config.PhoneNumber, r.config.Password != "")
 	}
 
-	// Generate signature exactly like working code
-	signature := r.generateSignature(params)
-	params["sign"] = signature
-
-	// Build query string exactly like working code
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := loginURL + "?" + values.Encode()
-
-	fmt.Printf("Login URL: %s\n", fullURL)
-
-	// Make POST request exactly like working code
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
+	env, body, err := r.send(ctx, "/api_order/login", map[string]string{
+		"phonenumber": r.config.PhoneNumber,
+		"password":    r.config.Password,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to make login request: %v", err)
+		return "", fmt.Errorf("login request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read login response body: %v", err)
+	if err := env.err("/api_order/login"); err != nil {
+		return "", err
 	}
-
-	fmt.Printf("Login Response: %s\n", string(body))
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to decode login response: %v", err)
+	var data struct {
+		Token string `json:"token"`
 	}
-
-	// Check for successful login and extract token exactly like working code
-	if dataField, ok := result["data"].(map[string]interface{}); ok {
-		if token, exists := dataField["token"].(string); exists {
-			r.token = token
-			r.tokenExpiry = time.Now().Add(24 * time.Hour)
-			return nil
-		}
+	if err := json.Unmarshal(env.Data, &data); err != nil || data.Token == "" {
+		return "", fmt.Errorf("token not found in response: %s", truncateForLog(string(body), 200))
 	}
-
-	return fmt.Errorf("token not found in response: %v", result)
+	return data.Token, nil
 }
 
-// ensureAuthenticated ensures we have a valid token
-func (r *RoamWiFiService) ensureAuthenticated() error {
-	// Always force a fresh login for debugging
-	return r.login()
+// Status reports the state of the cached login session and the circuit breaker. It is
+// unhealthy after a failed login or a rejected token until the next successful login, and
+// while the circuit is open.
+func (r *RoamWiFiService) Status() ProviderStatus {
+	session := r.session.Status()
+	circuit := r.breaker.Status()
+	healthy := (session.Authenticated || session.LastErrorAt == nil) && !r.breaker.Open()
+	return ProviderStatus{Provider: ProviderRoamWiFi, Healthy: healthy, Available: !r.breaker.Open(), Session: &session, Circuit: &circuit}
 }
 
-// GetSKUList retrieves the list of available eSIM SKUs from production API
-func (r *RoamWiFiService) GetSKUList() ([]SKUInfo, error) {
-	// Fast path: serve from cache if valid
-	r.cacheMu.RLock()
-	if time.Now().Before(r.skuCacheExpiry) && len(r.skuCache) > 0 {
-		cached := make([]SKUInfo, len(r.skuCache))
-		copy(cached, r.skuCache)
-		r.cacheMu.RUnlock()
-		return cached, nil
-	}
-	r.cacheMu.RUnlock()
+// roamWiFiSKUData is one entry of the api_esim/getSkus and getSkuByGroup responses
+type roamWiFiSKUData struct {
+	SKUID       roamWiFiNumber `json:"skuid"`
+	Display     string         `json:"display"`
+	CountryCode roamWiFiString `json:"countryCode"`
+}
 
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
+func (d roamWiFiSKUData) info() SKUInfo {
+	return SKUInfo{SKUID: d.SKUID.Int(), Display: d.Display, CountryCode: string(d.CountryCode)}
+}
 
-	apiURL := fmt.Sprintf("%s/api_esim/getSkus", r.config.APIURL)
-	params := map[string]string{"token": r.token}
-	params["sign"] = r.generateSignature(params)
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
+// getSKUs fetches a SKU list from path
+func (r *RoamWiFiService) getSKUs(ctx context.Context, path string) ([]SKUInfo, error) {
+	var data []roamWiFiSKUData
+	if err := r.read(ctx, path, map[string]string{}, &data); err != nil {
+		return nil, err
 	}
-	fullURL := apiURL + "?" + values.Encode()
-	fmt.Printf("GetSkus URL: %s (cache miss)\n", fullURL)
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+	skuList := make([]SKUInfo, 0, len(data))
+	for _, d := range data {
+		skuList = append(skuList, d.info())
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	return skuList, nil
+}
+
+// GetSKUList retrieves the list of available eSIM SKUs from production API. Caching is
+// left to CatalogCache; the last list fetched is only kept for provider outages.
+func (r *RoamWiFiService) GetSKUList(ctx context.Context) ([]SKUInfo, error) {
+	skuList, err := r.getSKUs(ctx, "/api_esim/getSkus")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-	fmt.Printf("SKU List API Response: %s\n", truncateForLog(string(body), 800))
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-	var codeStr string
-	switch v := result["code"].(type) {
-	case float64:
-		codeStr = fmt.Sprintf("%.0f", v)
-	case string:
-		codeStr = v
-	default:
-		return nil, fmt.Errorf("unexpected code type: %T", v)
-	}
-	if codeStr != "0" {
-		if msg, ok := result["message"].(string); ok {
-			return nil, fmt.Errorf("API error: %s", msg)
-		}
-		return nil, fmt.Errorf("API error code=%s body=%v", codeStr, result)
-	}
-	arr, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected data format")
-	}
-	var skuList []SKUInfo
-	for _, item := range arr {
-		if m, ok := item.(map[string]interface{}); ok {
-			sku := SKUInfo{}
-			if v, ok := m["skuid"].(float64); ok {
-				sku.SKUID = int(v)
-			}
-			if v, ok := m["display"].(string); ok {
-				sku.Display = v
-			}
-			if v, ok := m["countryCode"].(string); ok {
-				sku.CountryCode = v
-			}
-			skuList = append(skuList, sku)
+		r.cacheMu.RLock()
+		stale := append([]SKUInfo(nil), r.lastSKUs...)
+		r.cacheMu.RUnlock()
+		if len(stale) > 0 && IsUpstreamUnavailable(err) {
+			logrus.WithError(err).Warn("RoamWiFi unavailable, serving cached SKU list")
+			return stale, nil
 		}
+		return nil, err
 	}
-	// Store in cache (TTL 10 minutes)
 	r.cacheMu.Lock()
-	r.skuCache = skuList
-	r.skuCacheExpiry = time.Now().Add(10 * time.Minute)
+	r.lastSKUs = skuList
 	r.cacheMu.Unlock()
 	return skuList, nil
 }
 
-// InvalidateSKUCache clears the in-memory SKU list cache
-func (r *RoamWiFiService) InvalidateSKUCache() {
-	r.cacheMu.Lock()
-	defer r.cacheMu.Unlock()
-	r.skuCache = nil
-	r.skuCacheExpiry = time.Time{}
-}
-
 // truncateForLog safely shortens long payloads for logging
 func truncateForLog(s string, max int) string {
 	if len(s) <= max {
@@ -504,202 +381,47 @@ func truncateForLog(s string, max int) string {
 }
 
 // GetPackagesBySKU retrieves available packages for a specific SKU (legacy signed API)
-func (r *RoamWiFiService) GetPackagesBySKU(skuID string) ([]PackageInfo, error) {
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
-	apiURL := fmt.Sprintf("%s/api_esim/getPackages", r.config.APIURL)
-	params := map[string]string{"token": r.token, "skuId": skuID}
-	params["sign"] = r.generateSignature(params)
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := apiURL + "?" + values.Encode()
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+func (r *RoamWiFiService) GetPackagesBySKU(ctx context.Context, skuID string) ([]PackageInfo, error) {
+	data, err := r.getPackages(ctx, skuID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-	fmt.Printf("GetPackages URL=%s RAW=%s\n", fullURL, string(body))
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-	codeVal := fmt.Sprint(result["code"])
-	if codeVal != "0" && codeVal != "200" {
-		if msg, ok := result["message"].(string); ok {
-			return nil, fmt.Errorf("API error: %s", msg)
-		}
-		return nil, fmt.Errorf("API error code=%s body=%s", codeVal, string(body))
-	}
-	dataObj, ok := result["data"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected data format: data not object keys=%v", keysOf(result))
-	}
-	list, ok := dataObj["esimPackageDtoList"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("missing esimPackageDtoList in data keys=%v", keysOf(dataObj))
-	}
-	var countries string
-	if sc, ok := dataObj["supportCountry"].([]interface{}); ok {
-		var cs []string
-		for _, c := range sc {
-			if s, ok := c.(string); ok {
-				cs = append(cs, s)
-			}
-		}
-		countries = strings.Join(cs, ",")
+		return nil, err
 	}
-	pkgs := make([]PackageInfo, 0, len(list))
-	for _, raw := range list {
-		pm, ok := raw.(map[string]interface{})
-		if !ok {
-			continue
+	countries := strings.Join(data.SupportCountry, ",")
+	pkgs := make([]PackageInfo, 0, len(data.Packages))
+	for _, p := range data.Packages {
+		pkg := PackageInfo{
+			PackageID:   p.APICode,
+			PackageName: p.ShowName,
+			Validity:    p.Days.Int(),
+			Price:       float64(p.Price),
+			Countries:   countries,
 		}
-		pkg := PackageInfo{}
-		if v, ok := pm["apiCode"].(string); ok {
-			pkg.PackageID = v
+		if pkg.PackageID == "" && p.PriceID != 0 {
+			pkg.PackageID = fmt.Sprintf("priceid-%d", p.PriceID.Int())
 		}
-		if pkg.PackageID == "" {
-			if v, ok := pm["priceid"].(float64); ok {
-				pkg.PackageID = fmt.Sprintf("priceid-%d", int(v))
-			}
-		}
-		if pkg.PackageID == "" {
-			if v, ok := pm["pid"].(float64); ok {
-				pkg.PackageID = fmt.Sprintf("pid-%d", int(v))
-			}
-		}
-		if v, ok := pm["showName"].(string); ok && v != "" {
-			pkg.PackageName = v
+		if pkg.PackageID == "" && p.PID != 0 {
+			pkg.PackageID = fmt.Sprintf("pid-%d", p.PID.Int())
 		}
 		if pkg.PackageName == "" {
-			if v, ok := pm["premark"].(string); ok {
-				pkg.PackageName = truncatePremark(v)
-			}
-		}
-		if flows, ok := pm["flows"].(float64); ok {
-			if unit, ok := pm["unit"].(string); ok {
-				pkg.DataLimit = fmt.Sprintf("%d%s", int(flows), unit)
-			}
+			pkg.PackageName = truncatePremark(p.Premark)
 		}
-		if v, ok := pm["days"].(float64); ok {
-			pkg.Validity = int(v)
+		if p.Unit != "" {
+			pkg.DataLimit = fmt.Sprintf("%d%s", p.Flows.Int(), p.Unit)
 		}
-		if v, ok := pm["price"].(float64); ok {
-			pkg.Price = v
-		}
-		pkg.Countries = countries
 		pkgs = append(pkgs, pkg)
 	}
 	return pkgs, nil
 }
 
 // GetSKUsByContinent retrieves SKUs grouped by continent from production API
-func (r *RoamWiFiService) GetSKUsByContinent() ([]SKUInfo, error) {
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
-
-	// Use the exact same URL pattern as working code
-	apiURL := fmt.Sprintf("%s/api_esim/getSkuByGroup", r.config.APIURL)
-
-	params := map[string]string{
-		"token": r.token,
-	}
-	signature := r.generateSignature(params)
-	params["sign"] = signature
-
-	// Build URL with query parameters - POST request like working code
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := apiURL + "?" + values.Encode()
-
-	req, err := http.NewRequest("POST", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-
-	fmt.Printf("SKU By Continent API Response: %s\n", string(body))
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	// Check for successful response (code should be 0 for success)
-	var codeStr string
-	if code, ok := result["code"].(float64); ok {
-		codeStr = fmt.Sprintf("%.0f", code)
-	} else if code, ok := result["code"].(string); ok {
-		codeStr = code
-	} else {
-		return nil, fmt.Errorf("unexpected code type: %T", result["code"])
-	}
-
-	if codeStr != "0" {
-		if message, exists := result["message"].(string); exists {
-			return nil, fmt.Errorf("API error: %s", message)
-		}
-		return nil, fmt.Errorf("API error with code: %v", result["code"])
-	}
-
-	// Parse data field
-	dataField, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected data format")
-	}
-
-	var skuList []SKUInfo
-	for _, item := range dataField {
-		if skuMap, ok := item.(map[string]interface{}); ok {
-			sku := SKUInfo{}
-			if skuID, exists := skuMap["skuid"]; exists {
-				if skuIDFloat, ok := skuID.(float64); ok {
-					sku.SKUID = int(skuIDFloat)
-				}
-			}
-			if display, exists := skuMap["display"]; exists {
-				if displayStr, ok := display.(string); ok {
-					sku.Display = displayStr
-				}
-			}
-			if countryCode, exists := skuMap["countryCode"]; exists {
-				if countryCodeStr, ok := countryCode.(string); ok {
-					sku.CountryCode = countryCodeStr
-				}
-			}
-			skuList = append(skuList, sku)
-		}
-	}
-
-	return skuList, nil
+func (r *RoamWiFiService) GetSKUsByContinent(ctx context.Context) ([]SKUInfo, error) {
+	return r.getSKUs(ctx, "/api_esim/getSkuByGroup")
 }
 
 // GetPackagesBySKUBearer retains the newer bearer-based implementation for potential future use
-func (r *RoamWiFiService) GetPackagesBySKUBearer(skuID string) ([]PackageInfo, error) {
+func (r *RoamWiFiService) GetPackagesBySKUBearer(ctx context.Context, skuID string) ([]PackageInfo, error) {
 	url := fmt.Sprintf("%s/sku/%s/packages", r.config.APIURL, skuID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -734,15 +456,10 @@ func (r *RoamWiFiService) GetPackagesBySKUBearer(skuID string) ([]PackageInfo, e
 	return packages, nil
 }
 
-// CreateOrder creates an order (legacy signed endpoint)
-func (r *RoamWiFiService) CreateOrder(req OrderRequest) (*RoamWiFiOrderResponse, error) {
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
-
-	apiURL := fmt.Sprintf("%s/api_order/createOrder", r.config.APIURL)
+// CreateOrder creates an order (legacy signed endpoint). It is never retried here: the
+// endpoint takes no idempotency key, so a timed-out attempt may already have placed the order.
+func (r *RoamWiFiService) CreateOrder(ctx context.Context, req OrderRequest) (*RoamWiFiOrderResponse, error) {
 	params := map[string]string{
-		"token":          r.token,
 		"sku_id":         req.SKUID,
 		"package_id":     req.PackageID,
 		"customer_email": req.CustomerEmail,
@@ -755,75 +472,40 @@ func (r *RoamWiFiService) CreateOrder(req OrderRequest) (*RoamWiFiOrderResponse,
 			delete(params, k)
 		}
 	}
-	params["sign"] = r.generateSignature(params)
-
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := apiURL + "?" + values.Encode()
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-	fmt.Printf("CreateOrder URL=%s RAW=%s\n", fullURL, string(body))
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	var codeStr string
-	switch v := result["code"].(type) {
-	case float64:
-		codeStr = fmt.Sprintf("%.0f", v)
-	case string:
-		codeStr = v
-	}
-	if codeStr != "0" && codeStr != "200" {
-		if msg, ok := result["message"].(string); ok {
-			return nil, fmt.Errorf("API error: %s", msg)
-		}
-		return nil, fmt.Errorf("API error code=%s body=%s", codeStr, string(body))
-	}
 
-	data, _ := result["data"].(map[string]interface{})
-	if data == nil {
-		return nil, fmt.Errorf("missing data field body=%s", string(body))
+	var data struct {
+		OrderID        string                 `json:"order_id"`
+		OrderIDAlt     string                 `json:"orderId"`
+		Status         string                 `json:"status"`
+		QRCode         string                 `json:"qr_code"`
+		QRCodeAlt      string                 `json:"qrcode"`
+		ActivationCode string                 `json:"activation_code"`
+		ESIMData       map[string]interface{} `json:"esim_data"`
 	}
-	respObj := &RoamWiFiOrderResponse{}
-	if v, ok := data["order_id"].(string); ok {
-		respObj.OrderID = v
-	} else if v, ok := data["orderId"].(string); ok {
-		respObj.OrderID = v
-	}
-	if v, ok := data["status"].(string); ok {
-		respObj.Status = v
+	if err := r.call(ctx, "/api_order/createOrder", params, &data); err != nil {
+		return nil, err
 	}
-	if v, ok := data["qr_code"].(string); ok {
-		respObj.QRCode = v
-	} else if v, ok := data["qrcode"].(string); ok {
-		respObj.QRCode = v
+	respObj := &RoamWiFiOrderResponse{
+		OrderID:        data.OrderID,
+		Status:         data.Status,
+		QRCode:         data.QRCode,
+		ActivationCode: data.ActivationCode,
+		ESIMData:       data.ESIMData,
 	}
-	if v, ok := data["activation_code"].(string); ok {
-		respObj.ActivationCode = v
+	if respObj.OrderID == "" {
+		respObj.OrderID = data.OrderIDAlt
 	}
-	if esim, ok := data["esim_data"].(map[string]interface{}); ok {
-		respObj.ESIMData = esim
+	if respObj.QRCode == "" {
+		respObj.QRCode = data.QRCodeAlt
 	}
 	return respObj, nil
 }
 
 // GetOrderInfo retrieves order information by order ID
-func (r *RoamWiFiService) GetOrderInfo(orderID string) (*OrderInfo, error) {
+func (r *RoamWiFiService) GetOrderInfo(ctx context.Context, orderID string) (*OrderInfo, error) {
 	url := fmt.Sprintf("%s/order/%s", r.config.APIURL, orderID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -861,10 +543,10 @@ func (r *RoamWiFiService) GetOrderInfo(orderID string) (*OrderInfo, error) {
 }
 
 // GetOrderList retrieves the list of orders
-func (r *RoamWiFiService) GetOrderList(page, limit int) ([]OrderInfo, error) {
+func (r *RoamWiFiService) GetOrderList(ctx context.Context, page, limit int) ([]OrderInfo, error) {
 	url := fmt.Sprintf("%s/orders?page=%d&limit=%d", r.config.APIURL, page, limit)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -902,7 +584,7 @@ func (r *RoamWiFiService) GetOrderList(page, limit int) ([]OrderInfo, error) {
 }
 
 // VerifyResources verifies if resources are available
-func (r *RoamWiFiService) VerifyResources(skuID, packageID string) (bool, error) {
+func (r *RoamWiFiService) VerifyResources(ctx context.Context, skuID, packageID string) (bool, error) {
 	url := fmt.Sprintf("%s/verify/resources", r.config.APIURL)
 
 	reqBody := map[string]string{
@@ -915,7 +597,7 @@ func (r *RoamWiFiService) VerifyResources(skuID, packageID string) (bool, error)
 		return false, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBodyBytes))
 	if err != nil {
 		return false, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -958,7 +640,7 @@ func (r *RoamWiFiService) VerifyResources(skuID, packageID string) (bool, error)
 }
 
 // SendPDFEmail sends PDF email with eSIM details
-func (r *RoamWiFiService) SendPDFEmail(orderID, email string) error {
+func (r *RoamWiFiService) SendPDFEmail(ctx context.Context, orderID, email string) error {
 	url := fmt.Sprintf("%s/order/%s/send-pdf", r.config.APIURL, orderID)
 
 	reqBody := map[string]string{
@@ -970,7 +652,7 @@ func (r *RoamWiFiService) SendPDFEmail(orderID, email string) error {
 		return fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBodyBytes))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -997,10 +679,10 @@ func (r *RoamWiFiService) SendPDFEmail(orderID, email string) error {
 }
 
 // GetSKUByID retrieves a specific SKU by ID using the legacy signed getSkus list
-func (r *RoamWiFiService) GetSKUByID(skuID string) (*SKUInfo, error) {
+func (r *RoamWiFiService) GetSKUByID(ctx context.Context, skuID string) (*SKUInfo, error) {
 	// Reuse existing list method (signed API). This avoids the bearer /sku/{id} path
 	// which returned HTML and caused JSON decode errors.
-	list, err := r.GetSKUList()
+	list, err := r.GetSKUList(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1032,37 +714,16 @@ func truncatePremark(s string) string {
 	return cleaned
 }
 
-func keysOf(m map[string]interface{}) []string {
-	ks := make([]string, 0, len(m))
-	for k := range m {
-		ks = append(ks, k)
-	}
-	return ks
-}
-
 // GetPackagesRaw mirrors legacy GetPackages returning raw decoded map
-func (r *RoamWiFiService) GetPackagesRaw(skuID string) (map[string]interface{}, error) {
-	if err := r.ensureAuthenticated(); err != nil {
-		return nil, fmt.Errorf("authentication failed: %v", err)
-	}
-	apiURL := fmt.Sprintf("%s/api_esim/getPackages", r.config.APIURL)
-	params := map[string]string{"token": r.token, "skuId": skuID}
-	params["sign"] = r.generateSignature(params)
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, v)
-	}
-	fullURL := apiURL + "?" + values.Encode()
-	resp, err := http.Post(fullURL, "application/x-www-form-urlencoded", nil)
+func (r *RoamWiFiService) GetPackagesRaw(ctx context.Context, skuID string) (map[string]interface{}, error) {
+	var body []byte
+	err := r.retry.do(ctx, func() (err error) {
+		_, body, err = r.callRaw(ctx, "/api_esim/getPackages", map[string]string{"skuId": skuID})
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return nil, err
 	}
-	fmt.Printf("GetPackagesRaw URL=%s RAW=%s\n", fullURL, string(body))
 	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %v", err)
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// roamWiFiTokenSkew renews the token slightly before it expires
+const roamWiFiTokenSkew = time.Minute
+
+// RoamWiFiSessionStatus reports the health of the RoamWiFi login session
+type RoamWiFiSessionStatus struct {
+	Authenticated  bool       `json:"authenticated"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	LoginCount     int        `json:"login_count"`
+	LoginFailures  int        `json:"login_failures"`
+	Invalidations  int        `json:"invalidations"`
+	LoginInFlight  bool       `json:"login_in_flight"`
+}
+
+// roamWiFiSession caches the RoamWiFi token until it expires. Concurrent callers that
+// find no usable token share a single login instead of each performing their own.
+type roamWiFiSession struct {
+	mu       sync.Mutex
+	login    func(ctx context.Context) (string, error)
+	ttl      time.Duration
+	now      func() time.Time
+	token    string
+	expiry   time.Time
+	inflight *roamWiFiLoginCall
+
+	lastLogin     time.Time
+	lastError     string
+	lastErrorAt   time.Time
+	logins        int
+	loginFailures int
+	invalidations int
+}
+
+type roamWiFiLoginCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+func newRoamWiFiSession(login func(ctx context.Context) (string, error), ttl time.Duration) *roamWiFiSession {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &roamWiFiSession{login: login, ttl: ttl, now: time.Now}
+}
+
+// Token returns the cached token, logging in first when it is missing or about to expire.
+// The shared login is detached from ctx so one caller giving up does not fail the others;
+// each caller still stops waiting when its own ctx is done.
+func (s *roamWiFiSession) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	if s.token != "" && s.now().Add(roamWiFiTokenSkew).Before(s.expiry) {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	if call := s.inflight; call != nil {
+		s.mu.Unlock()
+		return call.wait(ctx)
+	}
+	call := &roamWiFiLoginCall{done: make(chan struct{})}
+	s.inflight = call
+	s.mu.Unlock()
+
+	go s.runLogin(context.WithoutCancel(ctx), call)
+	return call.wait(ctx)
+}
+
+func (s *roamWiFiSession) runLogin(ctx context.Context, call *roamWiFiLoginCall) {
+	call.token, call.err = s.login(ctx)
+
+	s.mu.Lock()
+	now := s.now()
+	s.inflight = nil
+	if call.err != nil {
+		s.loginFailures++
+		s.lastError = call.err.Error()
+		s.lastErrorAt = now
+	} else {
+		s.token = call.token
+		s.expiry = now.Add(s.ttl)
+		s.lastLogin = now
+		s.logins++
+	}
+	s.mu.Unlock()
+	close(call.done)
+}
+
+func (c *roamWiFiLoginCall) wait(ctx context.Context) (string, error) {
+	select {
+	case <-c.done:
+		return c.token, c.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Invalidate drops token if it is still the cached one, so the next call logs in again.
+// A token already replaced by a concurrent re-login is left alone.
+func (s *roamWiFiSession) Invalidate(token string, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token == "" || token != s.token {
+		return
+	}
+	s.token = ""
+	s.expiry = time.Time{}
+	s.invalidations++
+	s.lastError = reason
+	s.lastErrorAt = s.now()
+}
+
+// Status returns a snapshot of the session state
+func (s *roamWiFiSession) Status() RoamWiFiSessionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := RoamWiFiSessionStatus{
+		Authenticated: s.token != "" && s.now().Before(s.expiry),
+		LastError:     s.lastError,
+		LoginCount:    s.logins,
+		LoginFailures: s.loginFailures,
+		Invalidations: s.invalidations,
+		LoginInFlight: s.inflight != nil,
+	}
+	if status.Authenticated {
+		expiry := s.expiry
+		status.TokenExpiresAt = &expiry
+	}
+	if !s.lastLogin.IsZero() {
+		lastLogin := s.lastLogin
+		status.LastLoginAt = &lastLogin
+	}
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		status.LastErrorAt = &lastErrorAt
+	}
+	return status
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"esim-platform/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoamWiFiSessionReusesToken(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logins int
+	session := newRoamWiFiSession(func(context.Context) (string, error) {
+		logins++
+		return "token", nil
+	}, time.Hour)
+	session.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		token, err := session.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, 1, logins)
+
+	// Renewed shortly before expiry
+	now = now.Add(time.Hour - roamWiFiTokenSkew/2)
+	_, err := session.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, logins)
+
+	// Invalidating a stale token keeps the current one
+	session.Invalidate("old", "rejected")
+	assert.True(t, session.Status().Authenticated)
+	session.Invalidate("token", "rejected")
+	status := session.Status()
+	assert.False(t, status.Authenticated)
+	assert.Equal(t, 1, status.Invalidations)
+}
+
+func TestRoamWiFiSessionSingleFlight(t *testing.T) {
+	var logins int32
+	release := make(chan struct{})
+	session := newRoamWiFiSession(func(context.Context) (string, error) {
+		atomic.AddInt32(&logins, 1)
+		<-release
+		return "token", nil
+	}, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := session.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token", token)
+		}()
+	}
+	require.Eventually(t, func() bool { return session.Status().LoginInFlight }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&logins))
+}
+
+func TestRoamWiFiSessionLoginFailure(t *testing.T) {
+	session := newRoamWiFiSession(func(context.Context) (string, error) {
+		return "", errors.New("bad credentials")
+	}, time.Hour)
+
+	_, err := session.Token(context.Background())
+	assert.Error(t, err)
+	status := session.Status()
+	assert.Equal(t, 1, status.LoginFailures)
+	assert.Equal(t, "bad credentials", status.LastError)
+}
+
+func TestRoamWiFiReloginOnRejectedToken(t *testing.T) {
+	var logins, calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api_order/login":
+			n := atomic.AddInt32(&logins, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]string{"token": "t" + string(rune('0'+n))}})
+		case "/api_esim/getSkus":
+			atomic.AddInt32(&calls, 1)
+			if r.URL.Query().Get("token") == "t1" {
+				json.NewEncoder(w).Encode(map[string]interface{}{"code": 401, "message": "token expired"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": []map[string]interface{}{{"skuid": 1, "display": "Japan"}}})
+		}
+	}))
+	defer server.Close()
+
+	rw := NewRoamWiFiService(config.RoamWiFiConfig{APIURL: server.URL, PhoneNumber: "99999999", Password: "secret"})
+	skus, err := rw.GetSKUList(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, skus, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&logins))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	status := rw.Status()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 1, status.Session.Invalidations)
+}
+
+func TestRoamWiFiSessionCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	session := newRoamWiFiSession(func(ctx context.Context) (string, error) {
+		<-release
+		return "token", ctx.Err()
+	}, time.Hour)
+
+	// A caller that gives up does not abort the shared login
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := session.Token(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	token, err := session.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Equal(t, 1, session.Status().LoginCount)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Provider error kinds. RoamWiFi API errors wrap one of these when the code or message
+// identifies the failure, so callers can use errors.Is instead of matching strings.
+// ErrProviderOutOfStock and ErrProviderInvalidPackage are not mapped from RoamWiFi results
+// yet (see roamWiFiErrorCodes); only FakeESIMProvider returns them today.
+var (
+	ErrProviderAuthExpired    = errors.New("provider session expired")
+	ErrProviderOutOfStock     = errors.New("provider package out of stock")
+	ErrProviderInvalidPackage = errors.New("invalid provider package")
+	ErrProviderRateLimited    = errors.New("provider rate limit exceeded")
+)
+
+// roamWiFiErrorCodes maps known RoamWiFi result codes to error kinds.
+//
+// TODO: out-of-stock and invalid-package codes are not mapped yet. Those kinds dead-letter
+// provisioning and refund the order, so they may only come from a documented code, and the
+// RoamWiFi API documentation we have lists none. Until it does, such failures stay
+// unclassified and are retried until the job is dead-lettered.
+var roamWiFiErrorCodes = map[string]error{
+	"401": ErrProviderAuthExpired,
+	"403": ErrProviderAuthExpired,
+	"429": ErrProviderRateLimited,
+}
+
+// roamWiFiErrorMessages classifies errors by message when the code is not specific.
+// RoamWiFi answers in English or Chinese depending on the endpoint. An auth match makes
+// callRaw log in again and re-send the request, CreateOrder included, so those phrases
+// must name an expired or invalid session, not merely mention a token.
+var roamWiFiErrorMessages = []struct {
+	kind     error
+	keywords []string
+}{
+	{ErrProviderAuthExpired, []string{
+		"token expired", "token has expired", "token invalid", "token is invalid", "invalid token",
+		"not logged in", "please login", "please log in", "login expired",
+		"token过期", "token失效", "令牌过期", "令牌失效", "登录过期", "登录失效", "请登录", "未登录",
+	}},
+	{ErrProviderRateLimited, []string{"too many", "frequent", "rate limit", "频繁"}},
+}
+
+// RoamWiFiError is a non-success result returned by the RoamWiFi API
+type RoamWiFiError struct {
+	Endpoint string
+	Code     string
+	Message  string
+	Kind     error // one of the ErrProvider* values, or nil when unclassified
+}
+
+func (e *RoamWiFiError) Error() string {
+	return fmt.Sprintf("API error: %s (endpoint=%s code=%s)", e.Message, e.Endpoint, e.Code)
+}
+
+func (e *RoamWiFiError) Unwrap() error { return e.Kind }
+
+func classifyRoamWiFiError(code, message string) error {
+	if kind, ok := roamWiFiErrorCodes[code]; ok {
+		return kind
+	}
+	msg := strings.ToLower(message)
+	for _, m := range roamWiFiErrorMessages {
+		for _, kw := range m.keywords {
+			if strings.Contains(msg, kw) {
+				return m.kind
+			}
+		}
+	}
+	return nil
+}
+
+// roamWiFiString decodes values the provider sends either as a string or a number
+type roamWiFiString string
+
+func (s *roamWiFiString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = roamWiFiString(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err != nil {
+		return err
+	}
+	*s = roamWiFiString(num.String())
+	return nil
+}
+
+// roamWiFiNumber decodes numbers the provider sometimes sends as strings
+type roamWiFiNumber float64
+
+func (n *roamWiFiNumber) UnmarshalJSON(b []byte) error {
+	var s roamWiFiString
+	if err := s.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(s), 64)
+	if err != nil {
+		return err
+	}
+	*n = roamWiFiNumber(f)
+	return nil
+}
+
+func (n roamWiFiNumber) Int() int { return int(n) }
+
+// roamWiFiEnvelope is the response wrapper shared by the signed RoamWiFi endpoints
+type roamWiFiEnvelope struct {
+	Code    roamWiFiString  `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (e *roamWiFiEnvelope) ok() bool { return e.Code == "0" || e.Code == "200" }
+
+func (e *roamWiFiEnvelope) err(path string) error {
+	if e.ok() {
+		return nil
+	}
+	message := e.Message
+	if message == "" {
+		message = "no message"
+	}
+	return &RoamWiFiError{Endpoint: path, Code: string(e.Code), Message: message, Kind: classifyRoamWiFiError(string(e.Code), e.Message)}
+}
+
+// send signs params with generateSignature and POSTs them to path using the service
+// client. It returns the decoded envelope and raw body without checking the result code.
+func (r *RoamWiFiService) send(ctx context.Context, path string, params map[string]string) (*roamWiFiEnvelope, []byte, error) {
+	signed := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		signed[k] = v
+	}
+	signed["sign"] = r.generateSignature(signed)
+	values := url.Values{}
+	for k, v := range signed {
+		values.Add(k, v)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.APIURL+path+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body []byte
+	err = r.breaker.do(ctx, func() error {
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return transient(fmt.Errorf("failed to make request: %w", err))
+		}
+		defer resp.Body.Close()
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return transient(fmt.Errorf("failed to read response: %w", err))
+		}
+		logrus.WithFields(logrus.Fields{"endpoint": path, "status": resp.StatusCode}).Debugf("RoamWiFi response: %s", truncateForLog(string(body), 800))
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return transient(&RoamWiFiError{Endpoint: path, Code: strconv.Itoa(resp.StatusCode), Message: http.StatusText(resp.StatusCode), Kind: ErrProviderRateLimited})
+		case resp.StatusCode >= http.StatusInternalServerError:
+			return transient(&RoamWiFiError{Endpoint: path, Code: strconv.Itoa(resp.StatusCode), Message: http.StatusText(resp.StatusCode), Kind: ErrUpstreamUnavailable})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, body, err
+	}
+	var env roamWiFiEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, body, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return &env, body, nil
+}
+
+// callRaw sends a token-authenticated request and fails on a non-success result code.
+// When the provider rejects the token, the session is renewed and the call retried once;
+// the provider checks the token before doing anything, so this is safe for CreateOrder too.
+func (r *RoamWiFiService) callRaw(ctx context.Context, path string, params map[string]string) (*roamWiFiEnvelope, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := r.session.Token(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("authentication failed: %w", err)
+		}
+		withToken := make(map[string]string, len(params)+1)
+		for k, v := range params {
+			withToken[k] = v
+		}
+		withToken["token"] = token
+
+		env, body, err := r.send(ctx, path, withToken)
+		if err != nil {
+			return nil, body, err
+		}
+		if err := env.err(path); err != nil {
+			if errors.Is(err, ErrProviderAuthExpired) {
+				r.session.Invalidate(token, err.Error())
+				if attempt == 0 {
+					continue
+				}
+			}
+			if errors.Is(err, ErrProviderRateLimited) {
+				err = transient(err)
+			}
+			return env, body, err
+		}
+		return env, body, nil
+	}
+}
+
+// read is call for idempotent endpoints: transient failures are retried with backoff
+func (r *RoamWiFiService) read(ctx context.Context, path string, params map[string]string, out interface{}) error {
+	return r.retry.do(ctx, func() error { return r.call(ctx, path, params, out) })
+}
+
+// call is callRaw followed by decoding the envelope data into out
+func (r *RoamWiFiService) call(ctx context.Context, path string, params map[string]string, out interface{}) error {
+	env, _, err := r.callRaw(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	if len(env.Data) == 0 || string(env.Data) == "null" {
+		return fmt.Errorf("missing data in %s response", path)
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("unexpected %s data format: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"esim-platform/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoamWiFiTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api_order/login":
+			w.Write([]byte(`{"code":"0","data":{"token":"t"}}`))
+		case "/api_esim/getPackages":
+			switch r.URL.Query().Get("skuId") {
+			case "1":
+				w.Write([]byte(`{"code":"200","data":{"skuid":"1","countrycode":392,"esimPackageDtoList":[{"apiCode":"A1","flows":"5","unit":"GB","days":7,"price":"4.5","priceid":11}]}}`))
+			case "2":
+				w.Write([]byte(`{"code":1002,"message":"package sold out"}`))
+			default:
+				w.Write([]byte(`{"code":"429","message":"slow down"}`))
+			}
+		case "/api_order/createOrder":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	rw := NewRoamWiFiService(config.RoamWiFiConfig{APIURL: server.URL, PhoneNumber: "99999999", Password: "secret"})
+
+	detailed, err := rw.GetPackagesDetailed(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, detailed.SKUId)
+	assert.Equal(t, "392", detailed.CountryCode)
+	require.Len(t, detailed.Packages, 1)
+	assert.Equal(t, 4.5, detailed.Packages[0].Price)
+	assert.Equal(t, 11, detailed.Packages[0].PriceID)
+
+	packages, err := rw.GetPackagesBySKU(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "5GB", packages[0].DataLimit)
+
+	// Messages never classify a failure as permanent; only documented codes do
+	_, err = rw.GetPackagesBySKU(context.Background(), "2")
+	assert.NotErrorIs(t, err, ErrProviderOutOfStock)
+	assert.NotErrorIs(t, err, ErrProviderInvalidPackage)
+	var apiErr *RoamWiFiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "1002", apiErr.Code)
+	assert.Nil(t, apiErr.Kind)
+
+	_, err = rw.GetPackagesBySKU(context.Background(), "3")
+	assert.ErrorIs(t, err, ErrProviderRateLimited)
+
+	_, err = rw.CreateOrder(context.Background(), OrderRequest{SKUID: "1", PackageID: "11", Quantity: 1})
+	assert.ErrorIs(t, err, ErrProviderRateLimited)
+}
+
+func TestClassifyRoamWiFiError(t *testing.T) {
+	for message, want := range map[string]error{
+		"Token expired, please login again": ErrProviderAuthExpired,
+		"invalid token":                     ErrProviderAuthExpired,
+		"token失效":                           ErrProviderAuthExpired,
+		"请求过于频繁":                            ErrProviderRateLimited,
+		// Mentioning a token or login is not enough to log in and re-send the request
+		"order token already used":     nil,
+		"login account has no balance": nil,
+		"package sold out":             nil,
+	} {
+		assert.Equal(t, want, classifyRoamWiFiError("500", message), message)
+	}
+	assert.Equal(t, ErrProviderAuthExpired, classifyRoamWiFiError("401", ""))
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidRoundingRule is returned when a rounding rule cannot be parsed
+var ErrInvalidRoundingRule = errors.New("invalid rounding rule")
+
+const (
+	roundingRuleSettingKey = "mnt_rounding_rule"
+	// RoundingWhole rounds to the nearest tugrik; QPay accepts no decimals, so every rule
+	// rounds at least this far
+	RoundingWhole = "whole"
+	// defaultRoundingRule applies until an admin picks a rule
+	defaultRoundingRule = RoundingWhole
+)
+
+// RoundingRule turns a converted MNT price into the price customers see and pay. Rules are:
+//
+//	whole     nearest tugrik
+//	up_<n>    up to the next multiple of n, e.g. up_500 turns 71243.57 into 71500
+//	end_<n>   up to the next price ending in n, e.g. end_900 turns 71243.57 into 71900
+type RoundingRule struct {
+	Name   string
+	step   int64
+	ending int64
+}
+
+// ParseRoundingRule parses a rule name; an empty name is the default rule
+func ParseRoundingRule(name string) (RoundingRule, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == RoundingWhole {
+		return RoundingRule{Name: RoundingWhole, step: 1}, nil
+	}
+	kind, arg, ok := strings.Cut(name, "_")
+	n, err := strconv.Atoi(arg)
+	if !ok || err != nil || n < 1 || n > 1000000 {
+		return RoundingRule{}, fmt.Errorf("%w: %q", ErrInvalidRoundingRule, name)
+	}
+	switch kind {
+	case "up":
+		return RoundingRule{Name: name, step: int64(n)}, nil
+	case "end":
+		// The ending repeats every power of ten above it: 900 every 1000, 99 every 100
+		step := int64(10)
+		for step <= int64(n) {
+			step *= 10
+		}
+		return RoundingRule{Name: name, step: step, ending: int64(n)}, nil
+	}
+	return RoundingRule{}, fmt.Errorf("%w: %q", ErrInvalidRoundingRule, name)
+}
+
+// Apply rounds an MNT amount according to the rule
+func (r RoundingRule) Apply(mnt money.Decimal) money.Decimal {
+	if r.step <= 1 {
+		return mnt.Round(money.MNT.SettlementPlaces())
+	}
+	step := money.FromInt(r.step)
+	// Multiple of step at or below mnt
+	floor := mnt.Sub(mnt.Mod(step))
+	if mnt.Sign() < 0 && !floor.Equal(mnt) {
+		floor = floor.Sub(step)
+	}
+	if r.ending == 0 {
+		if floor.Equal(mnt) {
+			return mnt
+		}
+		return floor.Add(step)
+	}
+	price := floor.Add(money.FromInt(r.ending))
+	if price.LessThan(mnt) {
+		price = price.Add(step)
+	}
+	return price
+}
+
+// GetRoundingRule gets the MNT rounding rule from settings
+func (p *PricingService) GetRoundingRule(ctx context.Context) RoundingRule {
+	var setting models.AdminSetting
+	if err := p.db.WithContext(ctx).Where("setting_key = ?", roundingRuleSettingKey).First(&setting).Error; err == nil {
+		rule, err := ParseRoundingRule(setting.SettingValue)
+		if err == nil {
+			return rule
+		}
+		logrus.Warnf("Ignoring %s setting: %v", roundingRuleSettingKey, err)
+	}
+	rule, _ := ParseRoundingRule(defaultRoundingRule)
+	return rule
+}
+
+// SetRoundingRule stores the MNT rounding rule used for new prices
+func (p *PricingService) SetRoundingRule(ctx context.Context, name string) (RoundingRule, error) {
+	rule, err := ParseRoundingRule(name)
+	if err != nil {
+		return RoundingRule{}, err
+	}
+	setting := models.AdminSetting{
+		SettingKey:   roundingRuleSettingKey,
+		SettingValue: rule.Name,
+		Description:  "Rounding applied to MNT prices (whole, up_<n>, end_<n>)",
+	}
+	if err := p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "setting_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"setting_value", "updated_at"}),
+	}).Create(&setting).Error; err != nil {
+		return RoundingRule{}, fmt.Errorf("failed to save rounding rule: %v", err)
+	}
+	return rule, nil
+}
+
+// roundMNT applies rule to a calculated MNT price, if there is one
+func roundMNT(mnt *money.Decimal, rule RoundingRule) *money.Decimal {
+	if mnt == nil {
+		return nil
+	}
+	rounded := rule.Apply(*mnt)
+	return &rounded
+}
+
+// RepriceMNT recomputes the MNT price of every stored package at the current exchange rate
+// and rounding rule, returning how many packages' prices changed
+func (p *ProductService) RepriceMNT(ctx context.Context) (int, error) {
+	pricing := NewPricingService(p.db)
+	rate, err := pricing.GetRate(ctx, money.USD, money.MNT)
+	if err != nil {
+		return 0, err
+	}
+	rule := pricing.GetRoundingRule(ctx)
+
+	var prices []models.PackagePrice
+	if err := p.db.WithContext(ctx).Find(&prices).Error; err != nil {
+		return 0, fmt.Errorf("failed to load package prices: %v", err)
+	}
+	repriced := 0
+	for i := range prices {
+		before := prices[i]
+		setMNTPrice(&prices[i], &rate, rule)
+		if !mntPriceChanged(&before, &prices[i]) {
+			continue
+		}
+		if err := p.db.WithContext(ctx).Model(&prices[i]).
+			Select("effective_price_mnt", "regular_price_mnt", "exchange_rate", "exchange_rate_id", "rounding_rule").Updates(&prices[i]).Error; err != nil {
+			return repriced, fmt.Errorf("update package price: %w", err)
+		}
+		repriced++
+	}
+	if repriced > 0 {
+		warnCacheInvalidation(p.cache.InvalidateAll(ctx))
+	}
+	return repriced, nil
+}
+
+// mntPriceChanged reports whether setMNTPrice changed anything stored
+func mntPriceChanged(before, after *models.PackagePrice) bool {
+	return !equalDecimalPtr(before.EffectivePriceMNT, after.EffectivePriceMNT) ||
+		!equalDecimalPtr(before.RegularPriceMNT, after.RegularPriceMNT) ||
+		!equalDecimalPtr(before.ExchangeRate, after.ExchangeRate) ||
+		!equalUUIDPtr(before.ExchangeRateID, after.ExchangeRateID) ||
+		before.RoundingRule != after.RoundingRule
+}
+
+func equalDecimalPtr(a, b *money.Decimal) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalUUIDPtr(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
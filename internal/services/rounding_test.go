@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundingRuleApply(t *testing.T) {
+	cases := []struct {
+		rule string
+		in   string
+		want int64
+	}{
+		{"", "71243.57", 71244},
+		{"whole", "71243.2", 71243},
+		{"up_100", "71243.57", 71300},
+		{"up_500", "71243.57", 71500},
+		{"up_1000", "71243.57", 72000},
+		{"up_1000", "71000", 71000},
+		{"up_100", "71200.000001", 71300},
+		{"end_900", "71243.57", 71900},
+		{"end_900", "71950", 72900},
+		{"end_900", "71900", 71900},
+		{"end_99", "14250", 14299},
+	}
+	for _, tc := range cases {
+		rule, err := ParseRoundingRule(tc.rule)
+		require.NoError(t, err, tc.rule)
+		assert.Equal(t, money.FromInt(tc.want), rule.Apply(money.MustParse(tc.in)), "%s(%s)", tc.rule, tc.in)
+	}
+}
+
+func TestParseRoundingRule(t *testing.T) {
+	rule, err := ParseRoundingRule("")
+	require.NoError(t, err)
+	assert.Equal(t, RoundingWhole, rule.Name)
+
+	for _, name := range []string{"up", "up_0", "up_-100", "end_x", "nearest_100", "ceil"} {
+		_, err := ParseRoundingRule(name)
+		assert.ErrorIs(t, err, ErrInvalidRoundingRule, name)
+	}
+}
+
+func TestSetMNTPriceRecordsRate(t *testing.T) {
+	rule, err := ParseRoundingRule("end_900")
+	require.NoError(t, err)
+	oldID, newID := uuid.New(), uuid.New()
+	pp := models.PackagePrice{EffectivePriceUSD: money.MustParse("20.65")}
+
+	setMNTPrice(&pp, &ExchangeRate{ID: &oldID, From: money.USD, To: money.MNT, Rate: money.FromInt(3450)}, rule)
+	require.NotNil(t, pp.EffectivePriceMNT)
+	assert.Equal(t, "71900", pp.EffectivePriceMNT.String())
+	assert.Equal(t, &oldID, pp.ExchangeRateID)
+
+	before := pp
+	setMNTPrice(&pp, nil, rule)
+	assert.False(t, mntPriceChanged(&before, &pp))
+
+	// Same price at a new rate row still records which rate it came from
+	setMNTPrice(&pp, &ExchangeRate{ID: &newID, From: money.USD, To: money.MNT, Rate: money.FromInt(3451)}, rule)
+	assert.Equal(t, "71900", pp.EffectivePriceMNT.String())
+	assert.Equal(t, &newID, pp.ExchangeRateID)
+	assert.True(t, mntPriceChanged(&before, &pp))
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidScheduledPrice is returned for a change without exactly one target and one
+	// of override or markup, or with an empty or past time window
+	ErrInvalidScheduledPrice = errors.New("invalid scheduled price change")
+	// ErrScheduledPriceNotFound is returned when a scheduled price change does not exist
+	ErrScheduledPriceNotFound = errors.New("scheduled price change not found")
+	// ErrScheduledPriceFinished is returned when cancelling a change that already ended
+	ErrScheduledPriceFinished = errors.New("scheduled price change already finished")
+)
+
+// Price source of a package while a scheduled price change applies to it
+const priceSourceSale = "sale"
+
+func validScheduledChange(change *models.ScheduledPriceChange, now time.Time) error {
+	targets := 0
+	if change.PackagePriceID != nil {
+		targets++
+	}
+	if change.SKUID != "" {
+		targets++
+	}
+	if change.PricingRuleID != nil {
+		targets++
+	}
+	switch {
+	case strings.TrimSpace(change.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidScheduledPrice)
+	case targets != 1:
+		return fmt.Errorf("%w: set exactly one of package_price_id, sku_id and pricing_rule_id", ErrInvalidScheduledPrice)
+	case (change.OverridePriceUSD == nil) == (change.MarkupPercent == nil):
+		return fmt.Errorf("%w: set exactly one of override_price_usd and markup_percent", ErrInvalidScheduledPrice)
+	case change.OverridePriceUSD != nil && change.OverridePriceUSD.Sign() <= 0:
+		return fmt.Errorf("%w: override_price_usd must be positive", ErrInvalidScheduledPrice)
+	case change.MarkupPercent != nil && (*change.MarkupPercent < -100 || *change.MarkupPercent > 1000):
+		return fmt.Errorf("%w: markup_percent must be between -100 and 1000", ErrInvalidScheduledPrice)
+	case !change.EndsAt.After(change.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidScheduledPrice)
+	case !change.EndsAt.After(now):
+		return fmt.Errorf("%w: ends_at is in the past", ErrInvalidScheduledPrice)
+	}
+	return nil
+}
+
+// salePrice is the price a change sets for a package, rounded up to the cent for markups
+func salePrice(change models.ScheduledPriceChange, providerPrice money.Decimal) money.Decimal {
+	if change.OverridePriceUSD != nil {
+		return *change.OverridePriceUSD
+	}
+	return providerPrice.AddPercent(*change.MarkupPercent).Ceil(money.USD.SettlementPlaces())
+}
+
+// changeSpecificity ranks how narrowly a change targets packages; a package change beats
+// a SKU change, which beats a rule change
+func changeSpecificity(change models.ScheduledPriceChange) int {
+	switch {
+	case change.PackagePriceID != nil:
+		return 3
+	case change.SKUID != "":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// changeTargets reports whether change applies to pp, which rule won for
+func changeTargets(change models.ScheduledPriceChange, pp *models.PackagePrice, rule *models.PricingRule) bool {
+	switch {
+	case change.PackagePriceID != nil:
+		return *change.PackagePriceID == pp.ID
+	case change.SKUID != "":
+		return change.SKUID == pp.SKUID
+	default:
+		return rule != nil && *change.PricingRuleID == rule.ID
+	}
+}
+
+// saleFor returns the running change that applies to pp: the most specific one, and of
+// those the one that started last
+func saleFor(sales []models.ScheduledPriceChange, pp *models.PackagePrice, rule *models.PricingRule) *models.ScheduledPriceChange {
+	var best *models.ScheduledPriceChange
+	for i := range sales {
+		change := &sales[i]
+		if !changeTargets(*change, pp, rule) {
+			continue
+		}
+		if best == nil || changeSpecificity(*change) > changeSpecificity(*best) ||
+			(changeSpecificity(*change) == changeSpecificity(*best) && change.StartsAt.After(best.StartsAt)) {
+			best = change
+		}
+	}
+	return best
+}
+
+// applySale replaces the regular effective price of an active pp with the price of
+// change, keeping the regular price as the "was" price. A sale never goes below the
+// policy's minimum margin. A nil change clears any earlier sale.
+func applySale(pp *models.PackagePrice, policy models.MarginPolicy, change *models.ScheduledPriceChange) {
+	pp.RegularPriceUSD, pp.RegularPriceMNT, pp.ScheduledChangeID = nil, nil, nil
+	if change == nil || !pp.Active {
+		return
+	}
+	regular := pp.EffectivePriceUSD
+	price := salePrice(*change, pp.RawProviderPrice)
+	if belowMargin(policy, pp.RawProviderPrice, price) {
+		price = minPriceUSD(policy, pp.RawProviderPrice)
+	}
+	id := change.ID
+	pp.RegularPriceUSD = &regular
+	pp.ScheduledChangeID = &id
+	pp.EffectivePriceUSD = price
+	pp.PriceSource = priceSourceSale
+}
+
+// packagePricer prices packages from their pricing rule, their own markup or override,
+// the margin policy and any running scheduled price change
+type packagePricer struct {
+	rules *pricingRules
+	sales []models.ScheduledPriceChange
+}
+
+// price sets the effective USD price of pp and returns the margin violation found, if any
+func (pr *packagePricer) price(pp *models.PackagePrice, policy models.MarginPolicy) *models.PricingAlert {
+	rule := pr.rules.forPackage(pp)
+	alert := priceWithMargin(pp, policy, rule)
+	applySale(pp, policy, saleFor(pr.sales, pp, rule))
+	return alert
+}
+
+func (p *ProductService) loadPackagePricer(ctx context.Context) (*packagePricer, error) {
+	rules, err := p.loadPricingRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := p.runningSales(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &packagePricer{rules: rules, sales: sales}, nil
+}
+
+// runningSales returns the changes whose time window contains now
+func (p *ProductService) runningSales(ctx context.Context, now time.Time) ([]models.ScheduledPriceChange, error) {
+	var sales []models.ScheduledPriceChange
+	if err := p.db.WithContext(ctx).Where("starts_at <= ? AND ends_at > ? AND status <> ?", now, now, models.ScheduledPriceCancelled).
+		Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to load scheduled price changes: %v", err)
+	}
+	return sales, nil
+}
+
+// ListScheduledPriceChanges returns scheduled price changes, latest start first
+func (p *ProductService) ListScheduledPriceChanges(ctx context.Context, page, limit int, status string) ([]models.ScheduledPriceChange, int64, error) {
+	var changes []models.ScheduledPriceChange
+	var total int64
+	query := p.db.WithContext(ctx).Model(&models.ScheduledPriceChange{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("starts_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+// CreateScheduledPriceChange saves a change. An override below a package target's minimum
+// margin is rejected; one that has already started is applied right away.
+func (p *ProductService) CreateScheduledPriceChange(ctx context.Context, change *models.ScheduledPriceChange) error {
+	now := time.Now()
+	if err := validScheduledChange(change, now); err != nil {
+		return err
+	}
+	switch {
+	case change.PackagePriceID != nil:
+		var pp models.PackagePrice
+		if err := p.db.WithContext(ctx).First(&pp, "id = ?", *change.PackagePriceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: package price not found", ErrInvalidScheduledPrice)
+			}
+			return err
+		}
+		policy, err := p.marginPolicyFor(ctx, pp.SKUID)
+		if err != nil {
+			return err
+		}
+		if price := salePrice(*change, pp.RawProviderPrice); belowMargin(policy, pp.RawProviderPrice, price) {
+			return &MarginViolationError{PriceUSD: price, MinPriceUSD: minPriceUSD(policy, pp.RawProviderPrice), MinMarginPercent: policy.MinMarginPercent}
+		}
+	case change.PricingRuleID != nil:
+		var count int64
+		if err := p.db.WithContext(ctx).Model(&models.PricingRule{}).Where("id = ?", *change.PricingRuleID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: pricing rule not found", ErrInvalidScheduledPrice)
+		}
+	}
+
+	change.ID = uuid.New()
+	change.Status = models.ScheduledPriceScheduled
+	if err := p.db.WithContext(ctx).Create(change).Error; err != nil {
+		return fmt.Errorf("failed to save scheduled price change: %v", err)
+	}
+	if change.StartsAt.After(now) {
+		return nil
+	}
+	_, err := p.RunPriceSchedule(ctx, now)
+	if err == nil {
+		err = p.db.WithContext(ctx).First(change, "id = ?", change.ID).Error
+	}
+	return err
+}
+
+// CancelScheduledPriceChange stops a change that has not ended; one that has started is
+// reverted right away
+func (p *ProductService) CancelScheduledPriceChange(ctx context.Context, id uuid.UUID) (*models.ScheduledPriceChange, error) {
+	var change models.ScheduledPriceChange
+	if err := p.db.WithContext(ctx).First(&change, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduledPriceNotFound
+		}
+		return nil, err
+	}
+	if change.Status == models.ScheduledPriceEnded || change.Status == models.ScheduledPriceCancelled {
+		return nil, ErrScheduledPriceFinished
+	}
+	now := time.Now()
+	// Any repricing since it started, not only the scheduler's, may have applied it
+	started := !change.StartsAt.After(now)
+	change.Status = models.ScheduledPriceCancelled
+	change.EndedAt = &now
+	if err := p.db.WithContext(ctx).Model(&change).Select("status", "ended_at").Updates(&change).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel scheduled price change: %v", err)
+	}
+	if started {
+		return &change, p.repriceScheduled(ctx, []models.ScheduledPriceChange{change})
+	}
+	return &change, nil
+}
+
+// RunPriceSchedule starts the changes whose start has passed and ends those whose end has
+// passed, repricing the packages they target. It returns how many changes started or
+// ended.
+func (p *ProductService) RunPriceSchedule(ctx context.Context, now time.Time) (int, error) {
+	var due []models.ScheduledPriceChange
+	if err := p.db.WithContext(ctx).
+		Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)", models.ScheduledPriceScheduled, now, models.ScheduledPriceActive, now).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due price changes: %v", err)
+	}
+
+	var moved []models.ScheduledPriceChange
+	for _, change := range due {
+		from, updates := change.Status, map[string]interface{}{}
+		if change.EndsAt.After(now) {
+			updates["status"], updates["applied_at"] = models.ScheduledPriceActive, now
+		} else {
+			// Ends before the scheduler saw it start: nothing to revert, but record it
+			updates["status"], updates["ended_at"] = models.ScheduledPriceEnded, now
+			if from == models.ScheduledPriceScheduled {
+				updates["applied_at"] = now
+			}
+		}
+		// The status condition lets only one instance move a change
+		result := p.db.WithContext(ctx).Model(&models.ScheduledPriceChange{}).
+			Where("id = ? AND status = ?", change.ID, from).Updates(updates)
+		if result.Error != nil {
+			return len(moved), fmt.Errorf("failed to update scheduled price change: %v", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			logrus.Infof("Scheduled price change %q %s", change.Name, updates["status"])
+			moved = append(moved, change)
+		}
+	}
+	if len(moved) == 0 {
+		return 0, nil
+	}
+	return len(moved), p.repriceScheduled(ctx, moved)
+}
+
+// repriceScheduled reprices the SKUs the changes target, or every SKU when one targets a
+// pricing rule
+func (p *ProductService) repriceScheduled(ctx context.Context, changes []models.ScheduledPriceChange) error {
+	skus := map[string]bool{}
+	for _, change := range changes {
+		switch {
+		case change.PricingRuleID != nil:
+			return p.enforceMarginPolicy(ctx, "")
+		case change.SKUID != "":
+			skus[change.SKUID] = true
+		default:
+			var pp models.PackagePrice
+			if err := p.db.WithContext(ctx).Select("sku_id").First(&pp, "id = ?", *change.PackagePriceID).Error; err != nil {
+				return fmt.Errorf("failed to load scheduled package: %v", err)
+			}
+			skus[pp.SKUID] = true
+		}
+	}
+	for skuID := range skus {
+		if err := p.enforceMarginPolicy(ctx, skuID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PriceScheduler starts and ends scheduled price changes on time
+type PriceScheduler struct {
+	productService *ProductService
+	config         config.PriceScheduleConfig
+}
+
+func NewPriceScheduler(productService *ProductService, cfg config.PriceScheduleConfig) *PriceScheduler {
+	return &PriceScheduler{productService: productService, config: cfg}
+}
+
+// Run checks for due changes every interval until ctx is cancelled
+func (s *PriceScheduler) Run(ctx context.Context) {
+	if s.config.Interval <= 0 {
+		logrus.Warn("Price scheduler disabled: PRICE_SCHEDULE_INTERVAL must be positive; scheduled price changes will not start or end")
+		return
+	}
+	interval := time.Duration(s.config.Interval) * time.Second
+	logrus.Infof("Price scheduler started (interval %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.productService.RunPriceSchedule(ctx, time.Now()); err != nil {
+			logrus.Errorf("Price schedule: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			logrus.Info("Price scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"esim-platform/internal/models"
+	"esim-platform/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidScheduledChange(t *testing.T) {
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	valid := func() models.ScheduledPriceChange {
+		return models.ScheduledPriceChange{Name: "Black Friday", SKUID: "9001", MarkupPercent: floatPtr(5), StartsAt: now, EndsAt: now.Add(72 * time.Hour)}
+	}
+	change := valid()
+	require.NoError(t, validScheduledChange(&change, now))
+
+	packageID := uuid.New()
+	cases := map[string]func(*models.ScheduledPriceChange){
+		"no name":        func(c *models.ScheduledPriceChange) { c.Name = " " },
+		"no target":      func(c *models.ScheduledPriceChange) { c.SKUID = "" },
+		"two targets":    func(c *models.ScheduledPriceChange) { c.PackagePriceID = &packageID },
+		"no price":       func(c *models.ScheduledPriceChange) { c.MarkupPercent = nil },
+		"two prices":     func(c *models.ScheduledPriceChange) { c.OverridePriceUSD = usdPtr("5") },
+		"zero override":  func(c *models.ScheduledPriceChange) { c.MarkupPercent, c.OverridePriceUSD = nil, usdPtr("0") },
+		"markup too low": func(c *models.ScheduledPriceChange) { c.MarkupPercent = floatPtr(-101) },
+		"empty window":   func(c *models.ScheduledPriceChange) { c.EndsAt = c.StartsAt },
+		"already ended": func(c *models.ScheduledPriceChange) {
+			c.StartsAt, c.EndsAt = now.Add(-2*time.Hour), now.Add(-time.Hour)
+		},
+	}
+	for name, mutate := range cases {
+		change := valid()
+		mutate(&change)
+		assert.ErrorIs(t, validScheduledChange(&change, now), ErrInvalidScheduledPrice, name)
+	}
+}
+
+func TestSaleFor(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	rule := &models.PricingRule{ID: uuid.New()}
+	pp := &models.PackagePrice{ID: uuid.New(), SKUID: "9001"}
+	byRule := models.ScheduledPriceChange{ID: uuid.New(), PricingRuleID: &rule.ID, StartsAt: start.Add(2 * time.Hour)}
+	bySKU := models.ScheduledPriceChange{ID: uuid.New(), SKUID: "9001", StartsAt: start}
+	laterSKU := models.ScheduledPriceChange{ID: uuid.New(), SKUID: "9001", StartsAt: start.Add(time.Hour)}
+	byPackage := models.ScheduledPriceChange{ID: uuid.New(), PackagePriceID: &pp.ID, StartsAt: start}
+	otherSKU := models.ScheduledPriceChange{ID: uuid.New(), SKUID: "9002", StartsAt: start.Add(3 * time.Hour)}
+
+	assert.Nil(t, saleFor([]models.ScheduledPriceChange{otherSKU}, pp, rule))
+	assert.Nil(t, saleFor([]models.ScheduledPriceChange{byRule}, pp, nil), "rule target without the rule winning")
+	assert.Equal(t, byRule.ID, saleFor([]models.ScheduledPriceChange{byRule, otherSKU}, pp, rule).ID)
+	assert.Equal(t, bySKU.ID, saleFor([]models.ScheduledPriceChange{byRule, bySKU}, pp, rule).ID)
+	assert.Equal(t, laterSKU.ID, saleFor([]models.ScheduledPriceChange{bySKU, laterSKU}, pp, rule).ID)
+	assert.Equal(t, byPackage.ID, saleFor([]models.ScheduledPriceChange{byRule, laterSKU, byPackage}, pp, rule).ID)
+}
+
+func TestApplySale(t *testing.T) {
+	policy := models.MarginPolicy{MinMarginPercent: 10, Action: models.MarginActionReprice}
+	pp := models.PackagePrice{RawProviderPrice: money.MustParse("10"), MarkupPercent: floatPtr(30), Active: true}
+	change := &models.ScheduledPriceChange{ID: uuid.New(), MarkupPercent: floatPtr(15)}
+
+	require.Nil(t, priceWithMargin(&pp, policy, nil))
+	applySale(&pp, policy, change)
+	assert.Equal(t, "11.5", pp.EffectivePriceUSD.String())
+	assert.Equal(t, priceSourceSale, pp.PriceSource)
+	require.NotNil(t, pp.RegularPriceUSD)
+	assert.Equal(t, "13", pp.RegularPriceUSD.String())
+	assert.Equal(t, &change.ID, pp.ScheduledChangeID)
+
+	rule, err := ParseRoundingRule(RoundingWhole)
+	require.NoError(t, err)
+	setMNTPrice(&pp, &ExchangeRate{From: money.USD, To: money.MNT, Rate: money.FromInt(3450)}, rule)
+	assert.Equal(t, "39675", pp.EffectivePriceMNT.String())
+	require.NotNil(t, pp.RegularPriceMNT)
+	assert.Equal(t, "44850", pp.RegularPriceMNT.String())
+
+	// A sale never goes below the minimum margin
+	priceWithMargin(&pp, policy, nil)
+	applySale(&pp, policy, &models.ScheduledPriceChange{ID: uuid.New(), OverridePriceUSD: usdPtr("9")})
+	assert.Equal(t, "11", pp.EffectivePriceUSD.String())
+
+	// Once it ends the regular price is back and the "was" price is gone
+	priceWithMargin(&pp, policy, nil)
+	applySale(&pp, policy, nil)
+	setMNTPrice(&pp, &ExchangeRate{From: money.USD, To: money.MNT, Rate: money.FromInt(3450)}, rule)
+	assert.Equal(t, "13", pp.EffectivePriceUSD.String())
+	assert.Equal(t, "markup", pp.PriceSource)
+	assert.Nil(t, pp.RegularPriceUSD)
+	assert.Nil(t, pp.RegularPriceMNT)
+	assert.Nil(t, pp.ScheduledChangeID)
+
+	inactive := models.PackagePrice{RawProviderPrice: money.MustParse("10"), EffectivePriceUSD: money.MustParse("10")}
+	applySale(&inactive, policy, change)
+	assert.Nil(t, inactive.ScheduledChangeID)
+	assert.Equal(t, "10", inactive.EffectivePriceUSD.String())
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"esim-platform/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const webhookProviderQPay = "qpay"
+
+var (
+	// ErrDuplicateWebhook is returned when a webhook delivery has already been handled
+	ErrDuplicateWebhook = errors.New("webhook already processed")
+	// ErrPaymentRejected is returned when QPay does not confirm the payment a webhook claims
+	ErrPaymentRejected = errors.New("payment verification failed")
+)
+
+// qpayEventKey identifies a QPay delivery; retries of the same callback share the key
+func qpayEventKey(data *QPayWebhookData) string {
+	return fmt.Sprintf("%s:%s:%s", data.InvoiceID, data.TransactionID, data.PaymentStatus)
+}
+
+// recordWebhookEvent stores the delivery if it has not been seen before
+func (o *OrderService) recordWebhookEvent(ctx context.Context, data *QPayWebhookData, payload []byte) (*models.WebhookEvent, error) {
+	event := models.WebhookEvent{
+		Provider:      webhookProviderQPay,
+		EventKey:      qpayEventKey(data),
+		InvoiceID:     data.InvoiceID,
+		TransactionID: data.TransactionID,
+		OrderNumber:   data.SenderInvoiceNo,
+		PaymentStatus: data.PaymentStatus,
+		Payload:       string(payload),
+		Status:        models.WebhookEventReceived,
+	}
+	if err := o.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("record webhook event: %w", err)
+	}
+	return &event, nil
+}
+
+// lockWebhookEvent loads the stored event row with FOR UPDATE so concurrent deliveries serialize
+func lockWebhookEvent(tx *gorm.DB, event *models.WebhookEvent) (*models.WebhookEvent, error) {
+	var locked models.WebhookEvent
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND event_key = ?", event.Provider, event.EventKey).
+		First(&locked).Error; err != nil {
+		return nil, fmt.Errorf("lock webhook event: %w", err)
+	}
+	return &locked, nil
+}
+
+// finishWebhookEvent stores the processing outcome on the locked event row
+func finishWebhookEvent(tx *gorm.DB, event *models.WebhookEvent, ignoreReason string, procErr error) error {
+	now := time.Now()
+	event.Attempts++
+	switch {
+	case errors.Is(procErr, ErrPaymentRejected):
+		event.Status = models.WebhookEventRejected
+		event.Result = procErr.Error()
+		event.ProcessedAt = &now
+	case procErr != nil:
+		event.Status = models.WebhookEventFailed
+		event.Result = procErr.Error()
+	case ignoreReason != "":
+		event.Status = models.WebhookEventIgnored
+		event.Result = ignoreReason
+		event.ProcessedAt = &now
+	default:
+		event.Status = models.WebhookEventProcessed
+		event.Result = ""
+		event.ProcessedAt = &now
+	}
+	if err := tx.Save(event).Error; err != nil {
+		return fmt.Errorf("update webhook event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"esim-platform/internal/config"
+	"esim-platform/internal/models"
+	"esim-platform/internal/qpaysim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func webhookEvent(t *testing.T, env *orderTestEnv, data *QPayWebhookData) models.WebhookEvent {
+	t.Helper()
+	var event models.WebhookEvent
+	require.NoError(t, env.db.Where("provider = ? AND event_key = ?", webhookProviderQPay, qpayEventKey(data)).First(&event).Error)
+	return event
+}
+
+func TestPaymentWebhookProcessedOnce(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	assert.Equal(t, models.OrderStatusAwaitingPayment, created.Status)
+
+	data := callback(t, env.pay(t, created.OrderNumber, 0), qpaysim.StatusPaid)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil))
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+
+	// A redelivery is recorded once and queues no second provisioning job
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil), ErrDuplicateWebhook)
+	assert.Len(t, env.orderJobs(t, created.ID), 1)
+	event := webhookEvent(t, env, data)
+	assert.Equal(t, models.WebhookEventProcessed, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+
+	var payment models.PaymentTransaction
+	require.NoError(t, env.db.Where("order_id = ? AND type = ?", created.ID, models.PaymentTransactionTypePayment).First(&payment).Error)
+	assert.Equal(t, "paid", payment.Status)
+	assert.Equal(t, data.TransactionID, payment.QPayTransactionID)
+	assert.True(t, payment.Amount.Equal(created.Amount))
+}
+
+func TestPaymentWebhookOutOfOrder(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	pending := callback(t, env.invoice(t, created.OrderNumber), qpaysim.StatusPending)
+
+	paid := callback(t, env.pay(t, created.OrderNumber, 0), qpaysim.StatusPaid)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), paid, nil))
+
+	// The PENDING callback arrives late; it must not move the paid order back
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), pending, nil))
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+	event := webhookEvent(t, env, pending)
+	assert.Equal(t, models.WebhookEventIgnored, event.Status)
+	assert.Equal(t, "order already paid", event.Result)
+}
+
+func TestPaymentWebhookAmountMismatch(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+
+	data := callback(t, env.pay(t, created.OrderNumber, 1000), qpaysim.StatusPaid)
+	err := env.orders.ProcessPaymentWebhook(context.Background(), data, nil)
+	assert.ErrorIs(t, err, ErrPaymentRejected)
+	assert.ErrorContains(t, err, "does not match order amount")
+
+	assert.Equal(t, models.OrderStatusAwaitingPayment, env.order(t, created.ID).Status)
+	assert.Empty(t, env.orderJobs(t, created.ID))
+	assert.Equal(t, models.WebhookEventRejected, webhookEvent(t, env, data).Status)
+}
+
+func TestPaymentWebhookRejectedThenConfirmed(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+
+	// The callback claims PAID before QPay reports the invoice as paid
+	data := callback(t, env.invoice(t, created.OrderNumber), qpaysim.StatusPaid)
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil), ErrPaymentRejected)
+	assert.Equal(t, models.WebhookEventRejected, webhookEvent(t, env, data).Status)
+
+	// Once QPay confirms the payment, the same delivery is verified again and settles the order
+	env.pay(t, created.OrderNumber, 0)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil))
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+	event := webhookEvent(t, env, data)
+	assert.Equal(t, models.WebhookEventProcessed, event.Status)
+	assert.Equal(t, 2, event.Attempts)
+}
+
+func TestReconciledPaymentCallbackIsDuplicate(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	data := callback(t, env.pay(t, created.OrderNumber, 0), qpaysim.StatusPaid)
+
+	// The callback is lost; the reconciler finds the payment instead
+	reconciler := NewPaymentReconciler(env.db, env.orders, env.orders.qpayService, config.ReconcilerConfig{Interval: 60, MaxAge: 3600, MaxBackoff: 600, BatchSize: 10})
+	reconciler.ReconcileOnce(context.Background())
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+
+	// The late callback shares the reconciler's event and changes nothing
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil), ErrDuplicateWebhook)
+	assert.Len(t, env.orderJobs(t, created.ID), 1)
+}
+
+func TestPaymentWebhookCancelledIsVerified(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+
+	// QPay still has the invoice open, so a CANCELLED callback is not trusted
+	forged := callback(t, env.invoice(t, created.OrderNumber), qpaysim.StatusCancelled)
+	assert.ErrorIs(t, env.orders.ProcessPaymentWebhook(context.Background(), forged, nil), ErrPaymentRejected)
+	assert.Equal(t, models.OrderStatusAwaitingPayment, env.order(t, created.ID).Status)
+
+	// The customer can still pay the invoice
+	paid := callback(t, env.pay(t, created.OrderNumber, 0), qpaysim.StatusPaid)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), paid, nil))
+	assert.Equal(t, models.OrderStatusPaid, env.order(t, created.ID).Status)
+
+	other := env.createOrder(t)
+	require.NoError(t, env.sim.CancelInvoice(env.invoice(t, other.OrderNumber).InvoiceID))
+	cancelled := callback(t, env.invoice(t, other.OrderNumber), qpaysim.StatusCancelled)
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), cancelled, nil))
+	assert.Equal(t, models.OrderStatusCancelled, env.order(t, other.ID).Status)
+}
+
+func TestPaymentWebhookUnverifiedStatusNotRecorded(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+
+	data := callback(t, env.invoice(t, created.OrderNumber), "FAILED")
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil))
+	assert.Equal(t, models.WebhookEventIgnored, webhookEvent(t, env, data).Status)
+
+	var payment models.PaymentTransaction
+	require.NoError(t, env.db.Where("order_id = ? AND type = ?", created.ID, models.PaymentTransactionTypePayment).First(&payment).Error)
+	assert.Equal(t, "pending", payment.Status)
+}
+
+func TestPaymentWebhookStoresVerifiedTransactionID(t *testing.T) {
+	env := newOrderTestEnv(t)
+	created := env.createOrder(t)
+	inv := env.pay(t, created.OrderNumber, 0)
+
+	// The callback body is unauthenticated; only QPay's own payment ID is kept
+	data := callback(t, inv, qpaysim.StatusPaid)
+	data.TransactionID = "forged-payment"
+	require.NoError(t, env.orders.ProcessPaymentWebhook(context.Background(), data, nil))
+
+	var payment models.PaymentTransaction
+	require.NoError(t, env.db.Where("order_id = ? AND type = ?", created.ID, models.PaymentTransactionTypePayment).First(&payment).Error)
+	assert.Equal(t, inv.TransactionID, payment.QPayTransactionID)
+}